@@ -0,0 +1,75 @@
+// Package coretest provides checks for emulator cores to run from their
+// own tests.
+package coretest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// DeterminismFrames is how many frames to replay when checking
+// determinism. Run-ahead replays a few frames; netplay rollback can
+// replay more.
+const DeterminismFrames = 16
+
+// StateEmulator is an emulator with save states.
+type StateEmulator interface {
+	coreif.Emulator
+	coreif.SaveStater
+}
+
+// CheckDeterminism serializes emu, runs frames frames feeding input to
+// player 0, restores the state, and runs them again. It returns an error
+// at the first frame whose framebuffer or audio differs, or when the
+// final states differ. Run-ahead and netplay rollback need cores to pass
+// this.
+func CheckDeterminism(emu StateEmulator, frames int, input func(frame int) uint32) error {
+	start, err := emu.Serialize()
+	if err != nil {
+		return fmt.Errorf("serialize: %w", err)
+	}
+
+	run := func() (video, audio [][]byte, end []byte, err error) {
+		for i := 0; i < frames; i++ {
+			emu.SetInput(0, input(i))
+			emu.RunFrame()
+			video = append(video, bytes.Clone(emu.GetFramebuffer()))
+			samples := emu.GetAudioSamples()
+			pcm := make([]byte, len(samples)*2)
+			for j, v := range samples {
+				binary.LittleEndian.PutUint16(pcm[j*2:], uint16(v))
+			}
+			audio = append(audio, pcm)
+		}
+		end, err = emu.Serialize()
+		return video, audio, end, err
+	}
+
+	video, audio, end, err := run()
+	if err != nil {
+		return fmt.Errorf("serialize after first run: %w", err)
+	}
+	if err := emu.Deserialize(start); err != nil {
+		return fmt.Errorf("deserialize: %w", err)
+	}
+	video2, audio2, end2, err := run()
+	if err != nil {
+		return fmt.Errorf("serialize after replay: %w", err)
+	}
+
+	for i := range video {
+		if !bytes.Equal(video[i], video2[i]) {
+			return fmt.Errorf("frame %d: framebuffer differs after restore", i)
+		}
+		if !bytes.Equal(audio[i], audio2[i]) {
+			return fmt.Errorf("frame %d: audio differs after restore", i)
+		}
+	}
+	if !bytes.Equal(end, end2) {
+		return fmt.Errorf("state after %d frames differs after restore", frames)
+	}
+	return nil
+}
@@ -50,6 +50,8 @@ type SaveStater interface {
 	Serialize() ([]byte, error)
 
 	// Deserialize restores emulator state from previously serialized data.
+	// data is only valid during the call; frontends reuse the buffer, so
+	// implementations must copy anything they keep.
 	Deserialize(data []byte) error
 }
 
@@ -82,6 +84,11 @@ const (
 type MemoryRegion struct {
 	Type int
 	Size int
+
+	// Address is the flat MemoryInspector address of the region's first
+	// byte, used to build libretro memory maps. Regions that all leave it
+	// at 0 are laid out back to back in MemoryMap order.
+	Address uint32
 }
 
 // MemoryMapper enables libretro-style named memory region access.
@@ -95,3 +102,73 @@ type MemoryMapper interface {
 	// WriteRegion writes data to the specified memory region.
 	WriteRegion(regionType int, data []byte)
 }
+
+// MemoryExposer lets a MemoryMapper core share its memory regions in
+// place so frontends can read and write them without per-frame copies.
+type MemoryExposer interface {
+	// RegionMemory returns the live backing memory for the specified
+	// region, or nil if it can't be shared. The slice must keep the same
+	// address and length until the emulator is closed, and must not be
+	// reallocated by the core.
+	RegionMemory(regionType int) []byte
+}
+
+// OptionVisibility lets a core hide options that don't apply to the
+// loaded ROM or current region. Frontends that support it re-query
+// visibility after options or the region change.
+type OptionVisibility interface {
+	// OptionVisible reports whether the core option identified by key
+	// currently applies.
+	OptionVisible(key string) bool
+}
+
+// ControllerSelector lets a core switch the controller type plugged into
+// a player's port. Used with SystemInfo.Controllers.
+type ControllerSelector interface {
+	// SetController selects the controller for player by Controller.ID.
+	SetController(player int, id int)
+}
+
+// FrameChangeReporter lets a core tell frontends that can reuse the
+// previous frame, such as libretro with frame duping, when nothing was
+// drawn.
+type FrameChangeReporter interface {
+	// FrameChanged reports whether the framebuffer changed during the
+	// last RunFrame. Returning true when unsure is always safe.
+	FrameChanged() bool
+}
+
+// Resetter lets a core reset in place instead of being recreated,
+// keeping save RAM and loaded BIOS.
+type Resetter interface {
+	// Reset resets the system. A soft reset acts like the console's reset
+	// button; a hard reset is a power cycle. Save RAM is kept either way.
+	Reset(hard bool)
+}
+
+// MessageLevel is the severity of a core message.
+type MessageLevel int
+
+const (
+	MessageDebug MessageLevel = iota
+	MessageInfo
+	MessageWarn
+	MessageError
+)
+
+// Message is a log line or user-facing notification from a core.
+type Message struct {
+	Level    MessageLevel
+	Text     string
+	Notify   bool // Also show the message on screen
+	Duration int  // On-screen time in milliseconds; 0 uses the UI default
+}
+
+// MessageReporter lets a core send messages to the UI's log and on-screen
+// notifications.
+type MessageReporter interface {
+	// SetMessageHandler installs the UI's handler. Called after
+	// CreateEmulator and before Start(). The handler may be called from
+	// the emulation goroutine.
+	SetMessageHandler(handler func(Message))
+}
@@ -17,6 +17,14 @@ type Button struct {
 	DefaultPad string // Default gamepad button for standalone UI (e.g., "A", "Start")
 }
 
+// Controller describes a controller type a core can emulate on a player's
+// port, such as a 3-button or 6-button pad.
+type Controller struct {
+	Name    string // Display name, e.g. "6-Button Pad"
+	ID      int    // Core-defined ID passed to ControllerSelector
+	Buttons []int  // Button.IDs present on this controller; nil = all
+}
+
 // CoreOptionType identifies the kind of core option.
 type CoreOptionType int
 
@@ -92,12 +100,14 @@ type SystemInfo struct {
 	Name             string
 	ConsoleName      string
 	Extensions       []string
+	MaxROMSize       int64 // Largest ROM in bytes; 0 = romloader default (8MB)
 	ScreenWidth      int
 	MaxScreenHeight  int
 	PixelAspectRatio float64
 	SampleRate       int
 	Buttons          []Button
 	Players          int
+	Controllers      []Controller // Selectable controller types; first is the default
 	CoreOptions      []CoreOption
 	MetadataVariants []MetadataVariant
 	DataDirName      string
@@ -106,6 +116,7 @@ type SystemInfo struct {
 	CoreVersion      string
 	SerializeSize    int
 	BigEndianMemory  bool // true for big-endian CPUs (e.g. 68K)
+	PerformanceLevel int  // Relative CPU demand hint for frontends (libretro); 0 = unset
 	BIOSOptions      []BIOSOption
 }
 
@@ -127,3 +138,15 @@ type CoreFactory interface {
 	// The bool return indicates whether the region was found in the database.
 	DetectRegion(rom []byte) (Region, bool)
 }
+
+// ROMNormalizer is an optional CoreFactory interface for systems whose
+// dumps come in more than one form, e.g. with copier headers or
+// interleaved. Frontends normalize ROMs before hashing them for database
+// lookups and before CreateEmulator.
+type ROMNormalizer interface {
+	// NormalizeROM converts rom, loaded from a file with the given
+	// lowercase extension (".smd"), to the form ROM databases hash. It
+	// returns the converted ROM and a short description of the change,
+	// or rom unchanged and "" if it was already in that form.
+	NormalizeROM(ext string, rom []byte) ([]byte, string)
+}
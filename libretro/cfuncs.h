@@ -4,27 +4,45 @@
 #include <stdlib.h>
 #include <string.h>
 
+// Callbacks the frontend hasn't set are skipped, so the retro_* functions
+// can also be driven from tests
 static retro_environment_t environ_cb;
 static void _retro_set_environment(retro_environment_t cb) { environ_cb = cb; }
-static bool call_environ_cb(unsigned cmd, void *data) { return environ_cb(cmd, data); }
+static bool call_environ_cb(unsigned cmd, void *data) { return environ_cb && environ_cb(cmd, data); }
 
 static retro_video_refresh_t video_cb;
 static void _retro_set_video_refresh(retro_video_refresh_t cb) { video_cb = cb; }
-static void call_video_cb(const void *data, unsigned width, unsigned height, size_t pitch) { video_cb(data, width, height, pitch); }
+static void call_video_cb(const void *data, unsigned width, unsigned height, size_t pitch) { if (video_cb) video_cb(data, width, height, pitch); }
 
 static retro_audio_sample_t audio_cb;
 static void _retro_set_audio_sample(retro_audio_sample_t cb) { audio_cb = cb; }
 
 static retro_audio_sample_batch_t audio_batch_cb;
 static void _retro_set_audio_sample_batch(retro_audio_sample_batch_t cb) { audio_batch_cb = cb; }
-static size_t call_audio_batch_cb(const int16_t *data, size_t frames) { return audio_batch_cb(data, frames); }
+static size_t call_audio_batch_cb(const int16_t *data, size_t frames) { return audio_batch_cb ? audio_batch_cb(data, frames) : 0; }
 
 static retro_input_poll_t input_poll_cb;
 static void _retro_set_input_poll(retro_input_poll_t cb) { input_poll_cb = cb; }
-static void call_input_poll_cb(void) { input_poll_cb(); }
+static void call_input_poll_cb(void) { if (input_poll_cb) input_poll_cb(); }
 
 static retro_input_state_t input_state_cb;
 static void _retro_set_input_state(retro_input_state_t cb) { input_state_cb = cb; }
-static int16_t call_input_state_cb(unsigned port, unsigned device, unsigned index, unsigned id) { return input_state_cb(port, device, index, id); }
+static int16_t call_input_state_cb(unsigned port, unsigned device, unsigned index, unsigned id) { return input_state_cb ? input_state_cb(port, device, index, id) : 0; }
+
+static retro_set_rumble_state_t rumble_cb;
+static void _retro_set_rumble_state(retro_set_rumble_state_t cb) { rumble_cb = cb; }
+static bool call_rumble_cb(unsigned port, unsigned effect, uint16_t strength) { return rumble_cb && rumble_cb(port, (enum retro_rumble_effect)effect, strength); }
+
+// log_cb is variadic, so Go passes preformatted text through "%s"
+static retro_log_printf_t log_cb;
+static void _retro_set_log(retro_log_printf_t cb) { log_cb = cb; }
+static bool call_log_cb(unsigned level, const char *msg) {
+	if (!log_cb) return false;
+	log_cb((enum retro_log_level)level, "%s\n", msg);
+	return true;
+}
+
+// Exported from Go; passed to the frontend as the options display callback
+extern bool eblitui_update_display(void);
 
 #endif
@@ -0,0 +1,90 @@
+package libretro
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/user-none/eblitui/coreif"
+	"github.com/user-none/eblitui/romloader"
+)
+
+// archiveExtensions are the archive formats romloader opens, in libretro
+// form (lowercase, no dot). Content with these extensions is requested by
+// path so the wrapper extracts it instead of the frontend.
+var archiveExtensions = []string{
+	"zip", "7z", "rar", "gz", "tgz", "zst", "tzst", "xz", "txz",
+	"bz2", "tbz2", "tbz", "lz4", "chd",
+}
+
+// contentExtension converts a SystemInfo extension (".sms") to libretro
+// form ("sms").
+func contentExtension(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// validExtensions returns the system's ROM extensions followed by the
+// archive formats, in libretro form joined by '|'. Empty and repeated
+// extensions are skipped.
+func validExtensions(exts []string) string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, list := range [][]string{exts, archiveExtensions} {
+		for _, e := range list {
+			e = contentExtension(e)
+			if e == "" || seen[e] {
+				continue
+			}
+			seen[e] = true
+			out = append(out, e)
+		}
+	}
+	return strings.Join(out, "|")
+}
+
+// isArchive reports whether path names an archive romloader extracts.
+// ROM extensions take precedence so systems whose ROMs share an archive
+// extension are loaded as-is.
+func isArchive(path string, romExts []string) bool {
+	ext := contentExtension(filepath.Ext(path))
+	for _, e := range romExts {
+		if contentExtension(e) == ext {
+			return false
+		}
+	}
+	for _, e := range archiveExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// needsLoader reports whether content must be read through romloader:
+// the frontend passed only a path, or the path is an archive.
+func needsLoader(path string, size int, romExts []string) bool {
+	if path == "" {
+		return false
+	}
+	return size == 0 || isArchive(path, romExts)
+}
+
+// contentOptions returns the romloader options for the core's content:
+// its size limit and, if the factory implements coreif.ROMNormalizer,
+// its normalization.
+func contentOptions(f coreif.CoreFactory, info coreif.SystemInfo) romloader.Options {
+	opts := romloader.Options{MaxSize: info.MaxROMSize}
+	if n, ok := f.(coreif.ROMNormalizer); ok {
+		opts.Normalize = n.NormalizeROM
+	}
+	return opts
+}
+
+// normalizeContent applies the core's normalization to content the
+// frontend passed as data, using the extension of path. Returns the data
+// and the change made, or "" if none.
+func normalizeContent(opts romloader.Options, path string, data []byte) ([]byte, string) {
+	if opts.Normalize == nil {
+		return data, ""
+	}
+	return opts.Normalize(strings.ToLower(filepath.Ext(path)), data)
+}
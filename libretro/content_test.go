@@ -0,0 +1,113 @@
+package libretro
+
+import (
+	"testing"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// TestValidExtensions verifies dots are dropped and archives are appended
+func TestValidExtensions(t *testing.T) {
+	got := validExtensions([]string{".sms", ".SG", ".sms", ""})
+	if got != "sms|sg|zip|7z|rar|gz|tgz|zst|tzst|xz|txz|bz2|tbz2|tbz|lz4|chd" {
+		t.Errorf("validExtensions = %q", got)
+	}
+}
+
+// TestValidExtensions_Duplicates verifies extensions differing only in
+// case or dot, and ROM extensions shared with an archive format, are
+// listed once
+func TestValidExtensions_Duplicates(t *testing.T) {
+	got := validExtensions([]string{".zip"})
+	if got != "zip|7z|rar|gz|tgz|zst|tzst|xz|txz|bz2|tbz2|tbz|lz4|chd" {
+		t.Errorf("validExtensions = %q", got)
+	}
+	if got := validExtensions([]string{"bin", ".BIN"}); got != "bin|zip|7z|rar|gz|tgz|zst|tzst|xz|txz|bz2|tbz2|tbz|lz4|chd" {
+		t.Errorf("validExtensions = %q", got)
+	}
+}
+
+// TestIsArchive verifies archive detection by extension
+func TestIsArchive(t *testing.T) {
+	exts := []string{".sms"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/roms/game.zip", true},
+		{"/roms/game.7Z", true},
+		{"/roms/game.tar.gz", true},
+		{"/roms/game.sms", false},
+		{"/roms/game.zip#game.sms", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if got := isArchive(tc.path, exts); got != tc.want {
+			t.Errorf("isArchive(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+
+	// A ROM extension takes precedence over an archive one
+	if isArchive("/roms/game.zip", []string{".zip"}) {
+		t.Error("ROM extension treated as an archive")
+	}
+}
+
+// TestNeedsLoader verifies when content is read through romloader
+func TestNeedsLoader(t *testing.T) {
+	exts := []string{".sms"}
+	if !needsLoader("/roms/game.sms", 0, exts) {
+		t.Error("path without data should use the loader")
+	}
+	if !needsLoader("/roms/game.zip", 1024, exts) {
+		t.Error("archive data should use the loader")
+	}
+	if needsLoader("/roms/game.sms", 1024, exts) {
+		t.Error("ROM data should be used as-is")
+	}
+	if needsLoader("", 1024, exts) {
+		t.Error("data without a path should be used as-is")
+	}
+}
+
+// normalizingFactory strips a 2-byte header from .hdr ROMs
+type normalizingFactory struct {
+	coreif.CoreFactory
+}
+
+func (normalizingFactory) NormalizeROM(ext string, rom []byte) ([]byte, string) {
+	if ext != ".hdr" {
+		return rom, ""
+	}
+	return rom[2:], "removed header"
+}
+
+// TestContentOptions verifies the size limit and normalizer are taken
+// from the core
+func TestContentOptions(t *testing.T) {
+	opts := contentOptions(normalizingFactory{}, coreif.SystemInfo{MaxROMSize: 1 << 30})
+	if opts.MaxSize != 1<<30 || opts.Normalize == nil {
+		t.Errorf("opts = %+v", opts)
+	}
+	if opts := contentOptions(nil, coreif.SystemInfo{}); opts.Normalize != nil {
+		t.Error("core without ROMNormalizer has a normalizer")
+	}
+}
+
+// TestNormalizeContent verifies frontend data is normalized by extension
+func TestNormalizeContent(t *testing.T) {
+	opts := contentOptions(normalizingFactory{}, coreif.SystemInfo{})
+
+	rom, change := normalizeContent(opts, "/roms/Game.HDR", []byte("xxrom"))
+	if string(rom) != "rom" || change != "removed header" {
+		t.Errorf("rom = %q, change = %q", rom, change)
+	}
+	rom, change = normalizeContent(opts, "/roms/game.bin", []byte("xxrom"))
+	if string(rom) != "xxrom" || change != "" {
+		t.Errorf("other extension: rom = %q, change = %q", rom, change)
+	}
+	rom, _ = normalizeContent(contentOptions(nil, coreif.SystemInfo{}), "/roms/Game.hdr", []byte("xxrom"))
+	if string(rom) != "xxrom" {
+		t.Errorf("no normalizer: rom = %q", rom)
+	}
+}
@@ -2,4 +2,23 @@ module github.com/user-none/eblitui/libretro
 
 go 1.25.7
 
-require github.com/user-none/eblitui/coreif v0.4.0
+require (
+	github.com/user-none/eblitui/coreif v0.5.0
+	github.com/user-none/eblitui/romloader v0.3.0
+	github.com/user-none/eblitui/rumble v0.1.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/bodgit/plumbing v1.3.0 // indirect
+	github.com/bodgit/sevenzip v1.6.1 // indirect
+	github.com/bodgit/windows v1.0.1 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/nwaples/rardecode/v2 v2.2.2 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
+	github.com/spf13/afero v1.11.0 // indirect
+	github.com/ulikunitz/xz v0.5.12 // indirect
+	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
+	golang.org/x/text v0.33.0 // indirect
+)
@@ -0,0 +1,114 @@
+package libretro
+
+import "github.com/user-none/eblitui/coreif"
+
+// Libretro device IDs, mirrored from libretro.h for the pure Go helpers.
+const (
+	retroDeviceNone      = 0
+	retroDeviceJoypad    = 1
+	retroDeviceTypeShift = 8
+	retroDeviceMask      = (1 << retroDeviceTypeShift) - 1
+)
+
+// noController marks a port with nothing plugged in.
+const noController = -1
+
+// inputDescriptor names one button on one port for the frontend.
+type inputDescriptor struct {
+	Port int
+	ID   int // RETRO_DEVICE_ID_JOYPAD_* constant
+	Desc string
+}
+
+// controllerType is one entry of a port's controller list.
+type controllerType struct {
+	Desc   string
+	Device uint
+}
+
+// dpadDescriptors are the fixed d-pad buttons every controller has.
+var dpadDescriptors = []struct {
+	id   int
+	desc string
+}{
+	{4, "D-Pad Up"},
+	{5, "D-Pad Down"},
+	{6, "D-Pad Left"},
+	{7, "D-Pad Right"},
+}
+
+// controllerDevice returns the libretro device for the controller at
+// index in SystemInfo.Controllers. The first is the standard joypad and
+// the rest are joypad subclasses.
+func controllerDevice(index int) uint {
+	if index == 0 {
+		return retroDeviceJoypad
+	}
+	return uint(index)<<retroDeviceTypeShift | retroDeviceJoypad
+}
+
+// controllerIndex maps a libretro device to an index in a list of count
+// controllers. Returns noController for RETRO_DEVICE_NONE. Unknown
+// joypad subclasses and devices fall back to the default controller.
+func controllerIndex(count int, device uint) int {
+	if device == retroDeviceNone {
+		return noController
+	}
+	if device&retroDeviceMask != retroDeviceJoypad {
+		return 0
+	}
+	index := int(device >> retroDeviceTypeShift)
+	if index >= count {
+		return 0
+	}
+	return index
+}
+
+// controllerTypes lists the controllers a port accepts. Cores without
+// alternate controllers get a single standard pad.
+func controllerTypes(controllers []coreif.Controller) []controllerType {
+	if len(controllers) == 0 {
+		return []controllerType{{Desc: "RetroPad", Device: retroDeviceJoypad}}
+	}
+	types := make([]controllerType, len(controllers))
+	for i, c := range controllers {
+		types[i] = controllerType{Desc: c.Name, Device: controllerDevice(i)}
+	}
+	return types
+}
+
+// buildInputDescriptors names every mapped button on every port using the
+// system's button names. ports holds each player's controller index;
+// buttons missing from that controller and empty ports are left out.
+func buildInputDescriptors(info coreif.SystemInfo, mapping []RetropadMapping, ports []int) []inputDescriptor {
+	names := make(map[int]string, len(info.Buttons))
+	for _, b := range info.Buttons {
+		names[b.ID] = b.Name
+	}
+
+	var descs []inputDescriptor
+	for port, index := range ports {
+		if index == noController {
+			continue
+		}
+		var present map[int]bool
+		if index < len(info.Controllers) && info.Controllers[index].Buttons != nil {
+			present = make(map[int]bool)
+			for _, id := range info.Controllers[index].Buttons {
+				present[id] = true
+			}
+		}
+
+		for _, d := range dpadDescriptors {
+			descs = append(descs, inputDescriptor{Port: port, ID: d.id, Desc: d.desc})
+		}
+		for _, m := range mapping {
+			name, ok := names[m.BitID]
+			if !ok || (present != nil && !present[m.BitID]) {
+				continue
+			}
+			descs = append(descs, inputDescriptor{Port: port, ID: m.RetroID, Desc: name})
+		}
+	}
+	return descs
+}
@@ -0,0 +1,123 @@
+package libretro
+
+import (
+	"testing"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// testInputInfo has a 3-button pad and a 6-button pad
+var testInputInfo = coreif.SystemInfo{
+	Players: 2,
+	Buttons: []coreif.Button{
+		{Name: "A", ID: 4},
+		{Name: "B", ID: 5},
+		{Name: "C", ID: 6},
+		{Name: "Start", ID: 7},
+		{Name: "X", ID: 8},
+	},
+	Controllers: []coreif.Controller{
+		{Name: "3-Button Pad", ID: 3, Buttons: []int{4, 5, 6, 7}},
+		{Name: "6-Button Pad", ID: 6},
+	},
+}
+
+var testInputMap = []RetropadMapping{
+	{RetroID: JoypadY, BitID: 4},
+	{RetroID: JoypadB, BitID: 5},
+	{RetroID: JoypadA, BitID: 6},
+	{RetroID: JoypadStart, BitID: 7},
+	{RetroID: JoypadL, BitID: 8},
+}
+
+// TestControllerDevice verifies alternates are joypad subclasses
+func TestControllerDevice(t *testing.T) {
+	if got := controllerDevice(0); got != retroDeviceJoypad {
+		t.Errorf("controllerDevice(0) = %d, want %d", got, retroDeviceJoypad)
+	}
+	// RETRO_DEVICE_SUBCLASS(RETRO_DEVICE_JOYPAD, 0)
+	if got := controllerDevice(1); got != 0x101 {
+		t.Errorf("controllerDevice(1) = %#x, want 0x101", got)
+	}
+}
+
+// TestControllerIndex verifies devices round trip and unknown ones fall back
+func TestControllerIndex(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		if got := controllerIndex(3, controllerDevice(i)); got != i {
+			t.Errorf("controllerIndex(device %d) = %d, want %d", i, got, i)
+		}
+	}
+	if got := controllerIndex(3, retroDeviceNone); got != noController {
+		t.Errorf("none = %d, want noController", got)
+	}
+	if got := controllerIndex(2, controllerDevice(5)); got != 0 {
+		t.Errorf("unknown subclass = %d, want 0", got)
+	}
+	// RETRO_DEVICE_MOUSE
+	if got := controllerIndex(2, 2); got != 0 {
+		t.Errorf("mouse = %d, want 0", got)
+	}
+}
+
+// TestControllerTypes verifies cores without controllers get one pad
+func TestControllerTypes(t *testing.T) {
+	types := controllerTypes(nil)
+	if len(types) != 1 || types[0].Device != retroDeviceJoypad {
+		t.Errorf("default types = %+v", types)
+	}
+
+	types = controllerTypes(testInputInfo.Controllers)
+	if len(types) != 2 || types[1].Desc != "6-Button Pad" || types[1].Device != controllerDevice(1) {
+		t.Errorf("types = %+v", types)
+	}
+}
+
+// TestBuildInputDescriptors verifies every port gets the d-pad and named
+// buttons
+func TestBuildInputDescriptors(t *testing.T) {
+	descs := buildInputDescriptors(testInputInfo, testInputMap, []int{1, 1})
+
+	// 4 d-pad + 5 buttons per port
+	if len(descs) != 18 {
+		t.Fatalf("len = %d, want 18", len(descs))
+	}
+	for _, d := range descs {
+		if d.ID == JoypadL && d.Desc != "X" {
+			t.Errorf("JoypadL desc = %q, want \"X\"", d.Desc)
+		}
+	}
+	if descs[9].Port != 1 || descs[9].Desc != "D-Pad Up" {
+		t.Errorf("port 1 first desc = %+v", descs[9])
+	}
+}
+
+// TestBuildInputDescriptors_Controllers verifies buttons missing from a
+// port's controller and empty ports are left out
+func TestBuildInputDescriptors_Controllers(t *testing.T) {
+	descs := buildInputDescriptors(testInputInfo, testInputMap, []int{0, noController})
+
+	if len(descs) != 8 {
+		t.Fatalf("len = %d, want 8", len(descs))
+	}
+	for _, d := range descs {
+		if d.Port != 0 {
+			t.Errorf("descriptor for empty port: %+v", d)
+		}
+		if d.Desc == "X" {
+			t.Error("3-button pad has an X descriptor")
+		}
+	}
+}
+
+// TestBuildInputDescriptors_Unnamed verifies mappings without a button
+// are skipped
+func TestBuildInputDescriptors_Unnamed(t *testing.T) {
+	info := coreif.SystemInfo{Buttons: []coreif.Button{{Name: "A", ID: 4}}}
+	mapping := []RetropadMapping{{RetroID: JoypadA, BitID: 4}, {RetroID: JoypadB, BitID: 9}}
+
+	descs := buildInputDescriptors(info, mapping, []int{0})
+	if len(descs) != 5 || descs[4].Desc != "A" {
+		t.Errorf("descs = %+v", descs)
+	}
+}
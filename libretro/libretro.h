@@ -4,6 +4,7 @@
 #include <stdint.h>
 #include <stddef.h>
 #include <stdbool.h>
+#include <limits.h>
 
 #ifndef RETRO_CALLCONV
 #define RETRO_CALLCONV
@@ -11,6 +12,11 @@
 
 #define RETRO_API_VERSION 1
 
+#define RETRO_DEVICE_TYPE_SHIFT 8
+#define RETRO_DEVICE_MASK ((1 << RETRO_DEVICE_TYPE_SHIFT) - 1)
+#define RETRO_DEVICE_SUBCLASS(base, id) (((id + 1) << RETRO_DEVICE_TYPE_SHIFT) | base)
+
+#define RETRO_DEVICE_NONE   0
 #define RETRO_DEVICE_JOYPAD 1
 
 #define RETRO_DEVICE_ID_JOYPAD_B      0
@@ -36,12 +42,51 @@
 #define RETRO_MEMORY_SAVE_RAM   0
 #define RETRO_MEMORY_SYSTEM_RAM 2
 
+#define RETRO_MEMDESC_CONST      (1 << 0)
+#define RETRO_MEMDESC_BIGENDIAN  (1 << 1)
+#define RETRO_MEMDESC_SYSTEM_RAM (1 << 2)
+#define RETRO_MEMDESC_SAVE_RAM   (1 << 3)
+
+#define RETRO_ENVIRONMENT_EXPERIMENTAL 0x10000
+
+#define RETRO_ENVIRONMENT_GET_CAN_DUPE           3
+#define RETRO_ENVIRONMENT_SET_MESSAGE            6
+#define RETRO_ENVIRONMENT_SET_PERFORMANCE_LEVEL  8
 #define RETRO_ENVIRONMENT_GET_SYSTEM_DIRECTORY   9
 #define RETRO_ENVIRONMENT_SET_PIXEL_FORMAT      10
+#define RETRO_ENVIRONMENT_SET_INPUT_DESCRIPTORS 11
+#define RETRO_ENVIRONMENT_GET_RUMBLE_INTERFACE  23
+#define RETRO_ENVIRONMENT_GET_LOG_INTERFACE     27
+#define RETRO_ENVIRONMENT_GET_SAVE_DIRECTORY    31
 #define RETRO_ENVIRONMENT_GET_VARIABLE          15
 #define RETRO_ENVIRONMENT_SET_VARIABLES         16
 #define RETRO_ENVIRONMENT_GET_VARIABLE_UPDATE   17
+#define RETRO_ENVIRONMENT_SET_CONTROLLER_INFO   35
+#define RETRO_ENVIRONMENT_SET_MEMORY_MAPS       (36 | RETRO_ENVIRONMENT_EXPERIMENTAL)
 #define RETRO_ENVIRONMENT_SET_GEOMETRY          37
+#define RETRO_ENVIRONMENT_SET_SUPPORT_ACHIEVEMENTS (42 | RETRO_ENVIRONMENT_EXPERIMENTAL)
+#define RETRO_ENVIRONMENT_SET_SERIALIZATION_QUIRKS 44
+#define RETRO_ENVIRONMENT_GET_AUDIO_VIDEO_ENABLE (47 | RETRO_ENVIRONMENT_EXPERIMENTAL)
+#define RETRO_ENVIRONMENT_GET_CORE_OPTIONS_VERSION 52
+#define RETRO_ENVIRONMENT_GET_MESSAGE_INTERFACE_VERSION 59
+#define RETRO_ENVIRONMENT_SET_MESSAGE_EXT       60
+#define RETRO_ENVIRONMENT_SET_CONTENT_INFO_OVERRIDE 65
+#define RETRO_ENVIRONMENT_GET_GAME_INFO_EXT     66
+#define RETRO_ENVIRONMENT_SET_CORE_OPTIONS      53
+#define RETRO_ENVIRONMENT_SET_CORE_OPTIONS_DISPLAY 55
+#define RETRO_ENVIRONMENT_SET_CORE_OPTIONS_V2   67
+#define RETRO_ENVIRONMENT_SET_CORE_OPTIONS_UPDATE_DISPLAY_CALLBACK 69
+#define RETRO_ENVIRONMENT_GET_SAVESTATE_CONTEXT (72 | RETRO_ENVIRONMENT_EXPERIMENTAL)
+
+#define RETRO_SERIALIZATION_QUIRK_INCOMPLETE         (1 << 0)
+#define RETRO_SERIALIZATION_QUIRK_MUST_INITIALIZE    (1 << 1)
+#define RETRO_SERIALIZATION_QUIRK_CORE_VARIABLE_SIZE (1 << 2)
+#define RETRO_SERIALIZATION_QUIRK_FRONT_VARIABLE_SIZE (1 << 3)
+#define RETRO_SERIALIZATION_QUIRK_SINGLE_SESSION     (1 << 4)
+#define RETRO_SERIALIZATION_QUIRK_ENDIAN_DEPENDENT   (1 << 5)
+#define RETRO_SERIALIZATION_QUIRK_PLATFORM_DEPENDENT (1 << 6)
+
+#define RETRO_NUM_CORE_OPTION_VALUES_MAX 128
 
 enum retro_pixel_format {
 	RETRO_PIXEL_FORMAT_0RGB1555 = 0,
@@ -87,6 +132,146 @@ struct retro_variable {
 	const char *value;
 };
 
+struct retro_memory_descriptor {
+	uint64_t flags;
+	void *ptr;
+	size_t offset;
+	size_t start;
+	size_t select;
+	size_t disconnect;
+	size_t len;
+	const char *addrspace;
+};
+
+struct retro_memory_map {
+	const struct retro_memory_descriptor *descriptors;
+	unsigned num_descriptors;
+};
+
+enum retro_log_level {
+	RETRO_LOG_DEBUG = 0,
+	RETRO_LOG_INFO,
+	RETRO_LOG_WARN,
+	RETRO_LOG_ERROR
+};
+
+typedef void (RETRO_CALLCONV *retro_log_printf_t)(enum retro_log_level level, const char *fmt, ...);
+
+struct retro_log_callback {
+	retro_log_printf_t log;
+};
+
+struct retro_message {
+	const char *msg;
+	unsigned frames;
+};
+
+enum retro_message_target {
+	RETRO_MESSAGE_TARGET_ALL = 0,
+	RETRO_MESSAGE_TARGET_OSD,
+	RETRO_MESSAGE_TARGET_LOG
+};
+
+enum retro_message_type {
+	RETRO_MESSAGE_TYPE_NOTIFICATION = 0,
+	RETRO_MESSAGE_TYPE_NOTIFICATION_ALT,
+	RETRO_MESSAGE_TYPE_STATUS,
+	RETRO_MESSAGE_TYPE_PROGRESS
+};
+
+struct retro_message_ext {
+	const char *msg;
+	unsigned duration;
+	unsigned priority;
+	enum retro_log_level level;
+	enum retro_message_target target;
+	enum retro_message_type type;
+	int8_t progress;
+};
+
+enum retro_savestate_context {
+	RETRO_SAVESTATE_CONTEXT_NORMAL = 0,
+	RETRO_SAVESTATE_CONTEXT_RUNAHEAD_SAME_INSTANCE = 1,
+	RETRO_SAVESTATE_CONTEXT_RUNAHEAD_SAME_BINARY = 2,
+	RETRO_SAVESTATE_CONTEXT_ROLLBACK_NETPLAY = 3,
+	RETRO_SAVESTATE_CONTEXT_UNKNOWN = INT_MAX
+};
+
+enum retro_rumble_effect {
+	RETRO_RUMBLE_STRONG = 0,
+	RETRO_RUMBLE_WEAK = 1
+};
+
+typedef bool (RETRO_CALLCONV *retro_set_rumble_state_t)(unsigned port, enum retro_rumble_effect effect, uint16_t strength);
+
+struct retro_rumble_interface {
+	retro_set_rumble_state_t set_rumble_state;
+};
+
+struct retro_input_descriptor {
+	unsigned port;
+	unsigned device;
+	unsigned index;
+	unsigned id;
+	const char *description;
+};
+
+struct retro_controller_description {
+	const char *desc;
+	unsigned id;
+};
+
+struct retro_controller_info {
+	const struct retro_controller_description *types;
+	unsigned num_types;
+};
+
+struct retro_core_option_value {
+	const char *value;
+	const char *label;
+};
+
+struct retro_core_option_definition {
+	const char *key;
+	const char *desc;
+	const char *info;
+	struct retro_core_option_value values[RETRO_NUM_CORE_OPTION_VALUES_MAX];
+	const char *default_value;
+};
+
+struct retro_core_option_v2_category {
+	const char *key;
+	const char *desc;
+	const char *info;
+};
+
+struct retro_core_option_v2_definition {
+	const char *key;
+	const char *desc;
+	const char *desc_categorized;
+	const char *info;
+	const char *info_categorized;
+	const char *category_key;
+	struct retro_core_option_value values[RETRO_NUM_CORE_OPTION_VALUES_MAX];
+	const char *default_value;
+};
+
+struct retro_core_options_v2 {
+	struct retro_core_option_v2_category *categories;
+	struct retro_core_option_v2_definition *definitions;
+};
+
+struct retro_core_option_display {
+	const char *key;
+	bool visible;
+};
+
+typedef bool (RETRO_CALLCONV *retro_core_options_update_display_callback_t)(void);
+
+struct retro_core_options_update_display_callback {
+	retro_core_options_update_display_callback_t callback;
+};
+
 struct retro_game_info {
 	const char *path;
 	const void *data;
@@ -94,4 +279,24 @@ struct retro_game_info {
 	const char *meta;
 };
 
+struct retro_system_content_info_override {
+	const char *extensions;
+	bool need_fullpath;
+	bool persistent_data;
+};
+
+struct retro_game_info_ext {
+	const char *full_path;
+	const char *archive_path;
+	const char *archive_file;
+	const char *dir;
+	const char *name;
+	const char *ext;
+	const char *meta;
+	const void *data;
+	size_t size;
+	bool file_in_archive;
+	bool persistent_data;
+};
+
 #endif
@@ -6,12 +6,20 @@ package libretro
 */
 import "C"
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/user-none/eblitui/coreif"
+	"github.com/user-none/eblitui/romloader"
+	"github.com/user-none/eblitui/rumble"
 )
 
 // Libretro joypad button ID constants for use in RetropadMapping.
@@ -36,20 +44,17 @@ type RetropadMapping struct {
 	BitID   int // coreif bit position (from Button.ID)
 }
 
-// memoryBuffer holds a C-allocated buffer for a memory region.
-type memoryBuffer struct {
-	buf  *C.uint8_t
-	size C.size_t
-}
-
 var (
 	factory  coreif.CoreFactory
 	inputMap []RetropadMapping
 	sysInfo  coreif.SystemInfo
 
-	emulator     coreif.Emulator
-	saveStater   coreif.SaveStater
-	memoryMapper coreif.MemoryMapper
+	emulator           coreif.Emulator
+	saveStater         coreif.SaveStater
+	memoryMapper       coreif.MemoryMapper
+	memoryInspector    coreif.MemoryInspector
+	memoryExposer      coreif.MemoryExposer
+	controllerSelector coreif.ControllerSelector
 
 	region        coreif.Region
 	romData       []byte
@@ -57,8 +62,18 @@ var (
 	currentWidth  int
 	currentHeight int
 
-	// C-allocated memory buffers keyed by region type
+	// Frame change hint from the core, and whether the frontend can be
+	// asked to show its previous frame again
+	frameReporter coreif.FrameChangeReporter
+	videoDupe     frameDupe
+
+	// Reused copy of the state passed to retro_unserialize
+	stateBuf []byte
+
+	// Memory buffers keyed by region type, and the pins holding core
+	// memory shared in place
 	memBuffers map[int]*memoryBuffer
+	memPinner  runtime.Pinner
 
 	// Pre-allocated C strings (allocated once, freed in retro_deinit)
 	libNameStr   *C.char
@@ -69,15 +84,36 @@ var (
 	// Core option state
 	optionRegion   string = "Auto"
 	detectedRegion coreif.Region
+	optionPrefix   string
+
+	// Option definitions (region first, then SystemInfo.CoreOptions) and
+	// their C keys, built once
+	optionDefs []optionDef
+	optionKeys []*C.char
+
+	// Option visibility last reported to the frontend, keyed by
+	// CoreOption.Key. Missing keys are visible.
+	optionVisible map[string]bool
+
+	// C strings for option text, allocated once and shared by value
+	cStrings map[string]*C.char
 
-	// Pre-allocated C strings for options
-	optKeyRegion *C.char
-	optValRegion *C.char
-	optionPrefix string
+	// Controller index (into SystemInfo.Controllers) plugged into each
+	// player's port, or noController
+	portControllers []int
 
-	// Core-specific option keys and C strings
-	coreOptKeys []*C.char
-	coreOptVals []*C.char
+	// C-allocated controller lists for SET_CONTROLLER_INFO. The frontend
+	// keeps pointers to them, so they live for the life of the core.
+	controllerInfo *C.struct_retro_controller_info
+
+	// Frontend message interface version: 1 or later supports
+	// SET_MESSAGE_EXT
+	messageVersion C.uint
+
+	// Rumble engine for the loaded game's CHT rumble file, and the motor
+	// state it drives. nil when the game has no rumble.
+	rumbleEngine *rumble.Engine
+	motors       *rumbleMotors
 
 	// BIOS data loaded from system directory (keyed by BIOSOption.Key)
 	biosData map[string][]byte
@@ -90,13 +126,17 @@ func RegisterFactory(f coreif.CoreFactory, mapping []RetropadMapping) {
 	inputMap = mapping
 	sysInfo = f.SystemInfo()
 	optionPrefix = sysInfo.CoreName + "_"
+	portControllers = make([]int, sysInfo.Players)
 }
 
 //export retro_set_environment
 func retro_set_environment(cb C.retro_environment_t) {
 	C._retro_set_environment(cb)
-	ensureOptionStrings()
-	setVariables()
+	setupLogging()
+	ensureOptions()
+	setCoreOptions()
+	setControllerInfo()
+	setContentInfoOverride()
 }
 
 //export retro_set_video_refresh
@@ -132,7 +172,7 @@ func retro_init() {
 	currentHeight = sysInfo.MaxScreenHeight
 
 	ensureStrings()
-	ensureOptionStrings()
+	ensureOptions()
 }
 
 //export retro_deinit
@@ -140,6 +180,9 @@ func retro_deinit() {
 	emulator = nil
 	saveStater = nil
 	memoryMapper = nil
+	memoryInspector = nil
+	memoryExposer = nil
+	controllerSelector = nil
 	romData = nil
 	xrgbBuf = nil
 
@@ -157,7 +200,10 @@ func retro_get_system_info(info *C.struct_retro_system_info) {
 	info.library_name = libNameStr
 	info.library_version = libVerStr
 	info.valid_extensions = validExtStr
+	// Archives are listed in valid_extensions and extracted through
+	// romloader, so frontends must not extract them first
 	info.need_fullpath = C.bool(false)
+	info.block_extract = C.bool(true)
 }
 
 //export retro_get_system_av_info
@@ -188,6 +234,16 @@ func retro_get_system_av_info(info *C.struct_retro_system_av_info) {
 
 //export retro_set_controller_port_device
 func retro_set_controller_port_device(port C.uint, device C.uint) {
+	if int(port) >= len(portControllers) {
+		return
+	}
+	index := controllerIndex(len(sysInfo.Controllers), uint(device))
+	if index == portControllers[port] {
+		return
+	}
+	portControllers[port] = index
+	applyController(int(port))
+	setInputDescriptors()
 }
 
 //export retro_reset
@@ -198,14 +254,45 @@ func retro_reset() {
 
 	applyRegionOption()
 
+	// Cores that can reset in place keep their memory, options and BIOS
+	if r, ok := emulator.(coreif.Resetter); ok {
+		r.Reset(false)
+		videoDupe.invalidate()
+		resetRumble()
+		return
+	}
+
 	emu, err := factory.CreateEmulator(romData, region)
 	if err != nil {
+		showMessage(coreif.Message{Level: coreif.MessageError, Text: "Failed to reset: " + err.Error(), Notify: true})
 		return
 	}
+
+	// Keep save RAM across the reset; the new emulator has its own memory
+	var sram []byte
+	if buf, ok := memBuffers[coreif.MemorySaveRAM]; ok {
+		sram = bytes.Clone(buf.data)
+	}
+
 	setEmulator(emu)
 	applyCoreOptions()
 	applyBIOS()
 	emulator.Start()
+
+	// The frontend keeps the buffers and memory maps it was given at load,
+	// so the new emulator's memory is copied into the same buffers
+	if memoryMapper != nil {
+		if sram != nil {
+			memoryMapper.WriteRegion(coreif.MemorySaveRAM, sram)
+		}
+		for regionType, buf := range memBuffers {
+			buf.detach(regionType)
+			buf.push(regionType, memoryMapper, memoryInspector)
+		}
+	}
+
+	videoDupe.invalidate()
+	resetRumble()
 }
 
 //export retro_run
@@ -219,6 +306,7 @@ func retro_run() {
 	if C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_VARIABLE_UPDATE, unsafe.Pointer(&updated)) && updated {
 		updateRegionOption()
 		applyCoreOptions()
+		updateOptionDisplay()
 	}
 
 	// Poll input
@@ -226,6 +314,10 @@ func retro_run() {
 
 	// Build input bitmask for each player
 	for player := 0; player < sysInfo.Players; player++ {
+		if portControllers[player] == noController {
+			emulator.SetInput(player, 0)
+			continue
+		}
 		port := C.uint(player)
 		var buttons uint32
 
@@ -253,43 +345,47 @@ func retro_run() {
 		emulator.SetInput(player, buttons)
 	}
 
-	// Sync save RAM from C buffer to Go before frame
+	// Pass frontend save RAM changes to the core before the frame
 	if memoryMapper != nil {
-		if buf, ok := memBuffers[coreif.MemorySaveRAM]; ok && buf.buf != nil {
-			data := unsafe.Slice((*byte)(unsafe.Pointer(buf.buf)), int(buf.size))
-			goData := make([]byte, int(buf.size))
-			copy(goData, data)
-			memoryMapper.WriteRegion(coreif.MemorySaveRAM, goData)
+		if buf, ok := memBuffers[coreif.MemorySaveRAM]; ok {
+			buf.pull(memoryMapper)
 		}
 	}
 
 	// Run one frame
 	emulator.RunFrame()
 
-	// Sync Go memory to C buffers after frame
+	// Refresh the frontend's view of memory the core doesn't share
 	if memoryMapper != nil {
 		for regionType, buf := range memBuffers {
-			if buf.buf == nil {
-				continue
-			}
-			regionData := memoryMapper.ReadRegion(regionType)
-			if len(regionData) > 0 {
-				cBuf := unsafe.Slice((*byte)(unsafe.Pointer(buf.buf)), int(buf.size))
-				copy(cBuf, regionData)
-			}
+			buf.push(regionType, memoryMapper, memoryInspector)
 		}
 	}
 
-	// Video output
-	fb := emulator.GetFramebuffer()
-	activeHeight := emulator.GetActiveHeight()
-	if len(fb) > 0 {
-		outputVideo(fb, activeHeight)
+	updateRumble()
+
+	// Run-ahead and fast-forward turn off outputs the frontend won't use
+	videoOn, audioOn := audioVideoEnable()
+
+	// Video output. Unchanged frames are duped instead of converted.
+	if videoOn {
+		changed := frameReporter == nil || frameReporter.FrameChanged()
+		if videoDupe.dupe(changed) {
+			C.call_video_cb(nil, C.uint(currentWidth), C.uint(currentHeight), 0)
+		} else if fb := emulator.GetFramebuffer(); len(fb) > 0 {
+			outputVideo(fb, emulator.GetActiveHeight())
+			videoDupe.sent()
+		}
+	} else {
+		videoDupe.invalidate()
+		if videoDupe.canDupe {
+			C.call_video_cb(nil, C.uint(currentWidth), C.uint(currentHeight), 0)
+		}
 	}
 
-	// Audio output
+	// Audio output. Samples are always taken so the core's buffer drains.
 	samples := emulator.GetAudioSamples()
-	if len(samples) > 0 {
+	if audioOn && len(samples) > 0 {
 		frames := len(samples) / 2
 		C.call_audio_batch_cb((*C.int16_t)(unsafe.Pointer(&samples[0])), C.size_t(frames))
 	}
@@ -297,7 +393,15 @@ func retro_run() {
 
 //export retro_serialize_size
 func retro_serialize_size() C.size_t {
-	return C.size_t(sysInfo.SerializeSize)
+	if sysInfo.SerializeSize > 0 || saveStater == nil {
+		return C.size_t(sysInfo.SerializeSize)
+	}
+	// Variable size: report the current state's size
+	state, err := saveStater.Serialize()
+	if err != nil {
+		return 0
+	}
+	return C.size_t(len(state))
 }
 
 //export retro_serialize
@@ -308,15 +412,14 @@ func retro_serialize(data unsafe.Pointer, size C.size_t) C.bool {
 
 	state, err := saveStater.Serialize()
 	if err != nil {
+		logf(coreif.MessageError, "Serialize failed: %v", err)
 		return C.bool(false)
 	}
 
-	if len(state) > int(size) {
+	if !writeState(unsafe.Slice((*byte)(data), size), state) {
+		logf(coreif.MessageError, "Save state is %d bytes, frontend buffer is %d", len(state), size)
 		return C.bool(false)
 	}
-
-	dst := unsafe.Slice((*byte)(data), size)
-	copy(dst, state)
 	return C.bool(true)
 }
 
@@ -326,13 +429,29 @@ func retro_unserialize(data unsafe.Pointer, size C.size_t) C.bool {
 		return C.bool(false)
 	}
 
-	state := make([]byte, size)
-	src := unsafe.Slice((*byte)(data), size)
-	copy(state, src)
+	// Run-ahead restores every frame, so the copy buffer is reused.
+	// Deserialize must not keep the slice.
+	if cap(stateBuf) < int(size) {
+		stateBuf = make([]byte, size)
+	}
+	stateBuf = stateBuf[:size]
+	copy(stateBuf, unsafe.Slice((*byte)(data), size))
 
-	if err := saveStater.Deserialize(state); err != nil {
+	if err := saveStater.Deserialize(stateBuf); err != nil {
+		logf(coreif.MessageError, "Unserialize failed: %v", err)
 		return C.bool(false)
 	}
+	videoDupe.invalidate()
+
+	// Keep the frontend's copies of memory in step with the restored state
+	if memoryMapper != nil {
+		for regionType, buf := range memBuffers {
+			buf.push(regionType, memoryMapper, memoryInspector)
+		}
+	}
+	if !isReplayContext(saveStateContext()) {
+		resetRumble()
+	}
 	return C.bool(true)
 }
 
@@ -346,16 +465,27 @@ func retro_cheat_set(index C.uint, enabled C.bool, code *C.char) {
 
 //export retro_load_game
 func retro_load_game(game *C.struct_retro_game_info) C.bool {
-	if game == nil || game.data == nil || game.size == 0 || factory == nil {
+	if factory == nil {
+		return C.bool(false)
+	}
+	if game == nil {
+		logf(coreif.MessageError, "No game provided by the frontend")
 		return C.bool(false)
 	}
 
+	data, romPath, err := loadContent(game)
+	if err != nil {
+		showMessage(coreif.Message{Level: coreif.MessageError, Text: "Failed to load ROM: " + err.Error(), Notify: true})
+		return C.bool(false)
+	}
+	romData = data
+
 	// Set pixel format
 	var pixelFormat C.int = C.RETRO_PIXEL_FORMAT_XRGB8888
 	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_PIXEL_FORMAT, unsafe.Pointer(&pixelFormat))
 
-	// Copy ROM data
-	romData = C.GoBytes(game.data, C.int(game.size))
+	var canDupe C.bool
+	videoDupe.canDupe = bool(C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_CAN_DUPE, unsafe.Pointer(&canDupe)) && canDupe)
 
 	// Detect region
 	detectedRegion, _ = factory.DetectRegion(romData)
@@ -364,19 +494,30 @@ func retro_load_game(game *C.struct_retro_game_info) C.bool {
 	// Read region option before creating emulator
 	updateRegionOption()
 
+	if sysInfo.PerformanceLevel > 0 {
+		level := C.uint(sysInfo.PerformanceLevel)
+		C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_PERFORMANCE_LEVEL, unsafe.Pointer(&level))
+	}
+
 	// Create emulator
 	emu, err := factory.CreateEmulator(romData, region)
 	if err != nil {
+		showMessage(coreif.Message{Level: coreif.MessageError, Text: "Failed to create emulator: " + err.Error(), Notify: true})
 		return C.bool(false)
 	}
 	setEmulator(emu)
 	applyCoreOptions()
+	updateOptionDisplay()
+	setInputDescriptors()
 	loadBIOSFromSystemDir()
 	applyBIOS()
 	emulator.Start()
 
 	// Allocate memory buffers
 	allocMemBuffers()
+	setSerializationQuirks()
+
+	loadRumble(romPath)
 
 	return C.bool(true)
 }
@@ -388,10 +529,17 @@ func retro_load_game_special(gameType C.uint, info *C.struct_retro_game_info, nu
 
 //export retro_unload_game
 func retro_unload_game() {
+	stopRumble()
+	rumbleEngine = nil
 	emulator = nil
 	saveStater = nil
 	memoryMapper = nil
+	memoryInspector = nil
+	memoryExposer = nil
+	controllerSelector = nil
+	frameReporter = nil
 	romData = nil
+	stateBuf = nil
 	freeMemBuffers()
 }
 
@@ -412,7 +560,7 @@ func retro_get_memory_data(id C.uint) unsafe.Pointer {
 
 	if emuType, ok := retroToEmu[id]; ok {
 		if buf, ok := memBuffers[emuType]; ok {
-			return unsafe.Pointer(buf.buf)
+			return unsafe.Pointer(&buf.data[0])
 		}
 	}
 	return nil
@@ -427,7 +575,7 @@ func retro_get_memory_size(id C.uint) C.size_t {
 
 	if emuType, ok := retroToEmu[id]; ok {
 		if buf, ok := memBuffers[emuType]; ok {
-			return buf.size
+			return C.size_t(len(buf.data))
 		}
 	}
 	return 0
@@ -448,6 +596,170 @@ func setEmulator(emu coreif.Emulator) {
 	} else {
 		memoryMapper = nil
 	}
+
+	if mi, ok := emu.(coreif.MemoryInspector); ok {
+		memoryInspector = mi
+	} else {
+		memoryInspector = nil
+	}
+
+	if me, ok := emu.(coreif.MemoryExposer); ok {
+		memoryExposer = me
+	} else {
+		memoryExposer = nil
+	}
+
+	if mr, ok := emu.(coreif.MessageReporter); ok {
+		mr.SetMessageHandler(showMessage)
+	}
+
+	if fr, ok := emu.(coreif.FrameChangeReporter); ok {
+		frameReporter = fr
+	} else {
+		frameReporter = nil
+	}
+	videoDupe.invalidate()
+
+	if cs, ok := emu.(coreif.ControllerSelector); ok {
+		controllerSelector = cs
+	} else {
+		controllerSelector = nil
+	}
+	for player := range portControllers {
+		applyController(player)
+	}
+}
+
+// setControllerInfo declares the controller types each port accepts.
+func setControllerInfo() {
+	if controllerInfo == nil {
+		types := controllerTypes(sysInfo.Controllers)
+		typeBuf := C.calloc(C.size_t(len(types)), C.size_t(unsafe.Sizeof(C.struct_retro_controller_description{})))
+		typeSlice := unsafe.Slice((*C.struct_retro_controller_description)(typeBuf), len(types))
+		for i, t := range types {
+			typeSlice[i].desc = cString(t.Desc)
+			typeSlice[i].id = C.uint(t.Device)
+		}
+
+		// One entry per port plus a zeroed terminator; every port shares
+		// the same types
+		infoBuf := C.calloc(C.size_t(sysInfo.Players+1), C.size_t(unsafe.Sizeof(C.struct_retro_controller_info{})))
+		infoSlice := unsafe.Slice((*C.struct_retro_controller_info)(infoBuf), sysInfo.Players+1)
+		for i := 0; i < sysInfo.Players; i++ {
+			infoSlice[i].types = &typeSlice[0]
+			infoSlice[i].num_types = C.uint(len(types))
+		}
+		controllerInfo = &infoSlice[0]
+	}
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_CONTROLLER_INFO, unsafe.Pointer(controllerInfo))
+}
+
+// setSerializationQuirks tells the frontend how save states behave so
+// run-ahead and netplay can use them.
+func setSerializationQuirks() {
+	if saveStater == nil {
+		return
+	}
+	quirks := C.uint64_t(serializationQuirks(sysInfo.SerializeSize))
+	want := quirks
+	if !C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_SERIALIZATION_QUIRKS, unsafe.Pointer(&quirks)) {
+		return
+	}
+	// The frontend clears quirks it doesn't support
+	if want&quirkCoreVariableSize != 0 && quirks&quirkCoreVariableSize == 0 {
+		logf(coreif.MessageWarn, "Frontend does not support variable size save states")
+	}
+}
+
+// saveStateContext returns why the frontend is saving or restoring a
+// state, or stateContextNormal when it can't say.
+func saveStateContext() int {
+	var ctx C.int
+	if !C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_SAVESTATE_CONTEXT, unsafe.Pointer(&ctx)) {
+		return stateContextNormal
+	}
+	return int(ctx)
+}
+
+// setContentInfoOverride asks the frontend to pass archives by path so
+// they are extracted through romloader. ROMs are still loaded into memory
+// by the frontend.
+func setContentInfoOverride() {
+	overrides := []C.struct_retro_system_content_info_override{
+		{extensions: cString(strings.Join(archiveExtensions, "|")), need_fullpath: C.bool(true)},
+		{}, // Terminator
+	}
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_CONTENT_INFO_OVERRIDE, unsafe.Pointer(&overrides[0]))
+}
+
+// loadContent returns the ROM data and path for a game. The path and
+// data come from GET_GAME_INFO_EXT when the frontend supports it. Content
+// passed only by path, and archives, are read through romloader. The
+// core's ROM normalization is applied either way.
+func loadContent(game *C.struct_retro_game_info) ([]byte, string, error) {
+	var path string
+	if game.path != nil {
+		path = C.GoString(game.path)
+	}
+	data, size := game.data, game.size
+
+	var ext *C.struct_retro_game_info_ext
+	if C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_GAME_INFO_EXT, unsafe.Pointer(&ext)) && ext != nil {
+		if ext.full_path != nil {
+			path = C.GoString(ext.full_path)
+		}
+		if ext.data != nil {
+			data, size = ext.data, ext.size
+		}
+	}
+
+	opts := contentOptions(factory, sysInfo)
+	if needsLoader(path, int(size), sysInfo.Extensions) {
+		res, err := romloader.LoadWith(path, sysInfo.Extensions, opts)
+		if err != nil {
+			return nil, path, err
+		}
+		if res.Normalized != "" {
+			logf(coreif.MessageInfo, "Loaded %s: %s", res.Name, res.Normalized)
+		}
+		return res.Data, path, nil
+	}
+	if data == nil || size == 0 {
+		return nil, path, errors.New("no ROM data provided by the frontend")
+	}
+	rom, change := normalizeContent(opts, path, C.GoBytes(data, C.int(size)))
+	if change != "" {
+		logf(coreif.MessageInfo, "Loaded %s: %s", filepath.Base(path), change)
+	}
+	return rom, path, nil
+}
+
+// setInputDescriptors names each port's buttons for the frontend's input
+// settings, following the controller plugged into the port.
+func setInputDescriptors() {
+	descs := buildInputDescriptors(sysInfo, inputMap, portControllers)
+	cDescs := make([]C.struct_retro_input_descriptor, len(descs)+1)
+	for i, d := range descs {
+		cDescs[i] = C.struct_retro_input_descriptor{
+			port:        C.uint(d.Port),
+			device:      C.RETRO_DEVICE_JOYPAD,
+			id:          C.uint(d.ID),
+			description: cString(d.Desc),
+		}
+	}
+
+	// The last entry is left zeroed as the terminator
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_INPUT_DESCRIPTORS, unsafe.Pointer(&cDescs[0]))
+}
+
+// applyController tells the emulator which controller is plugged into the
+// player's port. Empty ports keep their controller and receive no input.
+func applyController(player int) {
+	index := portControllers[player]
+	if controllerSelector == nil || index == noController || index >= len(sysInfo.Controllers) {
+		return
+	}
+	controllerSelector.SetController(player, sysInfo.Controllers[index].ID)
 }
 
 // ensureStrings allocates C strings for system info once.
@@ -457,73 +769,168 @@ func ensureStrings() {
 	}
 	libNameStr = C.CString(sysInfo.CoreName)
 	libVerStr = C.CString(sysInfo.CoreVersion)
-	validExtStr = C.CString(strings.Join(sysInfo.Extensions, "|"))
+	validExtStr = C.CString(validExtensions(sysInfo.Extensions))
 	stringsReady = true
 }
 
-// ensureOptionStrings allocates C strings for core options once.
-func ensureOptionStrings() {
-	if optKeyRegion != nil {
+// ensureOptions builds the option definitions and their C keys once.
+func ensureOptions() {
+	if optionKeys != nil {
 		return
 	}
-	optKeyRegion = C.CString(optionPrefix + "region")
-	optValRegion = C.CString("Region; Auto|NTSC|PAL")
+	optionDefs = buildOptionDefs(optionPrefix, sysInfo.CoreOptions)
+	for _, d := range optionDefs {
+		optionKeys = append(optionKeys, cString(d.Key))
+	}
+}
+
+// cString returns a C copy of s that lives for the life of the core.
+// Identical strings share one allocation. Returns nil for "".
+func cString(s string) *C.char {
+	if s == "" {
+		return nil
+	}
+	if cs, ok := cStrings[s]; ok {
+		return cs
+	}
+	if cStrings == nil {
+		cStrings = make(map[string]*C.char)
+	}
+	cs := C.CString(s)
+	cStrings[s] = cs
+	return cs
+}
 
-	// Build core-specific option strings
-	for _, opt := range sysInfo.CoreOptions {
-		coreOptKeys = append(coreOptKeys, C.CString(optionPrefix+opt.Key))
+// setCoreOptions registers the core options with the frontend using the
+// newest interface it supports: options v2 with categories, v1 with
+// descriptions, or legacy variables.
+func setCoreOptions() {
+	var version C.uint
+	if !C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_CORE_OPTIONS_VERSION, unsafe.Pointer(&version)) {
+		version = 0
+	}
 
-		var valStr string
-		switch opt.Type {
-		case coreif.CoreOptionBool:
-			if opt.Default == "true" {
-				valStr = opt.Label + "; true|false"
-			} else {
-				valStr = opt.Label + "; false|true"
-			}
-		case coreif.CoreOptionSelect:
-			ordered := reorderDefault(opt.Values, opt.Default)
-			valStr = opt.Label + "; " + strings.Join(ordered, "|")
-		default:
-			valStr = opt.Label + "; " + strings.Join(opt.Values, "|")
-		}
-		coreOptVals = append(coreOptVals, C.CString(valStr))
+	switch {
+	case version >= 2 && setCoreOptionsV2():
+	case version >= 1 && setCoreOptionsV1():
+	default:
+		setVariables()
 	}
+
+	cb := C.struct_retro_core_options_update_display_callback{
+		callback: C.retro_core_options_update_display_callback_t(C.eblitui_update_display),
+	}
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_UPDATE_DISPLAY_CALLBACK, unsafe.Pointer(&cb))
 }
 
-// setVariables registers all core options with the frontend.
-func setVariables() {
-	// Count: region + core options + terminator
-	count := 1 // region
-	count += len(coreOptKeys)
-	count++ // nil terminator
+// setCoreOptionsV2 registers options with categories. The tables are
+// C-allocated since they hold pointers; the frontend copies them.
+func setCoreOptionsV2() bool {
+	cats := usedCategories(optionDefs)
+	catBuf := C.calloc(C.size_t(len(cats)+1), C.size_t(unsafe.Sizeof(C.struct_retro_core_option_v2_category{})))
+	defer C.free(catBuf)
+	catSlice := unsafe.Slice((*C.struct_retro_core_option_v2_category)(catBuf), len(cats)+1)
+	for i, c := range cats {
+		catSlice[i].key = cString(c.Key)
+		catSlice[i].desc = cString(c.Desc)
+		catSlice[i].info = cString(c.Info)
+	}
+
+	defBuf := C.calloc(C.size_t(len(optionDefs)+1), C.size_t(unsafe.Sizeof(C.struct_retro_core_option_v2_definition{})))
+	defer C.free(defBuf)
+	defSlice := unsafe.Slice((*C.struct_retro_core_option_v2_definition)(defBuf), len(optionDefs)+1)
+	for i, d := range optionDefs {
+		def := &defSlice[i]
+		def.key = optionKeys[i]
+		def.desc = cString(d.Desc)
+		def.info = cString(d.Info)
+		def.category_key = cString(d.Category)
+		fillOptionValues(&def.values, d.Values)
+		def.default_value = cString(d.Default)
+	}
+
+	opts := (*C.struct_retro_core_options_v2)(C.calloc(1, C.size_t(unsafe.Sizeof(C.struct_retro_core_options_v2{}))))
+	defer C.free(unsafe.Pointer(opts))
+	opts.categories = &catSlice[0]
+	opts.definitions = &defSlice[0]
 
-	options := make([]C.struct_retro_variable, count)
-	idx := 0
+	return bool(C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_V2, unsafe.Pointer(opts)))
+}
+
+// setCoreOptionsV1 registers options with descriptions but no categories.
+func setCoreOptionsV1() bool {
+	defBuf := C.calloc(C.size_t(len(optionDefs)+1), C.size_t(unsafe.Sizeof(C.struct_retro_core_option_definition{})))
+	defer C.free(defBuf)
+	defSlice := unsafe.Slice((*C.struct_retro_core_option_definition)(defBuf), len(optionDefs)+1)
+	for i, d := range optionDefs {
+		def := &defSlice[i]
+		def.key = optionKeys[i]
+		def.desc = cString(d.Desc)
+		def.info = cString(d.Info)
+		fillOptionValues(&def.values, d.Values)
+		def.default_value = cString(d.Default)
+	}
 
-	options[idx] = C.struct_retro_variable{key: optKeyRegion, value: optValRegion}
-	idx++
+	return bool(C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS, defBuf))
+}
 
-	for i := range coreOptKeys {
-		options[idx] = C.struct_retro_variable{key: coreOptKeys[i], value: coreOptVals[i]}
-		idx++
+// fillOptionValues copies values into a C values array. The array is
+// zeroed, so the entry after the last value terminates the list.
+func fillOptionValues(dst *[C.RETRO_NUM_CORE_OPTION_VALUES_MAX]C.struct_retro_core_option_value, values []optionValue) {
+	for i, v := range values {
+		dst[i].value = cString(v.Value)
+		dst[i].label = cString(v.Label)
 	}
+}
 
-	// Nil terminator
-	options[idx] = C.struct_retro_variable{key: nil, value: nil}
+// setVariables registers all core options with the frontend using the
+// legacy interface, for frontends without core options support.
+func setVariables() {
+	legacy := make([]C.struct_retro_variable, len(optionDefs)+1)
+	for i, d := range optionDefs {
+		legacy[i] = C.struct_retro_variable{key: optionKeys[i], value: cString(legacyValue(d))}
+	}
 
-	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_VARIABLES, unsafe.Pointer(&options[0]))
+	// The last entry is left zeroed as the terminator
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_VARIABLES, unsafe.Pointer(&legacy[0]))
+}
+
+//export eblitui_update_display
+func eblitui_update_display() C.bool {
+	return C.bool(updateOptionDisplay())
+}
+
+// updateOptionDisplay asks the core which options apply to the loaded ROM
+// and shows or hides them in the frontend. Returns true if any changed.
+func updateOptionDisplay() bool {
+	changes := visibilityChanges(sysInfo.CoreOptions, emulator, optionVisible)
+	if len(changes) == 0 {
+		return false
+	}
+	if optionVisible == nil {
+		optionVisible = make(map[string]bool)
+	}
+	for key, visible := range changes {
+		display := C.struct_retro_core_option_display{key: cString(optionPrefix + key), visible: C.bool(visible)}
+		C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_CORE_OPTIONS_DISPLAY, unsafe.Pointer(&display))
+		optionVisible[key] = visible
+	}
+	return true
 }
 
 // updateRegionOption reads the region option from the frontend and applies it.
 func updateRegionOption() {
 	var regionVar C.struct_retro_variable
-	regionVar.key = optKeyRegion
+	regionVar.key = optionKeys[0]
 	if C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_VARIABLE, unsafe.Pointer(&regionVar)) && regionVar.value != nil {
 		newRegion := C.GoString(regionVar.value)
+		if !validValue(optionDefs[0], newRegion) {
+			logf(coreif.MessageWarn, "Invalid value %q for %s, using Auto", newRegion, optionDefs[0].Key)
+		}
 		if newRegion != optionRegion {
 			optionRegion = newRegion
 			applyRegionOption()
+			updateOptionDisplay()
 		}
 	}
 }
@@ -534,14 +941,19 @@ func applyCoreOptions() {
 	if emulator == nil {
 		return
 	}
-	for i, cKey := range coreOptKeys {
+	// optionKeys[0] is the region; core options follow in order
+	for i, opt := range sysInfo.CoreOptions {
 		var v C.struct_retro_variable
-		v.key = cKey
-		if C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_VARIABLE, unsafe.Pointer(&v)) && v.value != nil {
-			if i < len(sysInfo.CoreOptions) {
-				emulator.SetOption(sysInfo.CoreOptions[i].Key, C.GoString(v.value))
-			}
+		v.key = optionKeys[i+1]
+		if !C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_VARIABLE, unsafe.Pointer(&v)) || v.value == nil {
+			continue
 		}
+		value := C.GoString(v.value)
+		if !validValue(optionDefs[i+1], value) {
+			logf(coreif.MessageWarn, "Ignoring invalid value %q for %s", value, optionDefs[i+1].Key)
+			continue
+		}
+		emulator.SetOption(opt.Key, value)
 	}
 }
 
@@ -589,6 +1001,14 @@ func outputVideo(fb []byte, activeHeight int) {
 	}
 }
 
+// audioVideoEnable reports whether the frontend wants this frame's video
+// and audio.
+func audioVideoEnable() (video, audio bool) {
+	var flags C.int
+	ok := C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_AUDIO_VIDEO_ENABLE, unsafe.Pointer(&flags))
+	return avOutputs(bool(ok), int(flags))
+}
+
 // updateGeometry notifies the frontend of geometry changes.
 func updateGeometry() {
 	var geom C.struct_retro_game_geometry
@@ -600,29 +1020,91 @@ func updateGeometry() {
 	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_GEOMETRY, unsafe.Pointer(&geom))
 }
 
-// allocMemBuffers allocates C-side memory buffers based on MemoryMapper.
+// allocMemBuffers sets up the memory buffers based on MemoryMapper. Regions
+// the core shares through MemoryExposer are pinned and used in place;
+// the rest get C buffers that are synced around each frame.
 func allocMemBuffers() {
 	freeMemBuffers()
 	if memoryMapper == nil {
 		return
 	}
 
+	layout := layoutMemory(memoryMapper.MemoryMap())
 	memBuffers = make(map[int]*memoryBuffer)
-	for _, r := range memoryMapper.MemoryMap() {
-		buf := (*C.uint8_t)(C.malloc(C.size_t(r.Size)))
-		C.memset(unsafe.Pointer(buf), 0, C.size_t(r.Size))
-		memBuffers[r.Type] = &memoryBuffer{buf: buf, size: C.size_t(r.Size)}
+	for _, d := range layout {
+		buf := &memoryBuffer{start: d.Start}
+		var shared []byte
+		if memoryExposer != nil {
+			shared = memoryExposer.RegionMemory(d.Type)
+		}
+		if len(shared) >= d.Size {
+			memPinner.Pin(&shared[0])
+			buf.data = shared[:d.Size]
+			buf.direct = true
+			buf.pinned = true
+		} else {
+			buf.data = unsafe.Slice((*byte)(C.calloc(C.size_t(d.Size), 1)), d.Size)
+			if d.Type == coreif.MemorySaveRAM {
+				buf.shadow = make([]byte, d.Size)
+			}
+			buf.push(d.Type, memoryMapper, memoryInspector)
+		}
+		memBuffers[d.Type] = buf
+	}
+
+	setMemoryMaps(layout)
+	achievements := C.bool(supportsAchievements(layout))
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_SUPPORT_ACHIEVEMENTS, unsafe.Pointer(&achievements))
+}
+
+// setMemoryMaps describes the memory buffers to the frontend so
+// achievements and cheat search can address them.
+func setMemoryMaps(layout []memoryDesc) {
+	if len(layout) == 0 {
+		return
+	}
+
+	var endian C.uint64_t
+	if sysInfo.BigEndianMemory {
+		endian = C.RETRO_MEMDESC_BIGENDIAN
+	}
+
+	// The map points at the descriptors, so they're C-allocated to pass
+	// cgo's pointer checks; the frontend copies them
+	descBuf := C.calloc(C.size_t(len(layout)), C.size_t(unsafe.Sizeof(C.struct_retro_memory_descriptor{})))
+	defer C.free(descBuf)
+	descs := unsafe.Slice((*C.struct_retro_memory_descriptor)(descBuf), len(layout))
+	for i, d := range layout {
+		flags := endian
+		switch d.Type {
+		case coreif.MemorySystemRAM:
+			flags |= C.RETRO_MEMDESC_SYSTEM_RAM
+		case coreif.MemorySaveRAM:
+			flags |= C.RETRO_MEMDESC_SAVE_RAM
+		}
+		buf := memBuffers[d.Type]
+		descs[i].flags = flags
+		descs[i].ptr = unsafe.Pointer(&buf.data[0])
+		descs[i].start = C.size_t(d.Start)
+		descs[i].len = C.size_t(len(buf.data))
+	}
+
+	mmap := C.struct_retro_memory_map{
+		descriptors:     &descs[0],
+		num_descriptors: C.uint(len(descs)),
 	}
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_MEMORY_MAPS, unsafe.Pointer(&mmap))
 }
 
-// freeMemBuffers frees all C-allocated memory buffers.
+// freeMemBuffers frees the C memory buffers and unpins shared core memory.
 func freeMemBuffers() {
 	for _, buf := range memBuffers {
-		if buf.buf != nil {
-			C.free(unsafe.Pointer(buf.buf))
+		if !buf.pinned {
+			C.free(unsafe.Pointer(&buf.data[0]))
 		}
 	}
 	memBuffers = nil
+	memPinner.Unpin()
 }
 
 // loadBIOSFromSystemDir queries the frontend for the system directory and
@@ -632,22 +1114,160 @@ func loadBIOSFromSystemDir() {
 		return
 	}
 
-	var sysDir *C.char
-	if !C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_SYSTEM_DIRECTORY, unsafe.Pointer(&sysDir)) || sysDir == nil {
-		return
-	}
-	dir := C.GoString(sysDir)
+	dir := environDirectory(C.RETRO_ENVIRONMENT_GET_SYSTEM_DIRECTORY)
 
 	biosData = make(map[string][]byte)
 	for _, opt := range sysInfo.BIOSOptions {
-		for _, v := range opt.Variants {
-			path := filepath.Join(dir, v.Filename)
-			data, err := os.ReadFile(path)
-			if err == nil {
-				biosData[opt.Key] = data
-				break
+		if dir != "" {
+			for _, v := range opt.Variants {
+				path := filepath.Join(dir, v.Filename)
+				data, err := os.ReadFile(path)
+				if err == nil {
+					biosData[opt.Key] = data
+					break
+				}
 			}
 		}
+		if _, ok := biosData[opt.Key]; !ok {
+			showMessage(biosMessage(opt))
+		}
+	}
+}
+
+// setupLogging gets the frontend's log interface and message interface
+// version. Without a log interface messages go to stderr.
+func setupLogging() {
+	var logCb C.struct_retro_log_callback
+	if C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_LOG_INTERFACE, unsafe.Pointer(&logCb)) {
+		C._retro_set_log(logCb.log)
+	} else {
+		C._retro_set_log(nil)
+	}
+
+	if !C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_MESSAGE_INTERFACE_VERSION, unsafe.Pointer(&messageVersion)) {
+		messageVersion = 0
+	}
+}
+
+// logf writes a message to the frontend log.
+func logf(level coreif.MessageLevel, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	cs := C.CString(msg)
+	defer C.free(unsafe.Pointer(cs))
+	if !C.call_log_cb(C.uint(logLevel(level)), cs) {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+}
+
+// showMessage logs a message and, if it asks to notify, shows it on
+// screen. Also installed as the handler for coreif.MessageReporter cores.
+func showMessage(m coreif.Message) {
+	logf(m.Level, "%s", m.Text)
+	if !m.Notify {
+		return
+	}
+
+	cs := C.CString(m.Text)
+	defer C.free(unsafe.Pointer(cs))
+	ms := messageDuration(m)
+
+	// Already logged above, so the extended message only targets the OSD
+	if messageVersion >= 1 {
+		ext := C.struct_retro_message_ext{
+			msg:      cs,
+			duration: C.uint(ms),
+			priority: 1,
+			level:    C.enum_retro_log_level(logLevel(m.Level)),
+			target:   C.RETRO_MESSAGE_TARGET_OSD,
+			_type:    C.RETRO_MESSAGE_TYPE_NOTIFICATION,
+			progress: -1,
+		}
+		C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_MESSAGE_EXT, unsafe.Pointer(&ext))
+		return
+	}
+
+	fps := 60
+	if emulator != nil {
+		fps = emulator.GetTiming().FPS
+	}
+	msg := C.struct_retro_message{msg: cs, frames: C.uint(messageFrames(ms, fps))}
+	C.call_environ_cb(C.RETRO_ENVIRONMENT_SET_MESSAGE, unsafe.Pointer(&msg))
+}
+
+// environDirectory returns a directory path from the frontend, or "" if
+// the frontend doesn't provide it.
+func environDirectory(cmd C.uint) string {
+	var dir *C.char
+	if !C.call_environ_cb(cmd, unsafe.Pointer(&dir)) || dir == nil {
+		return ""
+	}
+	return C.GoString(dir)
+}
+
+// loadRumble starts the rumble engine when the frontend supports rumble,
+// the core supports memory inspection, and a CHT rumble file exists for
+// the game in the save or system directory.
+func loadRumble(romPath string) {
+	rumbleEngine = nil
+	if memoryInspector == nil {
+		return
+	}
+
+	var iface C.struct_retro_rumble_interface
+	if !C.call_environ_cb(C.RETRO_ENVIRONMENT_GET_RUMBLE_INTERFACE, unsafe.Pointer(&iface)) || iface.set_rumble_state == nil {
+		return
+	}
+
+	dirs := []string{
+		environDirectory(C.RETRO_ENVIRONMENT_GET_SAVE_DIRECTORY),
+		environDirectory(C.RETRO_ENVIRONMENT_GET_SYSTEM_DIRECTORY),
+	}
+	for _, path := range rumbleFileCandidates(dirs, romPath, crc32.ChecksumIEEE(romData)) {
+		entries, err := rumble.ParseFile(path)
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+		C._retro_set_rumble_state(iface.set_rumble_state)
+		rumbleEngine = rumble.NewEngine(entries, sysInfo.BigEndianMemory)
+		motors = newRumbleMotors(sysInfo.Players)
+		return
+	}
+}
+
+// updateRumble evaluates the rumble conditions after a frame and updates
+// the frontend's motors.
+func updateRumble() {
+	if rumbleEngine == nil || memoryInspector == nil {
+		return
+	}
+	now := time.Now()
+	for _, ev := range rumbleEngine.Evaluate(memoryInspector) {
+		motors.fire(ev, now)
+	}
+	sendRumble(motors.update(now))
+}
+
+// resetRumble restarts rumble evaluation after the emulator state jumps,
+// so the jump itself doesn't trigger rumble.
+func resetRumble() {
+	if rumbleEngine == nil {
+		return
+	}
+	rumbleEngine.Reset()
+	stopRumble()
+}
+
+// stopRumble turns off any running motors.
+func stopRumble() {
+	if motors != nil {
+		sendRumble(motors.stop())
+	}
+}
+
+// sendRumble passes motor changes to the frontend.
+func sendRumble(changes []motorChange) {
+	for _, c := range changes {
+		C.call_rumble_cb(C.uint(c.Port), C.uint(c.Effect), C.uint16_t(c.Strength))
 	}
 }
 
@@ -1,7 +1,13 @@
 package libretro
 
 import (
+	"encoding/binary"
+	"fmt"
 	"testing"
+	"unsafe"
+
+	"github.com/user-none/eblitui/coreif"
+	"github.com/user-none/eblitui/coreif/coretest"
 )
 
 // TestConvertRGBAToXRGB8888_Basic verifies R,G,B channels swap correctly
@@ -135,3 +141,134 @@ func TestJoypadConstants(t *testing.T) {
 		t.Errorf("JoypadR = %d, want 11", JoypadR)
 	}
 }
+
+// stateCore is a 4x2 core that draws a counter mixed with the input. Every
+// bit of state it uses is serialized unless leak is set, in which case a
+// second counter survives restores like an unsaved hardware register.
+type stateCore struct {
+	coreif.Emulator
+	frame  uint32
+	input  uint32
+	forced uint32 // Test input, since tests have no input callback
+	leak   bool
+	hidden uint32
+	fb     []byte
+	audio  []int16
+}
+
+func (e *stateCore) SetInput(player int, buttons uint32) { e.input = buttons | e.forced }
+func (e *stateCore) GetActiveHeight() int                { return 2 }
+func (e *stateCore) GetFramebuffer() []byte              { return e.fb }
+
+func (e *stateCore) RunFrame() {
+	e.frame = e.frame*31 + e.input + 1
+	if e.leak {
+		e.hidden++
+	}
+	for i := range e.fb {
+		e.fb[i] = byte((e.frame + e.hidden) >> (i % 4 * 8))
+	}
+	e.audio = []int16{int16(e.frame), int16(e.frame >> 16)}
+}
+
+func (e *stateCore) GetAudioSamples() []int16 { return e.audio }
+
+func (e *stateCore) Serialize() ([]byte, error) {
+	return binary.LittleEndian.AppendUint32(nil, e.frame), nil
+}
+
+func (e *stateCore) Deserialize(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("state too short: %d bytes", len(data))
+	}
+	e.frame = binary.LittleEndian.Uint32(data)
+	return nil
+}
+
+type stateFactory struct{ coreif.CoreFactory }
+
+func (stateFactory) SystemInfo() coreif.SystemInfo {
+	return coreif.SystemInfo{
+		CoreName:        "test",
+		ScreenWidth:     4,
+		MaxScreenHeight: 2,
+		Players:         1,
+		SerializeSize:   64,
+	}
+}
+
+// retroEmulator runs a core through the libretro entry points the way a
+// frontend's run-ahead does: frames with retro_run, states through
+// retro_serialize and retro_unserialize, and video as converted for the
+// frontend.
+type retroEmulator struct {
+	coreif.Emulator
+	core *stateCore
+}
+
+func (r *retroEmulator) SetInput(player int, buttons uint32) { r.core.forced = buttons }
+func (r *retroEmulator) RunFrame()                           { retro_run() }
+func (r *retroEmulator) GetAudioSamples() []int16            { return r.core.audio }
+
+func (r *retroEmulator) GetFramebuffer() []byte {
+	return xrgbBuf[:sysInfo.ScreenWidth*r.core.GetActiveHeight()*4]
+}
+
+func (r *retroEmulator) Serialize() ([]byte, error) {
+	size := retro_serialize_size()
+	data := make([]byte, size)
+	if !retro_serialize(unsafe.Pointer(&data[0]), size) {
+		return nil, fmt.Errorf("retro_serialize failed")
+	}
+	return data, nil
+}
+
+func (r *retroEmulator) Deserialize(data []byte) error {
+	size := retro_serialize_size()
+	if len(data) != int(size) {
+		return fmt.Errorf("state is %d bytes, want %d", len(data), size)
+	}
+	if !retro_unserialize(unsafe.Pointer(&data[0]), size) {
+		return fmt.Errorf("retro_unserialize failed")
+	}
+	return nil
+}
+
+// newRetroEmulator loads core into the wrapper as if a game were loaded
+func newRetroEmulator(t *testing.T, core *stateCore) *retroEmulator {
+	t.Helper()
+	RegisterFactory(stateFactory{}, nil)
+	retro_init()
+	t.Cleanup(func() {
+		retro_deinit()
+		factory = nil
+	})
+	core.fb = make([]byte, sysInfo.ScreenWidth*sysInfo.MaxScreenHeight*4)
+	setEmulator(core)
+	return &retroEmulator{core: core}
+}
+
+// testInput presses a different button mask on each frame
+func testInput(frame int) uint32 {
+	return uint32(frame%3) << 4
+}
+
+// TestDeterminism_RunAhead verifies a core whose state is fully
+// serialized replays identically through retro_serialize, retro_run and
+// retro_unserialize
+func TestDeterminism_RunAhead(t *testing.T) {
+	emu := newRetroEmulator(t, &stateCore{})
+	emu.RunFrame()
+	if err := coretest.CheckDeterminism(emu, coretest.DeterminismFrames, testInput); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestDeterminism_DetectsHiddenState verifies state missing from a save
+// state is caught through the libretro entry points
+func TestDeterminism_DetectsHiddenState(t *testing.T) {
+	emu := newRetroEmulator(t, &stateCore{leak: true})
+	if err := coretest.CheckDeterminism(emu, coretest.DeterminismFrames, testInput); err == nil {
+		t.Error("hidden state not detected")
+	}
+}
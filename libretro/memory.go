@@ -0,0 +1,113 @@
+package libretro
+
+import (
+	"bytes"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// memoryBuffer is the frontend's view of one memory region. data is either
+// a C buffer synced with the core around each frame, or the core's own
+// memory pinned in place (direct), which needs no syncing.
+type memoryBuffer struct {
+	start  uint32 // Flat address in the memory map
+	data   []byte
+	direct bool
+
+	// pinned is set when data is core memory held by memPinner rather
+	// than a C buffer. It stays set once a reset stops the buffer being
+	// direct, since the frontend still points at it.
+	pinned bool
+
+	// shadow is save RAM as last exchanged with the core, so frontend
+	// writes are only passed on when something changed. nil when direct
+	// or for other regions.
+	shadow []byte
+}
+
+// memoryDesc places a memory region in the flat address space exposed to
+// the frontend through memory maps.
+type memoryDesc struct {
+	Type  int
+	Start uint32
+	Size  int
+}
+
+// layoutMemory places each region at its MemoryRegion.Address. If no
+// region sets an address they are laid out back to back in order, which
+// matches how frontends without memory maps join system RAM and save RAM.
+// Empty regions are skipped.
+func layoutMemory(regions []coreif.MemoryRegion) []memoryDesc {
+	explicit := false
+	for _, r := range regions {
+		if r.Address != 0 {
+			explicit = true
+			break
+		}
+	}
+
+	var descs []memoryDesc
+	var next uint32
+	for _, r := range regions {
+		if r.Size <= 0 {
+			continue
+		}
+		start := r.Address
+		if !explicit {
+			start = next
+			next += uint32(r.Size)
+		}
+		descs = append(descs, memoryDesc{Type: r.Type, Start: start, Size: r.Size})
+	}
+	return descs
+}
+
+// supportsAchievements reports whether the layout exposes system RAM,
+// which achievement sets read from.
+func supportsAchievements(descs []memoryDesc) bool {
+	for _, d := range descs {
+		if d.Type == coreif.MemorySystemRAM {
+			return true
+		}
+	}
+	return false
+}
+
+// pull passes frontend changes to a save RAM buffer on to the core.
+func (b *memoryBuffer) pull(mm coreif.MemoryMapper) {
+	if b.direct || b.shadow == nil || bytes.Equal(b.data, b.shadow) {
+		return
+	}
+	copy(b.shadow, b.data)
+	mm.WriteRegion(coreif.MemorySaveRAM, b.shadow)
+}
+
+// detach stops sharing a direct buffer with the core it came from, for
+// when that core is replaced. The frontend keeps the pointer, so the
+// memory stays pinned and is synced like a C buffer from then on.
+func (b *memoryBuffer) detach(regionType int) {
+	if !b.direct {
+		return
+	}
+	b.direct = false
+	if regionType == coreif.MemorySaveRAM {
+		b.shadow = bytes.Clone(b.data)
+	}
+}
+
+// push copies the core's region into the buffer. System RAM is read
+// through MemoryInspector when available so the frontend sees the same
+// bytes standalone achievements do.
+func (b *memoryBuffer) push(regionType int, mm coreif.MemoryMapper, mi coreif.MemoryInspector) {
+	if b.direct {
+		return
+	}
+	if regionType == coreif.MemorySystemRAM && mi != nil {
+		mi.ReadMemory(b.start, b.data)
+		return
+	}
+	copy(b.data, mm.ReadRegion(regionType))
+	if b.shadow != nil {
+		copy(b.shadow, b.data)
+	}
+}
@@ -0,0 +1,331 @@
+package libretro
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// TestLayoutMemory_Sequential verifies regions without addresses are
+// placed back to back
+func TestLayoutMemory_Sequential(t *testing.T) {
+	descs := layoutMemory([]coreif.MemoryRegion{
+		{Type: coreif.MemorySystemRAM, Size: 0x10000},
+		{Type: coreif.MemorySaveRAM, Size: 0x2000},
+	})
+	if len(descs) != 2 {
+		t.Fatalf("len = %d, want 2", len(descs))
+	}
+	if descs[0].Start != 0 || descs[1].Start != 0x10000 {
+		t.Errorf("starts = %#x, %#x, want 0, 0x10000", descs[0].Start, descs[1].Start)
+	}
+	if descs[1].Type != coreif.MemorySaveRAM || descs[1].Size != 0x2000 {
+		t.Errorf("save RAM desc = %+v", descs[1])
+	}
+}
+
+// TestLayoutMemory_Explicit verifies region addresses are kept
+func TestLayoutMemory_Explicit(t *testing.T) {
+	descs := layoutMemory([]coreif.MemoryRegion{
+		{Type: coreif.MemorySaveRAM, Size: 0x800, Address: 0x20000},
+		{Type: coreif.MemorySystemRAM, Size: 0x10000},
+	})
+	if len(descs) != 2 || descs[0].Start != 0x20000 || descs[1].Start != 0 {
+		t.Errorf("descs = %+v", descs)
+	}
+}
+
+// TestLayoutMemory_SkipsEmpty verifies empty regions take no space
+func TestLayoutMemory_SkipsEmpty(t *testing.T) {
+	descs := layoutMemory([]coreif.MemoryRegion{
+		{Type: coreif.MemorySaveRAM, Size: 0},
+		{Type: coreif.MemorySystemRAM, Size: 0x400},
+	})
+	if len(descs) != 1 || descs[0].Type != coreif.MemorySystemRAM || descs[0].Start != 0 {
+		t.Errorf("descs = %+v", descs)
+	}
+}
+
+// TestSupportsAchievements verifies system RAM is required
+func TestSupportsAchievements(t *testing.T) {
+	if supportsAchievements(nil) {
+		t.Error("no regions supports achievements")
+	}
+	if supportsAchievements([]memoryDesc{{Type: coreif.MemorySaveRAM, Size: 1}}) {
+		t.Error("save RAM only supports achievements")
+	}
+	if !supportsAchievements([]memoryDesc{{Type: coreif.MemorySystemRAM, Size: 1}}) {
+		t.Error("system RAM does not support achievements")
+	}
+}
+
+// fakeMemoryCore is a MemoryMapper with 64KB system RAM and 8KB save RAM.
+// ReadRegion returns copies, as cores do.
+type fakeMemoryCore struct {
+	ram    []byte
+	sram   []byte
+	writes int
+}
+
+func newFakeMemoryCore() *fakeMemoryCore {
+	return &fakeMemoryCore{ram: make([]byte, 0x10000), sram: make([]byte, 0x2000)}
+}
+
+func (c *fakeMemoryCore) MemoryMap() []coreif.MemoryRegion {
+	return []coreif.MemoryRegion{
+		{Type: coreif.MemorySystemRAM, Size: len(c.ram)},
+		{Type: coreif.MemorySaveRAM, Size: len(c.sram)},
+	}
+}
+
+func (c *fakeMemoryCore) region(regionType int) []byte {
+	if regionType == coreif.MemorySaveRAM {
+		return c.sram
+	}
+	return c.ram
+}
+
+func (c *fakeMemoryCore) ReadRegion(regionType int) []byte {
+	return append([]byte(nil), c.region(regionType)...)
+}
+
+func (c *fakeMemoryCore) WriteRegion(regionType int, data []byte) {
+	c.writes++
+	copy(c.region(regionType), data)
+}
+
+func (c *fakeMemoryCore) ReadMemory(addr uint32, buf []byte) uint32 {
+	return uint32(copy(buf, c.ram[addr:]))
+}
+
+// newTestBuffers builds synced buffers for the core; direct buffers use
+// the core's memory
+func newTestBuffers(c *fakeMemoryCore, direct bool) map[int]*memoryBuffer {
+	bufs := make(map[int]*memoryBuffer)
+	for _, d := range layoutMemory(c.MemoryMap()) {
+		buf := &memoryBuffer{start: d.Start}
+		if direct {
+			buf.data = c.region(d.Type)
+			buf.direct = true
+		} else {
+			buf.data = make([]byte, d.Size)
+			if d.Type == coreif.MemorySaveRAM {
+				buf.shadow = make([]byte, d.Size)
+			}
+			buf.push(d.Type, c, nil)
+		}
+		bufs[d.Type] = buf
+	}
+	return bufs
+}
+
+// TestMemoryBuffer_Pull verifies frontend save RAM writes reach the core
+// only when changed
+func TestMemoryBuffer_Pull(t *testing.T) {
+	c := newFakeMemoryCore()
+	buf := newTestBuffers(c, false)[coreif.MemorySaveRAM]
+
+	buf.pull(c)
+	if c.writes != 0 {
+		t.Errorf("writes = %d after unchanged pull, want 0", c.writes)
+	}
+
+	buf.data[10] = 0x42
+	buf.pull(c)
+	if c.writes != 1 || c.sram[10] != 0x42 {
+		t.Errorf("writes = %d, sram[10] = %#x, want 1 and 0x42", c.writes, c.sram[10])
+	}
+
+	buf.pull(c)
+	if c.writes != 1 {
+		t.Errorf("writes = %d after second pull, want 1", c.writes)
+	}
+}
+
+// TestMemoryBuffer_Push verifies core changes reach the frontend without
+// being mistaken for frontend writes
+func TestMemoryBuffer_Push(t *testing.T) {
+	c := newFakeMemoryCore()
+	buf := newTestBuffers(c, false)[coreif.MemorySaveRAM]
+
+	c.sram[5] = 0x99
+	buf.push(coreif.MemorySaveRAM, c, nil)
+	if buf.data[5] != 0x99 {
+		t.Errorf("data[5] = %#x, want 0x99", buf.data[5])
+	}
+	buf.pull(c)
+	if c.writes != 0 {
+		t.Errorf("writes = %d, want 0", c.writes)
+	}
+}
+
+// TestMemoryBuffer_PushInspector verifies system RAM is read through
+// MemoryInspector at the region's address
+func TestMemoryBuffer_PushInspector(t *testing.T) {
+	c := newFakeMemoryCore()
+	c.ram[0x100] = 0x12
+	buf := &memoryBuffer{start: 0x100, data: make([]byte, 4)}
+
+	buf.push(coreif.MemorySystemRAM, c, c)
+	if buf.data[0] != 0x12 {
+		t.Errorf("data[0] = %#x, want 0x12", buf.data[0])
+	}
+}
+
+// TestMemoryBuffer_Direct verifies shared memory is never copied
+func TestMemoryBuffer_Direct(t *testing.T) {
+	c := newFakeMemoryCore()
+	bufs := newTestBuffers(c, true)
+
+	bufs[coreif.MemorySaveRAM].data[0] = 0x7f
+	bufs[coreif.MemorySaveRAM].pull(c)
+	if c.writes != 0 || c.sram[0] != 0x7f {
+		t.Errorf("writes = %d, sram[0] = %#x, want 0 and 0x7f", c.writes, c.sram[0])
+	}
+}
+
+// syncFrame runs the per-frame memory sync around an emulated frame
+func syncFrame(c *fakeMemoryCore, bufs map[int]*memoryBuffer) {
+	if buf, ok := bufs[coreif.MemorySaveRAM]; ok {
+		buf.pull(c)
+	}
+	for regionType, buf := range bufs {
+		buf.push(regionType, c, nil)
+	}
+}
+
+// copyFrame is the sync the wrapper did before buffers were tracked:
+// save RAM copied into a new slice before every frame and every region
+// copied back after
+func copyFrame(c *fakeMemoryCore, bufs map[int]*memoryBuffer) {
+	if buf, ok := bufs[coreif.MemorySaveRAM]; ok {
+		goData := make([]byte, len(buf.data))
+		copy(goData, buf.data)
+		c.WriteRegion(coreif.MemorySaveRAM, goData)
+	}
+	for regionType, buf := range bufs {
+		copy(buf.data, c.ReadRegion(regionType))
+	}
+}
+
+// resetCore is an emulator backed by fakeMemoryCore memory
+type resetCore struct {
+	coreif.Emulator
+	*fakeMemoryCore
+}
+
+func (c *resetCore) Start() {}
+
+// exposedResetCore also shares its memory in place
+type exposedResetCore struct{ resetCore }
+
+func (c *exposedResetCore) RegionMemory(regionType int) []byte {
+	return c.region(regionType)
+}
+
+// resetFactory creates cores without Resetter, so retro_reset recreates
+// them. Each core's first RAM byte is its creation count.
+type resetFactory struct {
+	coreif.CoreFactory
+	expose bool
+	cores  []*fakeMemoryCore
+}
+
+func (f *resetFactory) CreateEmulator(rom []byte, region coreif.Region) (coreif.Emulator, error) {
+	c := newFakeMemoryCore()
+	f.cores = append(f.cores, c)
+	c.ram[0] = byte(len(f.cores))
+	if f.expose {
+		return &exposedResetCore{resetCore{fakeMemoryCore: c}}, nil
+	}
+	return &resetCore{fakeMemoryCore: c}, nil
+}
+
+// TestRetroReset_KeepsMemoryBuffers verifies recreating the emulator on
+// reset keeps the buffers the frontend was given, fills them from the new
+// emulator, and carries save RAM over
+func TestRetroReset_KeepsMemoryBuffers(t *testing.T) {
+	const retroMemorySaveRAM, retroMemorySystemRAM = 0, 2
+
+	for _, expose := range []bool{false, true} {
+		name := "copied"
+		if expose {
+			name = "direct"
+		}
+		t.Run(name, func(t *testing.T) {
+			f := &resetFactory{expose: expose}
+			factory, romData = f, []byte{0}
+			t.Cleanup(func() {
+				retro_unload_game()
+				factory = nil
+			})
+			emu, _ := f.CreateEmulator(romData, region)
+			setEmulator(emu)
+			allocMemBuffers()
+
+			sramPtr := retro_get_memory_data(retroMemorySaveRAM)
+			ramPtr := retro_get_memory_data(retroMemorySystemRAM)
+			if sramPtr == nil || ramPtr == nil {
+				t.Fatal("memory not exposed")
+			}
+			sram := unsafe.Slice((*byte)(sramPtr), retro_get_memory_size(retroMemorySaveRAM))
+			ram := unsafe.Slice((*byte)(ramPtr), retro_get_memory_size(retroMemorySystemRAM))
+
+			// A save file loaded by the frontend after load
+			sram[3] = 0x5a
+			retro_reset()
+
+			if len(f.cores) != 2 {
+				t.Fatalf("cores created = %d, want 2", len(f.cores))
+			}
+			if p := retro_get_memory_data(retroMemorySaveRAM); p != sramPtr {
+				t.Errorf("save RAM pointer changed from %p to %p", sramPtr, p)
+			}
+			if p := retro_get_memory_data(retroMemorySystemRAM); p != ramPtr {
+				t.Errorf("system RAM pointer changed from %p to %p", ramPtr, p)
+			}
+			if f.cores[1].sram[3] != 0x5a {
+				t.Errorf("new core sram[3] = %#x, want 0x5a", f.cores[1].sram[3])
+			}
+			if ram[0] != 2 {
+				t.Errorf("system RAM shows core %d, want 2", ram[0])
+			}
+
+			// Frontend writes keep reaching the new core
+			sram[4] = 0x66
+			memBuffers[coreif.MemorySaveRAM].pull(memoryMapper)
+			if f.cores[1].sram[4] != 0x66 {
+				t.Errorf("new core sram[4] = %#x, want 0x66", f.cores[1].sram[4])
+			}
+		})
+	}
+}
+
+// BenchmarkMemorySync measures the per-frame memory sync cost
+func BenchmarkMemorySync(b *testing.B) {
+	b.Run("copy", func(b *testing.B) {
+		c := newFakeMemoryCore()
+		bufs := newTestBuffers(c, false)
+		b.ReportAllocs()
+		for b.Loop() {
+			copyFrame(c, bufs)
+		}
+	})
+	b.Run("tracked", func(b *testing.B) {
+		c := newFakeMemoryCore()
+		bufs := newTestBuffers(c, false)
+		b.ReportAllocs()
+		for b.Loop() {
+			syncFrame(c, bufs)
+		}
+	})
+	b.Run("direct", func(b *testing.B) {
+		c := newFakeMemoryCore()
+		bufs := newTestBuffers(c, true)
+		b.ReportAllocs()
+		for b.Loop() {
+			syncFrame(c, bufs)
+		}
+	})
+}
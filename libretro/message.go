@@ -0,0 +1,59 @@
+package libretro
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// defaultMessageMs is how long notifications stay on screen when the
+// core doesn't say.
+const defaultMessageMs = 3000
+
+// logLevel maps a message level to a RETRO_LOG_* level. The values match,
+// so this only clamps levels outside the known range.
+func logLevel(level coreif.MessageLevel) int {
+	return min(max(int(level), int(coreif.MessageDebug)), int(coreif.MessageError))
+}
+
+// messageDuration returns how long m stays on screen in milliseconds.
+func messageDuration(m coreif.Message) int {
+	if m.Duration <= 0 {
+		return defaultMessageMs
+	}
+	return m.Duration
+}
+
+// messageFrames converts an on-screen time to frames for frontends that
+// only support RETRO_ENVIRONMENT_SET_MESSAGE.
+func messageFrames(ms, fps int) int {
+	if fps <= 0 {
+		fps = 60
+	}
+	return max((ms*fps+999)/1000, 1)
+}
+
+// biosMessage reports a BIOS missing from the system directory. Required
+// BIOS are errors shown on screen; optional ones are only logged.
+func biosMessage(opt coreif.BIOSOption) coreif.Message {
+	names := make([]string, 0, len(opt.Variants))
+	for _, v := range opt.Variants {
+		if v.Filename != "" {
+			names = append(names, v.Filename)
+		}
+	}
+	files := strings.Join(names, ", ")
+
+	if opt.Required {
+		return coreif.Message{
+			Level:  coreif.MessageError,
+			Text:   fmt.Sprintf("BIOS required: %s (%s)", opt.Label, files),
+			Notify: true,
+		}
+	}
+	return coreif.Message{
+		Level: coreif.MessageInfo,
+		Text:  fmt.Sprintf("Optional BIOS not found: %s (%s)", opt.Label, files),
+	}
+}
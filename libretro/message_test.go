@@ -0,0 +1,76 @@
+package libretro
+
+import (
+	"testing"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// TestLogLevel verifies levels map to RETRO_LOG_* and are clamped
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		level coreif.MessageLevel
+		want  int
+	}{
+		{coreif.MessageDebug, 0},
+		{coreif.MessageInfo, 1},
+		{coreif.MessageWarn, 2},
+		{coreif.MessageError, 3},
+		{-1, 0},
+		{9, 3},
+	}
+	for _, tc := range tests {
+		if got := logLevel(tc.level); got != tc.want {
+			t.Errorf("logLevel(%d) = %d, want %d", tc.level, got, tc.want)
+		}
+	}
+}
+
+// TestMessageDuration verifies the default on-screen time
+func TestMessageDuration(t *testing.T) {
+	if got := messageDuration(coreif.Message{}); got != defaultMessageMs {
+		t.Errorf("default = %d, want %d", got, defaultMessageMs)
+	}
+	if got := messageDuration(coreif.Message{Duration: 500}); got != 500 {
+		t.Errorf("duration = %d, want 500", got)
+	}
+}
+
+// TestMessageFrames verifies durations round up to whole frames
+func TestMessageFrames(t *testing.T) {
+	tests := []struct {
+		ms, fps, want int
+	}{
+		{3000, 60, 180},
+		{3000, 50, 150},
+		{10, 60, 1},
+		{0, 60, 1},
+		{1000, 0, 60},
+	}
+	for _, tc := range tests {
+		if got := messageFrames(tc.ms, tc.fps); got != tc.want {
+			t.Errorf("messageFrames(%d, %d) = %d, want %d", tc.ms, tc.fps, got, tc.want)
+		}
+	}
+}
+
+// TestBIOSMessage verifies required BIOS are shown and optional ones logged
+func TestBIOSMessage(t *testing.T) {
+	opt := coreif.BIOSOption{
+		Label:    "System BIOS",
+		Required: true,
+		Variants: []coreif.BIOSVariant{{Filename: "bios_us.bin"}, {Filename: "bios_jp.bin"}},
+	}
+	m := biosMessage(opt)
+	if m.Level != coreif.MessageError || !m.Notify {
+		t.Errorf("required message = %+v, want notified error", m)
+	}
+	if m.Text != "BIOS required: System BIOS (bios_us.bin, bios_jp.bin)" {
+		t.Errorf("text = %q", m.Text)
+	}
+
+	opt.Required = false
+	if m := biosMessage(opt); m.Level != coreif.MessageInfo || m.Notify {
+		t.Errorf("optional message = %+v, want logged info", m)
+	}
+}
@@ -0,0 +1,207 @@
+package libretro
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// maxOptionValues is the number of values an option can list, leaving room
+// for the terminator in RETRO_NUM_CORE_OPTION_VALUES_MAX.
+const maxOptionValues = 127
+
+// optionValue is one choice of a core option.
+type optionValue struct {
+	Value string
+	Label string // Empty uses Value
+}
+
+// optionCategory is a libretro core option category.
+type optionCategory struct {
+	Key  string
+	Desc string
+	Info string
+}
+
+// optionDef is a core option in the form registered with the frontend.
+// The same definitions back the v2, v1, and legacy option interfaces.
+type optionDef struct {
+	Key      string // Full key including the core prefix
+	Desc     string
+	Info     string
+	Category string // optionCategory key
+	Values   []optionValue
+	Default  string
+}
+
+// optionCategories are the libretro categories core options are grouped
+// into, in display order.
+var optionCategories = []optionCategory{
+	{Key: "system", Desc: "System", Info: "Region and emulation settings."},
+	{Key: "video", Desc: "Video", Info: "Picture and display settings."},
+	{Key: "audio", Desc: "Audio", Info: "Sound settings."},
+	{Key: "input", Desc: "Input", Info: "Controller settings."},
+}
+
+// categoryKey maps a coreif option category to a libretro category key.
+func categoryKey(c coreif.CoreOptionCategory) string {
+	switch c {
+	case coreif.CoreOptionCategoryAudio:
+		return "audio"
+	case coreif.CoreOptionCategoryVideo:
+		return "video"
+	case coreif.CoreOptionCategoryInput:
+		return "input"
+	default:
+		return "system"
+	}
+}
+
+// buildOptionDefs returns the region option followed by the core's
+// options, with keys prefixed by prefix.
+func buildOptionDefs(prefix string, opts []coreif.CoreOption) []optionDef {
+	defs := make([]optionDef, 0, len(opts)+1)
+	defs = append(defs, optionDef{
+		Key:      prefix + "region",
+		Desc:     "Region",
+		Info:     "Video region. Auto uses the region detected from the ROM.",
+		Category: "system",
+		Values:   []optionValue{{Value: "Auto"}, {Value: "NTSC"}, {Value: "PAL"}},
+		Default:  "Auto",
+	})
+
+	for _, opt := range opts {
+		defs = append(defs, optionDef{
+			Key:      prefix + opt.Key,
+			Desc:     opt.Label,
+			Info:     opt.Description,
+			Category: categoryKey(opt.Category),
+			Values:   optionValues(opt),
+			Default:  opt.Default,
+		})
+	}
+	return defs
+}
+
+// usedCategories returns the categories that at least one option uses.
+func usedCategories(defs []optionDef) []optionCategory {
+	used := make(map[string]bool)
+	for _, d := range defs {
+		used[d.Category] = true
+	}
+	var cats []optionCategory
+	for _, c := range optionCategories {
+		if used[c.Key] {
+			cats = append(cats, c)
+		}
+	}
+	return cats
+}
+
+// optionValues lists the values of a core option. Bool options use
+// "true"/"false" so SetOption receives the same values as the standalone
+// UI. Range options are expanded from Min to Max by Step; ranges with more
+// values than libretro allows use a coarser step.
+func optionValues(opt coreif.CoreOption) []optionValue {
+	switch opt.Type {
+	case coreif.CoreOptionBool:
+		return []optionValue{{Value: "false", Label: "Disabled"}, {Value: "true", Label: "Enabled"}}
+	case coreif.CoreOptionRange:
+		return rangeValues(opt)
+	default:
+		values := make([]optionValue, 0, len(opt.Values))
+		for _, v := range opt.Values {
+			values = append(values, optionValue{Value: v})
+		}
+		if len(values) > maxOptionValues {
+			logf(coreif.MessageWarn, "Option %s has %d values, listing the first %d",
+				opt.Key, len(values), maxOptionValues)
+			values = values[:maxOptionValues]
+		}
+		return values
+	}
+}
+
+// rangeValues expands a Range option into its values. Ranges with too
+// many values use a coarser step, keeping Max and the default so both
+// stay selectable.
+func rangeValues(opt coreif.CoreOption) []optionValue {
+	if opt.Max < opt.Min {
+		return nil
+	}
+	step := max(opt.Step, 1)
+	count := (opt.Max-opt.Min)/step + 1
+	if count > maxOptionValues {
+		// Room for Max and the default off the coarser step
+		step *= (count + maxOptionValues - 3) / (maxOptionValues - 2)
+		logf(coreif.MessageWarn, "Option %s has %d values, using a step of %d to fit %d",
+			opt.Key, count, step, maxOptionValues)
+	}
+
+	var nums []int
+	for v := opt.Min; v <= opt.Max; v += step {
+		nums = append(nums, v)
+	}
+	nums = append(nums, opt.Max)
+	if def, err := strconv.Atoi(opt.Default); err == nil && def >= opt.Min && def <= opt.Max {
+		nums = append(nums, def)
+	}
+	slices.Sort(nums)
+	nums = slices.Compact(nums)
+
+	values := make([]optionValue, len(nums))
+	for i, v := range nums {
+		values[i] = optionValue{Value: strconv.Itoa(v)}
+	}
+	return values
+}
+
+// validValue reports whether value is one of the definition's values.
+func validValue(d optionDef, value string) bool {
+	for _, v := range d.Values {
+		if v.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyValue formats a definition for RETRO_ENVIRONMENT_SET_VARIABLES:
+// "Desc; default|other|..." with the default value first.
+func legacyValue(d optionDef) string {
+	values := make([]string, len(d.Values))
+	for i, v := range d.Values {
+		values[i] = v.Value
+	}
+	if d.Default != "" {
+		values = reorderDefault(values, d.Default)
+	}
+	return d.Desc + "; " + strings.Join(values, "|")
+}
+
+// visibilityChanges returns the options whose visibility differs from
+// current, the state last reported to the frontend (missing keys are
+// visible). Cores that don't implement coreif.OptionVisibility, or no
+// loaded core, show every option.
+func visibilityChanges(opts []coreif.CoreOption, emu coreif.Emulator, current map[string]bool) map[string]bool {
+	vis, _ := emu.(coreif.OptionVisibility)
+
+	var changes map[string]bool
+	for _, opt := range opts {
+		visible := vis == nil || vis.OptionVisible(opt.Key)
+		shown, ok := current[opt.Key]
+		if !ok {
+			shown = true
+		}
+		if visible == shown {
+			continue
+		}
+		if changes == nil {
+			changes = make(map[string]bool)
+		}
+		changes[opt.Key] = visible
+	}
+	return changes
+}
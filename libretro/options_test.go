@@ -0,0 +1,198 @@
+package libretro
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/user-none/eblitui/coreif"
+)
+
+// TestBuildOptionDefs verifies the region option comes first and core
+// options keep their metadata
+func TestBuildOptionDefs(t *testing.T) {
+	opts := []coreif.CoreOption{
+		{Key: "sprites", Label: "Sprite Limit", Description: "Limit sprites per line", Type: coreif.CoreOptionBool, Default: "true", Category: coreif.CoreOptionCategoryVideo},
+		{Key: "volume", Label: "FM Volume", Type: coreif.CoreOptionRange, Default: "50", Min: 0, Max: 100, Step: 25, Category: coreif.CoreOptionCategoryAudio},
+	}
+	defs := buildOptionDefs("test_", opts)
+
+	if len(defs) != 3 {
+		t.Fatalf("len = %d, want 3", len(defs))
+	}
+	if defs[0].Key != "test_region" || defs[0].Category != "system" || defs[0].Default != "Auto" {
+		t.Errorf("region def = %+v", defs[0])
+	}
+	if d := defs[1]; d.Key != "test_sprites" || d.Desc != "Sprite Limit" || d.Info != "Limit sprites per line" || d.Category != "video" {
+		t.Errorf("sprites def = %+v", d)
+	}
+	if d := defs[2]; d.Category != "audio" || len(d.Values) != 5 || d.Default != "50" {
+		t.Errorf("volume def = %+v", d)
+	}
+}
+
+// TestCategoryKey verifies every coreif category maps to a libretro category
+func TestCategoryKey(t *testing.T) {
+	tests := []struct {
+		cat  coreif.CoreOptionCategory
+		want string
+	}{
+		{coreif.CoreOptionCategoryAudio, "audio"},
+		{coreif.CoreOptionCategoryVideo, "video"},
+		{coreif.CoreOptionCategoryInput, "input"},
+		{coreif.CoreOptionCategoryCore, "system"},
+	}
+	for _, tc := range tests {
+		if got := categoryKey(tc.cat); got != tc.want {
+			t.Errorf("categoryKey(%d) = %q, want %q", tc.cat, got, tc.want)
+		}
+	}
+}
+
+// TestUsedCategories verifies only categories with options are registered
+func TestUsedCategories(t *testing.T) {
+	defs := []optionDef{{Category: "audio"}, {Category: "system"}, {Category: "audio"}}
+	cats := usedCategories(defs)
+	if len(cats) != 2 || cats[0].Key != "system" || cats[1].Key != "audio" {
+		t.Errorf("categories = %+v, want system and audio in display order", cats)
+	}
+}
+
+// TestRangeValues verifies range expansion including the end value
+func TestRangeValues(t *testing.T) {
+	values := rangeValues(coreif.CoreOption{Type: coreif.CoreOptionRange, Min: -2, Max: 4, Step: 2})
+	want := []string{"-2", "0", "2", "4"}
+	if len(values) != len(want) {
+		t.Fatalf("len = %d, want %d", len(values), len(want))
+	}
+	for i, v := range values {
+		if v.Value != want[i] {
+			t.Errorf("values[%d] = %q, want %q", i, v.Value, want[i])
+		}
+	}
+}
+
+// TestRangeValues_ZeroStep verifies a missing step counts by one
+func TestRangeValues_ZeroStep(t *testing.T) {
+	values := rangeValues(coreif.CoreOption{Type: coreif.CoreOptionRange, Min: 1, Max: 3})
+	if len(values) != 3 {
+		t.Errorf("len = %d, want 3", len(values))
+	}
+}
+
+// TestRangeValues_Large verifies large ranges fit libretro's value limit
+// and keep Max and the default selectable
+func TestRangeValues_Large(t *testing.T) {
+	values := rangeValues(coreif.CoreOption{
+		Key: "large", Type: coreif.CoreOptionRange, Min: 0, Max: 1000, Step: 1, Default: "333",
+	})
+	if len(values) == 0 || len(values) > maxOptionValues {
+		t.Fatalf("len = %d, want 1..%d", len(values), maxOptionValues)
+	}
+	if values[0].Value != "0" {
+		t.Errorf("first = %q, want \"0\"", values[0].Value)
+	}
+	if last := values[len(values)-1].Value; last != "1000" {
+		t.Errorf("last = %q, want \"1000\"", last)
+	}
+	found := false
+	for i, v := range values {
+		if v.Value == "333" {
+			found = true
+		}
+		if i > 0 {
+			prev, _ := strconv.Atoi(values[i-1].Value)
+			cur, _ := strconv.Atoi(v.Value)
+			if cur <= prev {
+				t.Errorf("values not ascending at %d: %s, %s", i, values[i-1].Value, v.Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("default 333 missing from values")
+	}
+}
+
+// TestOptionValues_Bool verifies bool options keep true/false values
+func TestOptionValues_Bool(t *testing.T) {
+	values := optionValues(coreif.CoreOption{Type: coreif.CoreOptionBool})
+	if len(values) != 2 || values[0].Value != "false" || values[1].Value != "true" {
+		t.Errorf("values = %+v", values)
+	}
+	if values[1].Label != "Enabled" {
+		t.Errorf("true label = %q, want \"Enabled\"", values[1].Label)
+	}
+}
+
+// TestLegacyValue verifies the legacy variable string puts the default first
+func TestLegacyValue(t *testing.T) {
+	d := optionDef{
+		Desc:    "Sprite Limit",
+		Values:  []optionValue{{Value: "false"}, {Value: "true"}},
+		Default: "true",
+	}
+	if got := legacyValue(d); got != "Sprite Limit; true|false" {
+		t.Errorf("legacyValue = %q", got)
+	}
+
+	region := buildOptionDefs("x_", nil)[0]
+	if got := legacyValue(region); got != "Region; Auto|NTSC|PAL" {
+		t.Errorf("region legacyValue = %q", got)
+	}
+}
+
+// TestValidValue verifies values are checked against the definition
+func TestValidValue(t *testing.T) {
+	region := buildOptionDefs("x_", nil)[0]
+	if !validValue(region, "PAL") {
+		t.Error("PAL is not a valid region")
+	}
+	if validValue(region, "SECAM") || validValue(region, "") {
+		t.Error("unknown region value accepted")
+	}
+}
+
+// visibilityEmulator hides options listed in hidden
+type visibilityEmulator struct {
+	coreif.Emulator
+	hidden map[string]bool
+}
+
+func (e *visibilityEmulator) OptionVisible(key string) bool {
+	return !e.hidden[key]
+}
+
+// TestVisibilityChanges verifies only changed visibility is reported
+func TestVisibilityChanges(t *testing.T) {
+	opts := []coreif.CoreOption{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+	emu := &visibilityEmulator{hidden: map[string]bool{"b": true}}
+
+	changes := visibilityChanges(opts, emu, nil)
+	if len(changes) != 1 || changes["b"] != false {
+		t.Fatalf("changes = %v, want b hidden", changes)
+	}
+
+	// Nothing changes once the frontend has the current state
+	current := map[string]bool{"b": false}
+	if changes := visibilityChanges(opts, emu, current); changes != nil {
+		t.Errorf("changes = %v, want none", changes)
+	}
+
+	// Showing b again is reported
+	emu.hidden = nil
+	changes = visibilityChanges(opts, emu, current)
+	if len(changes) != 1 || changes["b"] != true {
+		t.Errorf("changes = %v, want b visible", changes)
+	}
+}
+
+// TestVisibilityChanges_NoSupport verifies cores without
+// OptionVisibility, or no core, show everything
+func TestVisibilityChanges_NoSupport(t *testing.T) {
+	opts := []coreif.CoreOption{{Key: "a"}}
+	if changes := visibilityChanges(opts, nil, nil); changes != nil {
+		t.Errorf("nil emulator changes = %v, want none", changes)
+	}
+	if changes := visibilityChanges(opts, nil, map[string]bool{"a": false}); !changes["a"] {
+		t.Errorf("changes = %v, want a shown again", changes)
+	}
+}
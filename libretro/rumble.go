@@ -0,0 +1,117 @@
+package libretro
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/user-none/eblitui/rumble"
+)
+
+// Libretro rumble effects, mirrored from libretro.h.
+const (
+	rumbleStrong = 0
+	rumbleWeak   = 1
+)
+
+// motorState is one rumble motor on one port.
+type motorState struct {
+	strength uint16
+	until    time.Time
+	reported uint16 // Strength last sent to the frontend
+}
+
+// motorChange is a rumble state to send to the frontend.
+type motorChange struct {
+	Port     int
+	Effect   int
+	Strength uint16
+}
+
+// rumbleMotors turns rumble engine events, which carry a duration, into
+// the on/off strength changes libretro's set_rumble_state expects.
+type rumbleMotors struct {
+	ports [][2]motorState
+}
+
+// newRumbleMotors creates stopped motors for the given number of ports.
+func newRumbleMotors(ports int) *rumbleMotors {
+	return &rumbleMotors{ports: make([][2]motorState, ports)}
+}
+
+// fire starts the motors for an event. Events for a port outside 0-15, or
+// past the last port, rumble every port.
+func (m *rumbleMotors) fire(ev rumble.Event, now time.Time) {
+	first, last := 0, len(m.ports)
+	if ev.Port >= 0 && ev.Port < 16 && ev.Port < len(m.ports) {
+		first, last = ev.Port, ev.Port+1
+	}
+	for port := first; port < last; port++ {
+		if ev.StrongDurationMs > 0 {
+			m.ports[port][rumbleStrong].start(ev.StrongMagnitude, ev.StrongDurationMs, now)
+		}
+		if ev.WeakDurationMs > 0 {
+			m.ports[port][rumbleWeak].start(ev.WeakMagnitude, ev.WeakDurationMs, now)
+		}
+	}
+}
+
+// start runs the motor at magnitude (0-1) for durationMs.
+func (s *motorState) start(magnitude float64, durationMs int, now time.Time) {
+	s.strength = uint16(min(max(magnitude, 0), 1) * 65535)
+	s.until = now.Add(time.Duration(durationMs) * time.Millisecond)
+}
+
+// update returns the motors whose strength changed since the last update,
+// turning off those whose duration has passed.
+func (m *rumbleMotors) update(now time.Time) []motorChange {
+	var changes []motorChange
+	for port := range m.ports {
+		for effect := range m.ports[port] {
+			s := &m.ports[port][effect]
+			var want uint16
+			if now.Before(s.until) {
+				want = s.strength
+			}
+			if want != s.reported {
+				s.reported = want
+				changes = append(changes, motorChange{Port: port, Effect: effect, Strength: want})
+			}
+		}
+	}
+	return changes
+}
+
+// stop turns every motor off and returns the motors that were running.
+func (m *rumbleMotors) stop() []motorChange {
+	for port := range m.ports {
+		for effect := range m.ports[port] {
+			m.ports[port][effect].until = time.Time{}
+		}
+	}
+	return m.update(time.Time{})
+}
+
+// rumbleFileCandidates lists where a game's CHT rumble file may be, in
+// search order. Each directory's rumble folder is checked for a file
+// named by the ROM's CRC32, as standalone stores them, then by the ROM's
+// name as in libretro-database. Empty directories are skipped.
+func rumbleFileCandidates(dirs []string, romPath string, crc uint32) []string {
+	names := []string{fmt.Sprintf("%08x.cht", crc)}
+	if romPath != "" {
+		base := filepath.Base(romPath)
+		names = append(names, strings.TrimSuffix(base, filepath.Ext(base))+" (Rumbles).cht")
+	}
+
+	var paths []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, "rumble", name))
+		}
+	}
+	return paths
+}
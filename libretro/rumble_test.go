@@ -0,0 +1,104 @@
+package libretro
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user-none/eblitui/rumble"
+)
+
+// TestRumbleMotors_Port verifies an event only rumbles its port
+func TestRumbleMotors_Port(t *testing.T) {
+	m := newRumbleMotors(2)
+	now := time.Now()
+
+	m.fire(rumble.Event{Port: 1, StrongMagnitude: 1, StrongDurationMs: 100}, now)
+	changes := m.update(now)
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want 1", changes)
+	}
+	if c := changes[0]; c.Port != 1 || c.Effect != rumbleStrong || c.Strength != 65535 {
+		t.Errorf("change = %+v, want port 1 strong 65535", c)
+	}
+}
+
+// TestRumbleMotors_AllPorts verifies out of range ports rumble every port
+func TestRumbleMotors_AllPorts(t *testing.T) {
+	for _, port := range []int{16, 5, -1} {
+		m := newRumbleMotors(2)
+		now := time.Now()
+		m.fire(rumble.Event{Port: port, WeakMagnitude: 0.5, WeakDurationMs: 100}, now)
+		changes := m.update(now)
+		if len(changes) != 2 {
+			t.Errorf("port %d: changes = %+v, want both ports", port, changes)
+			continue
+		}
+		for _, c := range changes {
+			if c.Effect != rumbleWeak || c.Strength != 32767 {
+				t.Errorf("port %d: change = %+v, want weak 32767", port, c)
+			}
+		}
+	}
+}
+
+// TestRumbleMotors_Duration verifies motors turn off once their duration
+// passes and unchanged motors aren't resent
+func TestRumbleMotors_Duration(t *testing.T) {
+	m := newRumbleMotors(1)
+	now := time.Now()
+	m.fire(rumble.Event{Port: 0, StrongMagnitude: 1, StrongDurationMs: 100}, now)
+	m.update(now)
+
+	if changes := m.update(now.Add(50 * time.Millisecond)); len(changes) != 0 {
+		t.Errorf("changes while running = %+v, want none", changes)
+	}
+	changes := m.update(now.Add(100 * time.Millisecond))
+	if len(changes) != 1 || changes[0].Strength != 0 {
+		t.Errorf("changes after duration = %+v, want strong off", changes)
+	}
+}
+
+// TestRumbleMotors_Stop verifies stop turns off running motors only
+func TestRumbleMotors_Stop(t *testing.T) {
+	m := newRumbleMotors(2)
+	now := time.Now()
+	m.fire(rumble.Event{Port: 0, StrongMagnitude: 1, StrongDurationMs: 1000}, now)
+	m.update(now)
+
+	changes := m.stop()
+	if len(changes) != 1 || changes[0].Port != 0 || changes[0].Strength != 0 {
+		t.Errorf("stop changes = %+v, want port 0 off", changes)
+	}
+	if changes := m.stop(); len(changes) != 0 {
+		t.Errorf("second stop changes = %+v, want none", changes)
+	}
+}
+
+// TestRumbleFileCandidates verifies search order and naming
+func TestRumbleFileCandidates(t *testing.T) {
+	paths := rumbleFileCandidates([]string{"/save", "", "/system"}, "/roms/Sonic (USA).md", 0xABC)
+	want := []string{
+		filepath.Join("/save", "rumble", "00000abc.cht"),
+		filepath.Join("/save", "rumble", "Sonic (USA) (Rumbles).cht"),
+		filepath.Join("/system", "rumble", "00000abc.cht"),
+		filepath.Join("/system", "rumble", "Sonic (USA) (Rumbles).cht"),
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths[%d] = %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
+
+// TestRumbleFileCandidates_NoPath verifies only CRC names are used
+// without a ROM path
+func TestRumbleFileCandidates_NoPath(t *testing.T) {
+	paths := rumbleFileCandidates([]string{"/system"}, "", 1)
+	if len(paths) != 1 || paths[0] != filepath.Join("/system", "rumble", "00000001.cht") {
+		t.Errorf("paths = %v", paths)
+	}
+}
@@ -0,0 +1,47 @@
+package libretro
+
+// Libretro savestate contexts from RETRO_ENVIRONMENT_GET_SAVESTATE_CONTEXT,
+// mirrored from libretro.h for the pure Go helpers.
+const (
+	stateContextNormal               = 0
+	stateContextRunaheadSameInstance = 1
+	stateContextRunaheadSameBinary   = 2
+	stateContextRollbackNetplay      = 3
+)
+
+// quirkCoreVariableSize is RETRO_SERIALIZATION_QUIRK_CORE_VARIABLE_SIZE.
+const quirkCoreVariableSize = 1 << 2
+
+// serializationQuirks returns the quirks to declare for a system. States
+// have a fixed size when SystemInfo.SerializeSize is set; otherwise the
+// size is taken from the current state and may change between calls.
+func serializationQuirks(serializeSize int) uint64 {
+	if serializeSize <= 0 {
+		return quirkCoreVariableSize
+	}
+	return 0
+}
+
+// isReplayContext reports whether a savestate is taken or restored for
+// run-ahead or netplay rollback rather than by the user. Replayed frames
+// must not have side effects the user can notice, such as stopping rumble.
+func isReplayContext(ctx int) bool {
+	switch ctx {
+	case stateContextRunaheadSameInstance, stateContextRunaheadSameBinary, stateContextRollbackNetplay:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeState copies state into dst and zeroes the rest so every state of
+// a fixed size is byte-for-byte reproducible, which netplay relies on when
+// comparing states. Returns false when state doesn't fit.
+func writeState(dst, state []byte) bool {
+	if len(state) > len(dst) {
+		return false
+	}
+	n := copy(dst, state)
+	clear(dst[n:])
+	return true
+}
@@ -0,0 +1,56 @@
+package libretro
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSerializationQuirks verifies only systems without a fixed size
+// declare variable size states
+func TestSerializationQuirks(t *testing.T) {
+	if q := serializationQuirks(4096); q != 0 {
+		t.Errorf("fixed size quirks = %#x, want 0", q)
+	}
+	if q := serializationQuirks(0); q != quirkCoreVariableSize {
+		t.Errorf("variable size quirks = %#x, want %#x", q, quirkCoreVariableSize)
+	}
+}
+
+// TestIsReplayContext verifies run-ahead and netplay are replays
+func TestIsReplayContext(t *testing.T) {
+	tests := []struct {
+		ctx  int
+		want bool
+	}{
+		{stateContextNormal, false},
+		{stateContextRunaheadSameInstance, true},
+		{stateContextRunaheadSameBinary, true},
+		{stateContextRollbackNetplay, true},
+		{99, false},
+	}
+	for _, tc := range tests {
+		if got := isReplayContext(tc.ctx); got != tc.want {
+			t.Errorf("isReplayContext(%d) = %v, want %v", tc.ctx, got, tc.want)
+		}
+	}
+}
+
+// TestWriteState verifies short states are zero padded so the buffer
+// doesn't keep bytes from an earlier state
+func TestWriteState(t *testing.T) {
+	dst := bytes.Repeat([]byte{0xAA}, 8)
+	if !writeState(dst, []byte{1, 2, 3}) {
+		t.Fatal("writeState failed")
+	}
+	if want := []byte{1, 2, 3, 0, 0, 0, 0, 0}; !bytes.Equal(dst, want) {
+		t.Errorf("dst = %v, want %v", dst, want)
+	}
+}
+
+// TestWriteState_TooLarge verifies states larger than the buffer fail
+func TestWriteState_TooLarge(t *testing.T) {
+	dst := make([]byte, 2)
+	if writeState(dst, []byte{1, 2, 3}) {
+		t.Error("oversized state accepted")
+	}
+}
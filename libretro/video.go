@@ -0,0 +1,40 @@
+package libretro
+
+// GET_AUDIO_VIDEO_ENABLE bits, mirrored from libretro.h.
+const (
+	avEnableVideo = 1 << 0
+	avEnableAudio = 1 << 1
+)
+
+// avOutputs decodes the GET_AUDIO_VIDEO_ENABLE bits. Frontends that don't
+// support the call want both.
+func avOutputs(supported bool, flags int) (video, audio bool) {
+	if !supported {
+		return true, true
+	}
+	return flags&avEnableVideo != 0, flags&avEnableAudio != 0
+}
+
+// frameDupe decides when the frontend can show its previous frame again
+// instead of receiving a new one.
+type frameDupe struct {
+	canDupe bool // Frontend accepts NULL frames (GET_CAN_DUPE)
+	current bool // Frontend holds the core's latest frame
+}
+
+// dupe reports whether the frame can be duped. changed is whether the
+// core drew anything since the last frame.
+func (d *frameDupe) dupe(changed bool) bool {
+	return d.canDupe && d.current && !changed
+}
+
+// sent records that a new frame reached the frontend.
+func (d *frameDupe) sent() {
+	d.current = true
+}
+
+// invalidate records that the frontend's frame is out of date, e.g.
+// after a frame was skipped or the emulator state was replaced.
+func (d *frameDupe) invalidate() {
+	d.current = false
+}
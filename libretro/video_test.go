@@ -0,0 +1,57 @@
+package libretro
+
+import "testing"
+
+// TestAVOutputs verifies the enable bits and the unsupported default
+func TestAVOutputs(t *testing.T) {
+	tests := []struct {
+		supported    bool
+		flags        int
+		video, audio bool
+	}{
+		{false, 0, true, true},
+		{true, avEnableVideo | avEnableAudio, true, true},
+		{true, avEnableAudio, false, true},
+		{true, avEnableVideo, true, false},
+		{true, 0, false, false},
+	}
+	for _, tc := range tests {
+		video, audio := avOutputs(tc.supported, tc.flags)
+		if video != tc.video || audio != tc.audio {
+			t.Errorf("avOutputs(%v, %d) = %v, %v, want %v, %v", tc.supported, tc.flags, video, audio, tc.video, tc.audio)
+		}
+	}
+}
+
+// TestFrameDupe verifies frames are only duped once the frontend has the
+// latest frame and nothing changed
+func TestFrameDupe(t *testing.T) {
+	d := frameDupe{canDupe: true}
+	if d.dupe(false) {
+		t.Error("duped before any frame was sent")
+	}
+
+	d.sent()
+	if !d.dupe(false) {
+		t.Error("unchanged frame not duped")
+	}
+	if d.dupe(true) {
+		t.Error("changed frame duped")
+	}
+
+	// A skipped frame or restored state leaves the frontend out of date
+	d.invalidate()
+	if d.dupe(false) {
+		t.Error("duped after invalidate")
+	}
+}
+
+// TestFrameDupe_Unsupported verifies nothing is duped without frontend
+// support
+func TestFrameDupe_Unsupported(t *testing.T) {
+	d := frameDupe{}
+	d.sent()
+	if d.dupe(false) {
+		t.Error("duped without GET_CAN_DUPE")
+	}
+}
@@ -0,0 +1,294 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadDAT loads and parses a clrmamepro or Logiqx XML DAT file from disk
+func LoadDAT(path string) (*RDB, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDAT(data)
+}
+
+// ParseDAT parses a DAT file as used by No-Intro, Redump and
+// libretro-database, detecting whether it's Logiqx XML or clrmamepro
+// text. Each ROM of a game becomes a Game with the game's name, so the
+// result indexes the same CRC32, MD5, SHA1, size and name lookups as an
+// RDB.
+func ParseDAT(data []byte) (*RDB, error) {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		return ParseLogiqxDAT(data)
+	}
+	return ParseClrMameProDAT(data)
+}
+
+// logiqxDAT is the part of a Logiqx XML DAT used for games. MAME style
+// DATs use machine in place of game.
+type logiqxDAT struct {
+	Games    []logiqxGame `xml:"game"`
+	Machines []logiqxGame `xml:"machine"`
+}
+
+type logiqxGame struct {
+	Name         string      `xml:"name,attr"`
+	Description  string      `xml:"description"`
+	Year         string      `xml:"year"`
+	Manufacturer string      `xml:"manufacturer"`
+	ROMs         []logiqxROM `xml:"rom"`
+}
+
+type logiqxROM struct {
+	Name   string `xml:"name,attr"`
+	Size   string `xml:"size,attr"`
+	CRC    string `xml:"crc,attr"`
+	MD5    string `xml:"md5,attr"`
+	SHA1   string `xml:"sha1,attr"`
+	Serial string `xml:"serial,attr"`
+}
+
+// ParseLogiqxDAT parses a Logiqx XML DAT
+func ParseLogiqxDAT(data []byte) (*RDB, error) {
+	var dat logiqxDAT
+	if err := xml.Unmarshal(data, &dat); err != nil {
+		return nil, fmt.Errorf("rdb: DAT: %w", err)
+	}
+
+	var games []Game
+	for _, lg := range append(dat.Games, dat.Machines...) {
+		g := Game{
+			Name:        lg.Name,
+			Description: lg.Description,
+			Publisher:   lg.Manufacturer,
+		}
+		setDATField(&g, "releaseyear", lg.Year)
+		if len(lg.ROMs) == 0 {
+			games = append(games, g)
+			continue
+		}
+		for _, r := range lg.ROMs {
+			rg := g
+			rg.ROMName = r.Name
+			setDATField(&rg, "size", r.Size)
+			setDATField(&rg, "crc", r.CRC)
+			setDATField(&rg, "md5", r.MD5)
+			setDATField(&rg, "sha1", r.SHA1)
+			setDATField(&rg, "serial", r.Serial)
+			games = append(games, rg)
+		}
+	}
+	return newRDB(games), nil
+}
+
+// setDATField sets a game field from a DAT string value. DAT fields use
+// the RDB field names, as libretro-database builds its RDBs from them.
+func setDATField(g *Game, key, value string) {
+	if value == "" {
+		return
+	}
+	setGameField(g, key, value)
+}
+
+// datToken is a clrmamepro token: a parenthesis, or a bare or quoted word
+type datToken struct {
+	text   string
+	quoted bool
+	line   int
+}
+
+// datBlock is a parenthesized list of clrmamepro key/value pairs. Values
+// are words or nested blocks.
+type datBlock struct {
+	pairs []datPair
+}
+
+type datPair struct {
+	key   string
+	value string
+	block *datBlock
+}
+
+// ParseClrMameProDAT parses a clrmamepro text DAT
+func ParseClrMameProDAT(data []byte) (*RDB, error) {
+	tokens, err := tokenizeDAT(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &datParser{tokens: tokens}
+	var games []Game
+	for p.pos < len(p.tokens) {
+		key := p.tokens[p.pos]
+		p.pos++
+		block, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		// Skip the clrmamepro header and other non-game blocks
+		switch key.text {
+		case "game", "machine", "resource":
+			games = append(games, datGames(block)...)
+		}
+	}
+	return newRDB(games), nil
+}
+
+// datGames returns a game for each rom in a game block
+func datGames(block *datBlock) []Game {
+	var g Game
+	var roms []*datBlock
+	for _, pair := range block.pairs {
+		if pair.block != nil {
+			if pair.key == "rom" {
+				roms = append(roms, pair.block)
+			}
+			continue
+		}
+		setDATField(&g, pair.key, pair.value)
+	}
+	if len(roms) == 0 {
+		return []Game{g}
+	}
+
+	games := make([]Game, 0, len(roms))
+	for _, rom := range roms {
+		rg := g
+		for _, pair := range rom.pairs {
+			if pair.block != nil {
+				continue
+			}
+			key := pair.key
+			if key == "name" {
+				key = "rom_name"
+			}
+			setDATField(&rg, key, pair.value)
+		}
+		games = append(games, rg)
+	}
+	return games
+}
+
+// datParser builds blocks from clrmamepro tokens
+type datParser struct {
+	tokens []datToken
+	pos    int
+}
+
+// block parses a parenthesized block
+func (p *datParser) block() (*datBlock, error) {
+	if err := p.expectOpen(); err != nil {
+		return nil, err
+	}
+	b := &datBlock{}
+	for {
+		if p.pos >= len(p.tokens) {
+			return nil, p.errorf("unterminated block")
+		}
+		key := p.tokens[p.pos]
+		p.pos++
+		if key.text == ")" && !key.quoted {
+			return b, nil
+		}
+		if key.text == "(" && !key.quoted {
+			return nil, fmt.Errorf("rdb: DAT line %d: expected key, found (", key.line)
+		}
+
+		if p.pos >= len(p.tokens) {
+			return nil, p.errorf("missing value for %s", key.text)
+		}
+		value := p.tokens[p.pos]
+		switch {
+		case value.text == "(" && !value.quoted:
+			nested, err := p.block()
+			if err != nil {
+				return nil, err
+			}
+			b.pairs = append(b.pairs, datPair{key: key.text, block: nested})
+		case value.text == ")" && !value.quoted:
+			return nil, fmt.Errorf("rdb: DAT line %d: missing value for %s", value.line, key.text)
+		default:
+			p.pos++
+			b.pairs = append(b.pairs, datPair{key: key.text, value: value.text})
+		}
+	}
+}
+
+// expectOpen consumes the ( starting a block
+func (p *datParser) expectOpen() error {
+	if p.pos >= len(p.tokens) {
+		return p.errorf("expected (")
+	}
+	t := p.tokens[p.pos]
+	if t.text != "(" || t.quoted {
+		return fmt.Errorf("rdb: DAT line %d: expected (, found %q", t.line, t.text)
+	}
+	p.pos++
+	return nil
+}
+
+// errorf returns an error at the line of the last token
+func (p *datParser) errorf(format string, args ...any) error {
+	line := 1
+	if len(p.tokens) > 0 {
+		line = p.tokens[min(p.pos, len(p.tokens)-1)].line
+	}
+	return fmt.Errorf("rdb: DAT line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// tokenizeDAT splits clrmamepro text into tokens. Quoted strings may
+// contain spaces and parentheses, and a backslash escapes a quote.
+func tokenizeDAT(data []byte) ([]datToken, error) {
+	s := strings.TrimPrefix(string(data), "\xef\xbb\xbf")
+	var tokens []datToken
+	line := 1
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, datToken{text: string(c), line: line})
+			i++
+		case c == '"':
+			start := line
+			var sb strings.Builder
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("rdb: DAT line %d: unterminated string", start)
+				}
+				c := s[i]
+				i++
+				if c == '"' {
+					break
+				}
+				if c == '\\' && i < len(s) && (s[i] == '"' || s[i] == '\\') {
+					c = s[i]
+					i++
+				}
+				if c == '\n' {
+					line++
+				}
+				sb.WriteByte(c)
+			}
+			tokens = append(tokens, datToken{text: sb.String(), quoted: true, line: start})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\r\n()\"", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, datToken{text: s[start:i], line: line})
+		}
+	}
+	return tokens, nil
+}
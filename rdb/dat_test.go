@@ -0,0 +1,147 @@
+package rdb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testClrMameProDAT = `clrmamepro (
+	name "Sega - Mega Drive - Genesis"
+	description "Sega - Mega Drive - Genesis"
+	version 20240101-000000
+)
+
+game (
+	name "Sonic the Hedgehog (USA, Europe)"
+	description "Sonic the Hedgehog (USA, Europe)"
+	developer "Sonic Team"
+	releaseyear "1991"
+	serial "MK-1079"
+	rom ( name "Sonic the Hedgehog (USA, Europe).md" size 524288 crc F9394E97 md5 1BC674BE034E43C96B86487AC69D9293 sha1 6DDEB3347B133B886E55D51E8B3CF11F0501FEA6 )
+)
+
+game (
+	name "Game (Disc \"1\") (Japan)"
+	rom ( name "Game (Japan) (Track 1).bin" size 1000 crc 11111111 )
+	rom ( name "Game (Japan) (Track 2).bin" size 2000 crc 22222222 )
+)
+`
+
+const testLogiqxDAT = `<?xml version="1.0"?>
+<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/Dats/datafile.dtd">
+<datafile>
+	<header>
+		<name>Sega - Mega Drive - Genesis</name>
+	</header>
+	<game name="Sonic the Hedgehog (USA, Europe)">
+		<description>Sonic the Hedgehog (USA, Europe)</description>
+		<year>1991</year>
+		<manufacturer>Sega</manufacturer>
+		<rom name="Sonic the Hedgehog (USA, Europe).md" size="524288" crc="f9394e97" md5="1bc674be034e43c96b86487ac69d9293" sha1="6ddeb3347b133b886e55d51e8b3cf11f0501fea6" serial="MK-1079"/>
+	</game>
+	<machine name="Arcade Game">
+		<rom name="a.bin" size="16" crc="33333333"/>
+		<rom name="b.bin" size="32" crc="44444444"/>
+	</machine>
+</datafile>
+`
+
+// checkSonic checks the lookups of the Sonic entry in both test DATs
+func checkSonic(t *testing.T, rdb *RDB) {
+	t.Helper()
+	g := rdb.FindByCRC32(0xF9394E97)
+	if g == nil {
+		t.Fatal("FindByCRC32 found nothing")
+	}
+	if g.Name != "Sonic the Hedgehog (USA, Europe)" || g.ROMName != "Sonic the Hedgehog (USA, Europe).md" ||
+		g.Size != 524288 || g.Serial != "MK-1079" || g.ReleaseYear != 1991 {
+		t.Errorf("game = %+v", g)
+	}
+	if rdb.FindByMD5("1bc674be034e43c96b86487ac69d9293") != g {
+		t.Error("FindByMD5 didn't find the game")
+	}
+	if rdb.FindBySHA1("6DDEB3347B133B886E55D51E8B3CF11F0501FEA6") != g {
+		t.Error("FindBySHA1 didn't find the game")
+	}
+	if rdb.FindByROMName("sonic the hedgehog (usa, europe).md") != g {
+		t.Error("FindByROMName didn't find the game")
+	}
+	if len(rdb.FindBySize(524288)) != 1 {
+		t.Error("FindBySize didn't find the game")
+	}
+}
+
+func TestParseClrMameProDAT(t *testing.T) {
+	rdb, err := ParseDAT([]byte(testClrMameProDAT))
+	if err != nil {
+		t.Fatalf("ParseDAT: %v", err)
+	}
+	if rdb.GameCount() != 3 {
+		t.Fatalf("expected 3 games, got %d", rdb.GameCount())
+	}
+	checkSonic(t, rdb)
+	if g := rdb.FindByCRC32(0xF9394E97); g.Developer != "Sonic Team" {
+		t.Errorf("Developer = %q", g.Developer)
+	}
+
+	track2 := rdb.FindByCRC32(0x22222222)
+	if track2 == nil || track2.Name != `Game (Disc "1") (Japan)` || track2.ROMName != "Game (Japan) (Track 2).bin" || track2.Size != 2000 {
+		t.Errorf("track 2 = %+v", track2)
+	}
+}
+
+func TestParseLogiqxDAT(t *testing.T) {
+	rdb, err := ParseDAT([]byte(testLogiqxDAT))
+	if err != nil {
+		t.Fatalf("ParseDAT: %v", err)
+	}
+	if rdb.GameCount() != 3 {
+		t.Fatalf("expected 3 games, got %d", rdb.GameCount())
+	}
+	checkSonic(t, rdb)
+	if g := rdb.FindByCRC32(0xF9394E97); g.Publisher != "Sega" {
+		t.Errorf("Publisher = %q", g.Publisher)
+	}
+	if g := rdb.FindByCRC32(0x44444444); g == nil || g.Name != "Arcade Game" || g.ROMName != "b.bin" {
+		t.Errorf("machine rom = %+v", g)
+	}
+}
+
+func TestParseClrMameProDATErrors(t *testing.T) {
+	tests := []struct {
+		dat  string
+		line string
+	}{
+		{"game (\n\tname \"Sonic\n)", "line 2"},
+		{"game (\n\tname \"Sonic\"\n", "line 2"},
+		{"game (\n\tname\n)", "line 3"},
+		{"game\nname", "line 2"},
+		{"game (\n\t( name x )\n)", "line 2"},
+	}
+	for _, tc := range tests {
+		_, err := ParseDAT([]byte(tc.dat))
+		if err == nil || !strings.Contains(err.Error(), tc.line) {
+			t.Errorf("ParseDAT(%q): err = %v, want %s", tc.dat, err, tc.line)
+		}
+	}
+}
+
+func TestParseLogiqxDATError(t *testing.T) {
+	if _, err := ParseDAT([]byte("<datafile><game name=\"x\">")); err == nil {
+		t.Error("expected error for truncated XML")
+	}
+}
+
+func TestLoadDAT(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dat")
+	if err := os.WriteFile(path, []byte("\xef\xbb\xbf"+testLogiqxDAT), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rdb, err := LoadDAT(path)
+	if err != nil {
+		t.Fatalf("LoadDAT: %v", err)
+	}
+	checkSonic(t, rdb)
+}
@@ -0,0 +1,287 @@
+package rdb
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// LazyRDB is an RDB that keeps the file data, memory mapped where
+// supported, and decodes a game only when it's looked up. Loading indexes
+// the offset of each game by CRC32, MD5, SHA1, serial, ROM name and size,
+// so it holds a few small map entries per game instead of every decoded
+// Game.
+//
+// Each lookup decodes a new Game that doesn't reference the file data.
+// Lookups are safe for concurrent use, including with Close.
+type LazyRDB struct {
+	mu    sync.RWMutex // Held for reading while data is decoded
+	data  []byte
+	unmap func() error
+	count int
+
+	byCRC32   map[uint32]int
+	byMD5     map[[16]byte]int
+	bySHA1    map[[20]byte]int
+	bySerial  map[uint64]int   // Keyed by hash of the normalized serial
+	byROMName map[uint64]int   // Keyed by hash of the lowercase ROM name
+	bySize    map[uint64][]int // Sizes are shared, so every game is kept
+}
+
+// indexFields are the fields decoded while indexing. Every other field is
+// skipped until a game is looked up.
+var indexFields = map[string]bool{
+	"name":     true,
+	"crc":      true,
+	"md5":      true,
+	"sha1":     true,
+	"serial":   true,
+	"rom_name": true,
+	"size":     true,
+}
+
+// OpenRDB opens and indexes an RDB file from disk without decoding its
+// games. The file is checked as fully as LoadRDB does, so malformed or
+// truncated files return a *ParseError. The file data is released by Close,
+// or when the LazyRDB is garbage collected.
+func OpenRDB(path string) (*LazyRDB, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := NewLazyRDB(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+
+	var once sync.Once
+	l.unmap = func() (err error) {
+		once.Do(func() { err = unmap() })
+		return err
+	}
+	runtime.AddCleanup(l, func(release func() error) { release() }, l.unmap)
+	return l, nil
+}
+
+// NewLazyRDB indexes RDB file content without decoding its games. data
+// must not be modified while the LazyRDB is in use.
+func NewLazyRDB(data []byte) (*LazyRDB, error) {
+	l := &LazyRDB{
+		data:      data,
+		byCRC32:   make(map[uint32]int),
+		byMD5:     make(map[[16]byte]int),
+		bySHA1:    make(map[[20]byte]int),
+		bySerial:  make(map[uint64]int),
+		byROMName: make(map[uint64]int),
+		bySize:    make(map[uint64][]int),
+	}
+
+	err := parseRecords(data, func(d *decoder, start, pairs int) error {
+		g := Game{}
+		for range pairs {
+			key, err := d.rawKey()
+			if err != nil {
+				return err
+			}
+			if !indexFields[string(key)] {
+				if err := d.skip(maxDepth); err != nil {
+					return err
+				}
+				continue
+			}
+			value, err := d.value(maxDepth)
+			if err != nil {
+				return err
+			}
+			setGameField(&g, string(key), value)
+		}
+		l.index(&g, start)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// index adds the game at offset to the indexes. As with Parse, later
+// games replace earlier ones with the same key, and games without a name
+// or CRC32 are left out.
+func (l *LazyRDB) index(g *Game, offset int) {
+	if g.Name == "" && g.CRC32 == 0 {
+		return
+	}
+	l.count++
+	if g.CRC32 != 0 {
+		l.byCRC32[g.CRC32] = offset
+	}
+	if md5, ok := md5Key(g.MD5); ok {
+		l.byMD5[md5] = offset
+	}
+	if sha1, ok := sha1Key(g.SHA1); ok {
+		l.bySHA1[sha1] = offset
+	}
+	if g.Serial != "" {
+		l.bySerial[hashKey(normalizeSerial(g.Serial))] = offset
+	}
+	if g.ROMName != "" {
+		l.byROMName[hashKey(strings.ToLower(g.ROMName))] = offset
+	}
+	if g.Size != 0 {
+		l.bySize[g.Size] = append(l.bySize[g.Size], offset)
+	}
+}
+
+// md5Key returns the bytes of a hex MD5
+func md5Key(md5 string) ([16]byte, bool) {
+	var key [16]byte
+	ok := decodeHash(key[:], md5)
+	return key, ok
+}
+
+// sha1Key returns the bytes of a hex SHA1
+func sha1Key(sha1 string) ([20]byte, bool) {
+	var key [20]byte
+	ok := decodeHash(key[:], sha1)
+	return key, ok
+}
+
+// decodeHash decodes a hex hash that fills dst
+func decodeHash(dst []byte, h string) bool {
+	if len(h) != 2*len(dst) {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(h))
+	return err == nil
+}
+
+// hashKey hashes a string index key. Lookups check the decoded game, so a
+// collision can only hide a game, never return the wrong one.
+func hashKey(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// game decodes the game whose record starts at offset, if ok from the
+// index lookup
+func (l *LazyRDB) game(offset int, ok bool) *Game {
+	if !ok {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.data == nil {
+		return nil
+	}
+	d := &decoder{data: l.data, pos: offset}
+	n, err := d.mapHeader()
+	if err != nil {
+		return nil
+	}
+	g := &Game{}
+	for range n {
+		key, err := d.key()
+		if err != nil {
+			return nil
+		}
+		value, err := d.value(maxDepth)
+		if err != nil {
+			return nil
+		}
+		setGameField(g, key, value)
+	}
+	// The mapping is released once l is unreachable
+	runtime.KeepAlive(l)
+	return g
+}
+
+// FindByCRC32 looks up a game by its CRC32 checksum
+func (l *LazyRDB) FindByCRC32(crc32 uint32) *Game {
+	offset, ok := l.byCRC32[crc32]
+	return l.game(offset, ok)
+}
+
+// FindByMD5 looks up a game by its MD5 hash, in hex of either case
+func (l *LazyRDB) FindByMD5(md5 string) *Game {
+	key, ok := md5Key(md5)
+	if !ok {
+		return nil
+	}
+	offset, ok := l.byMD5[key]
+	return l.game(offset, ok)
+}
+
+// FindBySHA1 looks up a game by its SHA1 hash, in hex of either case
+func (l *LazyRDB) FindBySHA1(sha1 string) *Game {
+	key, ok := sha1Key(sha1)
+	if !ok {
+		return nil
+	}
+	offset, ok := l.bySHA1[key]
+	return l.game(offset, ok)
+}
+
+// FindBySerial looks up a game by its serial. Case and surrounding space
+// are ignored.
+func (l *LazyRDB) FindBySerial(serial string) *Game {
+	serial = normalizeSerial(serial)
+	offset, ok := l.bySerial[hashKey(serial)]
+	g := l.game(offset, ok)
+	if g == nil || normalizeSerial(g.Serial) != serial {
+		return nil
+	}
+	return g
+}
+
+// FindByROMName looks up a game by its ROM filename, ignoring case
+func (l *LazyRDB) FindByROMName(name string) *Game {
+	name = strings.ToLower(name)
+	offset, ok := l.byROMName[hashKey(name)]
+	g := l.game(offset, ok)
+	if g == nil || strings.ToLower(g.ROMName) != name {
+		return nil
+	}
+	return g
+}
+
+// FindBySize returns every game whose ROM is size bytes, in file order
+func (l *LazyRDB) FindBySize(size uint64) []*Game {
+	var games []*Game
+	for _, offset := range l.bySize[size] {
+		if g := l.game(offset, true); g != nil {
+			games = append(games, g)
+		}
+	}
+	return games
+}
+
+// GetMD5ByCRC32 returns the MD5 hash for a game found by CRC32
+func (l *LazyRDB) GetMD5ByCRC32(crc32 uint32) string {
+	if g := l.FindByCRC32(crc32); g != nil {
+		return g.MD5
+	}
+	return ""
+}
+
+// GameCount returns the number of games in the database
+func (l *LazyRDB) GameCount() int {
+	return l.count
+}
+
+// Close releases the file data without waiting for garbage collection.
+// It waits for lookups in progress, and lookups after Close find nothing.
+func (l *LazyRDB) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.data = nil
+	if l.unmap == nil {
+		return nil
+	}
+	unmap := l.unmap
+	l.unmap = nil
+	return unmap()
+}
@@ -0,0 +1,281 @@
+package rdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// lazyTestGames returns n games with every indexed field and some that
+// are only decoded on lookup
+func lazyTestGames(n int) []Game {
+	games := make([]Game, n)
+	for i := range games {
+		games[i] = Game{
+			Name:        fmt.Sprintf("Game %d (USA)", i),
+			Description: fmt.Sprintf("Game %d", i),
+			Genre:       "Action",
+			Developer:   "Developer",
+			Publisher:   "Publisher",
+			ROMName:     fmt.Sprintf("Game %d (USA).md", i),
+			ReleaseYear: 1990 + uint(i%10),
+			Size:        uint64(131072 * (1 + i%8)),
+			CRC32:       uint32(i + 1),
+			Serial:      fmt.Sprintf("MK-%05d", i),
+			MD5:         fmt.Sprintf("%032x", i+1),
+			SHA1:        fmt.Sprintf("%040x", i+1),
+			Extra:       map[string]any{"tags": []any{"one", "two"}},
+		}
+	}
+	return games
+}
+
+// writeLazyTestRDB writes n test games to an RDB file
+func writeLazyTestRDB(t testing.TB, n int) (string, []byte) {
+	t.Helper()
+	data, err := Encode(lazyTestGames(n))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.rdb")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func TestLazyRDBMatchesParse(t *testing.T) {
+	path, data := writeLazyTestRDB(t, 100)
+	full, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	lazy, err := OpenRDB(path)
+	if err != nil {
+		t.Fatalf("OpenRDB: %v", err)
+	}
+	defer lazy.Close()
+
+	if lazy.GameCount() != full.GameCount() {
+		t.Errorf("GameCount = %d, want %d", lazy.GameCount(), full.GameCount())
+	}
+	for i := range full.Games() {
+		want := &full.Games()[i]
+		lookups := map[string]*Game{
+			"FindByCRC32":   lazy.FindByCRC32(want.CRC32),
+			"FindByMD5":     lazy.FindByMD5(want.MD5),
+			"FindBySHA1":    lazy.FindBySHA1(want.SHA1),
+			"FindBySerial":  lazy.FindBySerial(want.Serial),
+			"FindByROMName": lazy.FindByROMName(want.ROMName),
+		}
+		for name, got := range lookups {
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s = %+v, want %+v", name, got, want)
+			}
+		}
+		if md5 := lazy.GetMD5ByCRC32(want.CRC32); md5 != want.MD5 {
+			t.Errorf("GetMD5ByCRC32 = %q, want %q", md5, want.MD5)
+		}
+	}
+	for _, size := range []uint64{131072, 262144, 1} {
+		if got, want := lazy.FindBySize(size), full.FindBySize(size); !reflect.DeepEqual(got, want) {
+			t.Errorf("FindBySize(%d) = %d games, want %d", size, len(got), len(want))
+		}
+	}
+
+	if g := lazy.FindByMD5("0000000000000000000000000000000A"); g == nil || g.CRC32 != 10 {
+		t.Errorf("FindByMD5 uppercase = %+v", g)
+	}
+	if g := lazy.FindBySerial(" mk-00005 "); g == nil || g.CRC32 != 6 {
+		t.Errorf("FindBySerial normalized = %+v", g)
+	}
+	if g := lazy.FindByROMName("GAME 5 (USA).MD"); g == nil || g.CRC32 != 6 {
+		t.Errorf("FindByROMName case = %+v", g)
+	}
+	if lazy.FindByCRC32(0xFFFFFFFF) != nil || lazy.FindByMD5("bad") != nil || lazy.FindBySHA1("bad") != nil ||
+		lazy.FindBySerial("unknown") != nil || lazy.FindByROMName("unknown") != nil {
+		t.Error("unknown keys found a game")
+	}
+}
+
+func TestLazyRDBSkipsUnnamedGames(t *testing.T) {
+	lazy, err := NewLazyRDB(encodeTestRDB(
+		[]testField{{"name", "Game A (USA)"}},
+		[]testField{{"description", "No name or CRC"}, {"serial", "X-1"}},
+	))
+	if err != nil {
+		t.Fatalf("NewLazyRDB: %v", err)
+	}
+	if lazy.GameCount() != 1 || lazy.FindBySerial("X-1") != nil {
+		t.Errorf("GameCount = %d, unnamed game indexed", lazy.GameCount())
+	}
+}
+
+func TestLazyRDBErrors(t *testing.T) {
+	data := encodeTestRDB(
+		[]testField{{"name", "Game A (USA)"}, {"tags", "x"}, {"size", uint64(1)}},
+	)
+	for n := range len(data) {
+		if _, err := NewLazyRDB(data[:n]); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("NewLazyRDB of %d/%d bytes: err = %v, want truncation", n, len(data), err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.rdb")
+	if err := os.WriteFile(path, []byte("not an rdb file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenRDB(path); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("OpenRDB invalid: err = %v", err)
+	}
+	if _, err := OpenRDB(filepath.Join(t.TempDir(), "missing.rdb")); !os.IsNotExist(err) {
+		t.Errorf("OpenRDB missing: err = %v", err)
+	}
+}
+
+func TestLazyRDBClose(t *testing.T) {
+	path, _ := writeLazyTestRDB(t, 10)
+	lazy, err := OpenRDB(path)
+	if err != nil {
+		t.Fatalf("OpenRDB: %v", err)
+	}
+	g := lazy.FindByCRC32(1)
+	if err := lazy.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Games found before Close don't reference the file data
+	if g == nil || g.Name != "Game 0 (USA)" || g.Extra["tags"].([]any)[1] != "two" {
+		t.Errorf("game after Close = %+v", g)
+	}
+	if lazy.FindByCRC32(1) != nil {
+		t.Error("FindByCRC32 after Close found a game")
+	}
+	if err := lazy.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestLazyRDBCloseDuringLookups(t *testing.T) {
+	path, _ := writeLazyTestRDB(t, 100)
+	lazy, err := OpenRDB(path)
+	if err != nil {
+		t.Fatalf("OpenRDB: %v", err)
+	}
+
+	// Lookups racing Close either find the game or nothing, never fault
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				crc := uint32((w*1000+i)%100 + 1)
+				if g := lazy.FindByCRC32(crc); g != nil && g.CRC32 != crc {
+					t.Errorf("FindByCRC32(%d) = %+v", crc, g)
+					return
+				}
+			}
+		}()
+	}
+	lazy.Close()
+	wg.Wait()
+}
+
+func FuzzNewLazyRDB(f *testing.F) {
+	valid := encodeTestRDB(
+		[]testField{{"name", "Game A (USA)"}, {"crc", []byte{1, 2, 3, 4}}, {"serial", "S-1"}},
+	)
+	f.Add(valid)
+	f.Add(valid[:len(valid)-3])
+
+	// Lazy indexing accepts exactly what Parse accepts
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, lazyErr := NewLazyRDB(data)
+		_, err := Parse(data)
+		if (lazyErr == nil) != (err == nil) {
+			t.Fatalf("NewLazyRDB err = %v, Parse err = %v", lazyErr, err)
+		}
+	})
+}
+
+// benchmarkGames is the size of a large libretro-database RDB
+const benchmarkGames = 20000
+
+// reportHeap reports the heap retained by the value returned from load
+func reportHeap(b *testing.B, load func() any) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	v := load()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(v)
+	b.ReportMetric(float64(after.HeapAlloc)-float64(before.HeapAlloc), "heap-bytes")
+}
+
+func BenchmarkLoadRDB(b *testing.B) {
+	path, _ := writeLazyTestRDB(b, benchmarkGames)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := LoadRDB(path); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportHeap(b, func() any {
+		rdb, _ := LoadRDB(path)
+		return rdb
+	})
+}
+
+func BenchmarkOpenRDB(b *testing.B) {
+	path, _ := writeLazyTestRDB(b, benchmarkGames)
+	b.ReportAllocs()
+	for b.Loop() {
+		lazy, err := OpenRDB(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		lazy.Close()
+	}
+	reportHeap(b, func() any {
+		lazy, _ := OpenRDB(path)
+		b.Cleanup(func() { lazy.Close() })
+		return lazy
+	})
+}
+
+func BenchmarkFindByCRC32(b *testing.B) {
+	_, data := writeLazyTestRDB(b, benchmarkGames)
+	full, err := Parse(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	lazy, err := NewLazyRDB(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("RDB", func(b *testing.B) {
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			full.FindByCRC32(uint32(i%benchmarkGames + 1))
+			i++
+		}
+	})
+	b.Run("LazyRDB", func(b *testing.B) {
+		b.ReportAllocs()
+		i := 0
+		for b.Loop() {
+			lazy.FindByCRC32(uint32(i%benchmarkGames + 1))
+			i++
+		}
+	})
+}
@@ -0,0 +1,580 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+)
+
+// MessagePack format constants
+const (
+	mpfFixMap    = 0x80
+	mpfFixArray  = 0x90
+	mpfFixStr    = 0xa0
+	mpfNil       = 0xc0
+	mpfFalse     = 0xc2
+	mpfTrue      = 0xc3
+	mpfBin8      = 0xc4
+	mpfBin16     = 0xc5
+	mpfBin32     = 0xc6
+	mpfExt8      = 0xc7
+	mpfExt16     = 0xc8
+	mpfExt32     = 0xc9
+	mpfFloat32   = 0xca
+	mpfFloat64   = 0xcb
+	mpfUint8     = 0xcc
+	mpfUint16    = 0xcd
+	mpfUint32    = 0xce
+	mpfUint64    = 0xcf
+	mpfInt8      = 0xd0
+	mpfInt16     = 0xd1
+	mpfInt32     = 0xd2
+	mpfInt64     = 0xd3
+	mpfFixExt1   = 0xd4
+	mpfFixExt2   = 0xd5
+	mpfFixExt4   = 0xd6
+	mpfFixExt8   = 0xd7
+	mpfFixExt16  = 0xd8
+	mpfStr8      = 0xd9
+	mpfStr16     = 0xda
+	mpfStr32     = 0xdb
+	mpfArray16   = 0xdc
+	mpfArray32   = 0xdd
+	mpfMap16     = 0xde
+	mpfMap32     = 0xdf
+	mpfNegFixInt = 0xe0
+)
+
+// maxDepth limits nesting of arrays and maps inside a game field
+const maxDepth = 32
+
+// maxPrealloc limits the elements allocated for an array or map before
+// they're read. Larger containers grow as elements are read.
+const maxPrealloc = 1024
+
+// Ext is a MessagePack extension value.
+type Ext struct {
+	Type int8
+	Data []byte
+}
+
+// ParseError reports malformed RDB data and the offset it was found at.
+// Truncated data wraps io.ErrUnexpectedEOF.
+type ParseError struct {
+	Offset int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("rdb: offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// decoder reads MessagePack values from data
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+// errorf returns a ParseError at offset
+func errorf(offset int, format string, args ...any) error {
+	return &ParseError{Offset: offset, Err: fmt.Errorf(format, args...)}
+}
+
+// peek returns the next byte without consuming it
+func (d *decoder) peek() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, &ParseError{Offset: d.pos, Err: io.ErrUnexpectedEOF}
+	}
+	return d.data[d.pos], nil
+}
+
+// next consumes n bytes
+func (d *decoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, &ParseError{Offset: len(d.data), Err: io.ErrUnexpectedEOF}
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uint reads an n byte big-endian unsigned integer
+func (d *decoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// length reads an n byte container or data length. Each element takes at
+// least one byte, so lengths past the end of the data are rejected. A
+// length within the data can still be claimed again by each nested
+// container, so containers limit what they allocate up front to
+// maxPrealloc elements.
+func (d *decoder) length(n int) (int, error) {
+	v, err := d.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	if v > uint64(len(d.data)-d.pos) {
+		return 0, &ParseError{Offset: len(d.data), Err: io.ErrUnexpectedEOF}
+	}
+	return int(v), nil
+}
+
+// mapHeader reads the header of a map and returns its number of pairs
+func (d *decoder) mapHeader() (int, error) {
+	start := d.pos
+	t, err := d.peek()
+	if err != nil {
+		return 0, err
+	}
+	d.pos++
+	switch {
+	case t >= mpfFixMap && t < mpfFixArray:
+		return int(t - mpfFixMap), nil
+	case t == mpfMap16:
+		return d.length(2)
+	case t == mpfMap32:
+		return d.length(4)
+	}
+	return 0, errorf(start, "expected map, found type 0x%02x", t)
+}
+
+// key reads a map key, which RDB files always write as a string
+func (d *decoder) key() (string, error) {
+	b, err := d.rawKey()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// rawKey reads a map key without copying it
+func (d *decoder) rawKey() ([]byte, error) {
+	start := d.pos
+	t, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	d.pos++
+
+	n := 0
+	switch {
+	case t >= mpfFixStr && t < mpfNil:
+		n = int(t - mpfFixStr)
+	case t == mpfStr8, t == mpfStr16, t == mpfStr32:
+		n, err = d.length(1 << (t - mpfStr8))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errorf(start, "map key is type 0x%02x, not a string", t)
+	}
+	return d.next(n)
+}
+
+// value reads any value. Unsigned integers decode to uint64 and signed
+// ones to int64, floats to float64, strings to string, binary to []byte,
+// arrays to []any, maps to map[string]any and extensions to Ext.
+func (d *decoder) value(depth int) (any, error) {
+	start := d.pos
+	t, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	d.pos++
+
+	switch {
+	case t < mpfFixMap:
+		return uint64(t), nil
+	case t < mpfFixArray:
+		return d.mapBody(start, int(t-mpfFixMap), depth)
+	case t < mpfFixStr:
+		return d.arrayBody(start, int(t-mpfFixArray), depth)
+	case t < mpfNil:
+		return d.str(int(t - mpfFixStr))
+	case t >= mpfNegFixInt:
+		return int64(int8(t)), nil
+	}
+
+	switch t {
+	case mpfNil:
+		return nil, nil
+	case mpfFalse:
+		return false, nil
+	case mpfTrue:
+		return true, nil
+	case mpfBin8, mpfBin16, mpfBin32:
+		n, err := d.length(1 << (t - mpfBin8))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		return bytes.Clone(b), err
+	case mpfExt8, mpfExt16, mpfExt32:
+		n, err := d.length(1 << (t - mpfExt8))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case mpfFixExt1, mpfFixExt2, mpfFixExt4, mpfFixExt8, mpfFixExt16:
+		return d.ext(1 << (t - mpfFixExt1))
+	case mpfFloat32:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case mpfFloat64:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case mpfUint8, mpfUint16, mpfUint32, mpfUint64:
+		return d.uint(1 << (t - mpfUint8))
+	case mpfInt8, mpfInt16, mpfInt32, mpfInt64:
+		n := 1 << (t - mpfInt8)
+		v, err := d.uint(n)
+		if err != nil {
+			return nil, err
+		}
+		// Sign extend from n bytes
+		shift := 64 - 8*n
+		return int64(v<<shift) >> shift, nil
+	case mpfStr8, mpfStr16, mpfStr32:
+		n, err := d.length(1 << (t - mpfStr8))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case mpfArray16, mpfArray32:
+		n, err := d.length(2 << (t - mpfArray16))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayBody(start, n, depth)
+	case mpfMap16, mpfMap32:
+		n, err := d.length(2 << (t - mpfMap16))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapBody(start, n, depth)
+	}
+	return nil, errorf(start, "invalid type 0x%02x", t)
+}
+
+// skip consumes a value without decoding it, checking it as value would
+func (d *decoder) skip(depth int) error {
+	start := d.pos
+	t, err := d.peek()
+	if err != nil {
+		return err
+	}
+	d.pos++
+
+	switch {
+	case t < mpfFixMap, t >= mpfNegFixInt:
+		return nil
+	case t < mpfFixArray:
+		return d.skipMap(start, int(t-mpfFixMap), depth)
+	case t < mpfFixStr:
+		return d.skipArray(start, int(t-mpfFixArray), depth)
+	case t < mpfNil:
+		_, err := d.next(int(t - mpfFixStr))
+		return err
+	}
+
+	n := 0
+	switch t {
+	case mpfNil, mpfFalse, mpfTrue:
+		return nil
+	case mpfBin8, mpfBin16, mpfBin32:
+		n, err = d.length(1 << (t - mpfBin8))
+	case mpfStr8, mpfStr16, mpfStr32:
+		n, err = d.length(1 << (t - mpfStr8))
+	case mpfExt8, mpfExt16, mpfExt32:
+		n, err = d.length(1 << (t - mpfExt8))
+		n++ // Type
+	case mpfFixExt1, mpfFixExt2, mpfFixExt4, mpfFixExt8, mpfFixExt16:
+		n = 1 + 1<<(t-mpfFixExt1)
+	case mpfFloat32:
+		n = 4
+	case mpfFloat64:
+		n = 8
+	case mpfUint8, mpfUint16, mpfUint32, mpfUint64:
+		n = 1 << (t - mpfUint8)
+	case mpfInt8, mpfInt16, mpfInt32, mpfInt64:
+		n = 1 << (t - mpfInt8)
+	case mpfArray16, mpfArray32:
+		count, err := d.length(2 << (t - mpfArray16))
+		if err != nil {
+			return err
+		}
+		return d.skipArray(start, count, depth)
+	case mpfMap16, mpfMap32:
+		count, err := d.length(2 << (t - mpfMap16))
+		if err != nil {
+			return err
+		}
+		return d.skipMap(start, count, depth)
+	default:
+		return errorf(start, "invalid type 0x%02x", t)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = d.next(n)
+	return err
+}
+
+// skipArray skips the n elements of an array starting at start
+func (d *decoder) skipArray(start, n, depth int) error {
+	if depth <= 0 {
+		return errorf(start, "values nested too deeply")
+	}
+	for range n {
+		if err := d.skip(depth - 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipMap skips the n pairs of a map starting at start
+func (d *decoder) skipMap(start, n, depth int) error {
+	if depth <= 0 {
+		return errorf(start, "values nested too deeply")
+	}
+	for range n {
+		if _, err := d.rawKey(); err != nil {
+			return err
+		}
+		if err := d.skip(depth - 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// str reads an n byte string
+func (d *decoder) str(n int) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// ext reads an extension type and n bytes of data
+func (d *decoder) ext(n int) (any, error) {
+	t, err := d.uint(1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return Ext{Type: int8(t), Data: bytes.Clone(b)}, nil
+}
+
+// arrayBody reads the n elements of an array starting at start
+func (d *decoder) arrayBody(start, n, depth int) (any, error) {
+	if depth <= 0 {
+		return nil, errorf(start, "values nested too deeply")
+	}
+	out := make([]any, 0, min(n, maxPrealloc))
+	for range n {
+		v, err := d.value(depth - 1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// mapBody reads the n pairs of a map starting at start
+func (d *decoder) mapBody(start, n, depth int) (any, error) {
+	if depth <= 0 {
+		return nil, errorf(start, "values nested too deeply")
+	}
+	out := make(map[string]any, min(n, maxPrealloc))
+	for range n {
+		k, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth - 1)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// encoder appends MessagePack values to data using the smallest format
+// that holds each value
+type encoder struct {
+	data []byte
+}
+
+// uint appends an unsigned integer
+func (e *encoder) uint(v uint64) {
+	switch {
+	case v < mpfFixMap:
+		e.data = append(e.data, byte(v))
+	case v <= math.MaxUint8:
+		e.data = append(e.data, mpfUint8, byte(v))
+	case v <= math.MaxUint16:
+		e.data = binary.BigEndian.AppendUint16(append(e.data, mpfUint16), uint16(v))
+	case v <= math.MaxUint32:
+		e.data = binary.BigEndian.AppendUint32(append(e.data, mpfUint32), uint32(v))
+	default:
+		e.data = binary.BigEndian.AppendUint64(append(e.data, mpfUint64), v)
+	}
+}
+
+// int appends a signed integer, as unsigned when it isn't negative
+func (e *encoder) int(v int64) {
+	switch {
+	case v >= 0:
+		e.uint(uint64(v))
+	case v >= -32:
+		e.data = append(e.data, byte(v))
+	case v >= math.MinInt8:
+		e.data = append(e.data, mpfInt8, byte(v))
+	case v >= math.MinInt16:
+		e.data = binary.BigEndian.AppendUint16(append(e.data, mpfInt16), uint16(v))
+	case v >= math.MinInt32:
+		e.data = binary.BigEndian.AppendUint32(append(e.data, mpfInt32), uint32(v))
+	default:
+		e.data = binary.BigEndian.AppendUint64(append(e.data, mpfInt64), uint64(v))
+	}
+}
+
+// header appends the type and length of a string, binary, extension or
+// container. A fix or t8 of 0 means the value has no fixed-length or 8 bit
+// length type.
+func (e *encoder) header(n int, fix byte, fixMax int, t8, t16, t32 byte) {
+	switch {
+	case fix != 0 && n <= fixMax:
+		e.data = append(e.data, fix+byte(n))
+	case t8 != 0 && n <= math.MaxUint8:
+		e.data = append(e.data, t8, byte(n))
+	case n <= math.MaxUint16:
+		e.data = binary.BigEndian.AppendUint16(append(e.data, t16), uint16(n))
+	default:
+		e.data = binary.BigEndian.AppendUint32(append(e.data, t32), uint32(n))
+	}
+}
+
+// str appends a string
+func (e *encoder) str(s string) {
+	e.header(len(s), mpfFixStr, 31, mpfStr8, mpfStr16, mpfStr32)
+	e.data = append(e.data, s...)
+}
+
+// bin appends binary data
+func (e *encoder) bin(b []byte) {
+	e.header(len(b), 0, 0, mpfBin8, mpfBin16, mpfBin32)
+	e.data = append(e.data, b...)
+}
+
+// mapHeader appends the header of a map of n pairs
+func (e *encoder) mapHeader(n int) {
+	e.header(n, mpfFixMap, 15, 0, mpfMap16, mpfMap32)
+}
+
+// ext appends an extension value
+func (e *encoder) ext(v Ext) {
+	switch len(v.Data) {
+	case 1:
+		e.data = append(e.data, mpfFixExt1)
+	case 2:
+		e.data = append(e.data, mpfFixExt2)
+	case 4:
+		e.data = append(e.data, mpfFixExt4)
+	case 8:
+		e.data = append(e.data, mpfFixExt8)
+	case 16:
+		e.data = append(e.data, mpfFixExt16)
+	default:
+		e.header(len(v.Data), 0, 0, mpfExt8, mpfExt16, mpfExt32)
+	}
+	e.data = append(e.data, byte(v.Type))
+	e.data = append(e.data, v.Data...)
+}
+
+// value appends any of the types returned by decoder.value, plus Go's
+// other integer and float types
+func (e *encoder) value(v any) error {
+	switch v := v.(type) {
+	case nil:
+		e.data = append(e.data, mpfNil)
+	case bool:
+		if v {
+			e.data = append(e.data, mpfTrue)
+		} else {
+			e.data = append(e.data, mpfFalse)
+		}
+	case uint64:
+		e.uint(v)
+	case uint:
+		e.uint(uint64(v))
+	case uint32:
+		e.uint(uint64(v))
+	case uint16:
+		e.uint(uint64(v))
+	case uint8:
+		e.uint(uint64(v))
+	case int64:
+		e.int(v)
+	case int:
+		e.int(int64(v))
+	case int32:
+		e.int(int64(v))
+	case int16:
+		e.int(int64(v))
+	case int8:
+		e.int(int64(v))
+	case float64:
+		e.data = binary.BigEndian.AppendUint64(append(e.data, mpfFloat64), math.Float64bits(v))
+	case float32:
+		e.data = binary.BigEndian.AppendUint32(append(e.data, mpfFloat32), math.Float32bits(v))
+	case string:
+		e.str(v)
+	case []byte:
+		e.bin(v)
+	case Ext:
+		e.ext(v)
+	case []any:
+		e.header(len(v), mpfFixArray, 15, 0, mpfArray16, mpfArray32)
+		for _, elem := range v {
+			if err := e.value(elem); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		e.mapHeader(len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			e.str(k)
+			if err := e.value(v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("rdb: can't encode %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestDecoderValue(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want any
+	}{
+		{"positive fixint", []byte{0x7f}, uint64(127)},
+		{"negative fixint", []byte{0xff}, int64(-1)},
+		{"nil", []byte{mpfNil}, nil},
+		{"false", []byte{mpfFalse}, false},
+		{"true", []byte{mpfTrue}, true},
+		{"uint8", []byte{mpfUint8, 0xff}, uint64(0xff)},
+		{"uint16", []byte{mpfUint16, 0x12, 0x34}, uint64(0x1234)},
+		{"uint32", []byte{mpfUint32, 0x12, 0x34, 0x56, 0x78}, uint64(0x12345678)},
+		{"uint64", []byte{mpfUint64, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, uint64(1<<64 - 1)},
+		{"int8", []byte{mpfInt8, 0x80}, int64(-128)},
+		{"int16", []byte{mpfInt16, 0xff, 0xfe}, int64(-2)},
+		{"int32", []byte{mpfInt32, 0x00, 0x01, 0x00, 0x00}, int64(65536)},
+		{"int64", []byte{mpfInt64, 0x80, 0, 0, 0, 0, 0, 0, 0}, int64(-1 << 63)},
+		{"float32", []byte{mpfFloat32, 0x3f, 0xc0, 0x00, 0x00}, float64(1.5)},
+		{"float64", []byte{mpfFloat64, 0x40, 0x09, 0x21, 0xfb, 0x54, 0x44, 0x2d, 0x18}, 3.141592653589793},
+		{"fixstr", []byte{mpfFixStr + 2, 'h', 'i'}, "hi"},
+		{"str8", []byte{mpfStr8, 2, 'h', 'i'}, "hi"},
+		{"str16", []byte{mpfStr16, 0, 2, 'h', 'i'}, "hi"},
+		{"str32", []byte{mpfStr32, 0, 0, 0, 2, 'h', 'i'}, "hi"},
+		{"bin8", []byte{mpfBin8, 2, 1, 2}, []byte{1, 2}},
+		{"bin16", []byte{mpfBin16, 0, 2, 1, 2}, []byte{1, 2}},
+		{"bin32", []byte{mpfBin32, 0, 0, 0, 2, 1, 2}, []byte{1, 2}},
+		{"fixarray", []byte{mpfFixArray + 2, 0x01, mpfTrue}, []any{uint64(1), true}},
+		{"array16", []byte{mpfArray16, 0, 1, mpfNil}, []any{nil}},
+		{"array32", []byte{mpfArray32, 0, 0, 0, 0}, []any{}},
+		{"fixmap", []byte{mpfFixMap + 1, mpfFixStr + 1, 'a', 0x02}, map[string]any{"a": uint64(2)}},
+		{"map16", []byte{mpfMap16, 0, 1, mpfFixStr + 1, 'a', mpfNil}, map[string]any{"a": nil}},
+		{"map32", []byte{mpfMap32, 0, 0, 0, 0}, map[string]any{}},
+		{"nested", []byte{mpfFixMap + 1, mpfFixStr + 1, 'a', mpfFixArray + 1, mpfFixMap}, map[string]any{"a": []any{map[string]any{}}}},
+		{"fixext1", []byte{mpfFixExt1, 0x05, 0xaa}, Ext{Type: 5, Data: []byte{0xaa}}},
+		{"fixext2", []byte{mpfFixExt2, 0xff, 1, 2}, Ext{Type: -1, Data: []byte{1, 2}}},
+		{"fixext4", []byte{mpfFixExt4, 1, 1, 2, 3, 4}, Ext{Type: 1, Data: []byte{1, 2, 3, 4}}},
+		{"fixext8", []byte{mpfFixExt8, 1, 1, 2, 3, 4, 5, 6, 7, 8}, Ext{Type: 1, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}}},
+		{"fixext16", append([]byte{mpfFixExt16, 1}, make([]byte, 16)...), Ext{Type: 1, Data: make([]byte, 16)}},
+		{"ext8", []byte{mpfExt8, 1, 7, 0xaa}, Ext{Type: 7, Data: []byte{0xaa}}},
+		{"ext16", []byte{mpfExt16, 0, 1, 7, 0xaa}, Ext{Type: 7, Data: []byte{0xaa}}},
+		{"ext32", []byte{mpfExt32, 0, 0, 0, 0, 7}, Ext{Type: 7, Data: []byte{}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &decoder{data: tc.data}
+			got, err := d.value(maxDepth)
+			if err != nil {
+				t.Fatalf("value: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("value = %#v, want %#v", got, tc.want)
+			}
+			if d.pos != len(tc.data) {
+				t.Errorf("consumed %d of %d bytes", d.pos, len(tc.data))
+			}
+		})
+	}
+}
+
+func TestDecoderTruncated(t *testing.T) {
+	for _, data := range [][]byte{
+		{mpfUint32, 0, 0},
+		{mpfStr8, 5, 'a'},
+		{mpfBin16, 0},
+		{mpfFixArray + 2, 0x01},
+		{mpfMap32, 0xff, 0xff, 0xff, 0xff},
+		{mpfFixExt4, 1, 0},
+	} {
+		d := &decoder{data: data}
+		_, err := d.value(maxDepth)
+		var perr *ParseError
+		if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.As(err, &perr) || perr.Offset != len(data) {
+			t.Errorf("value(% x): err = %v, want truncation at %d", data, err, len(data))
+		}
+	}
+}
+
+func TestDecoderDepth(t *testing.T) {
+	data := make([]byte, maxDepth+2)
+	for i := range data {
+		data[i] = mpfFixArray + 1
+	}
+	d := &decoder{data: data}
+	_, err := d.value(maxDepth)
+	var perr *ParseError
+	if !errors.As(err, &perr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("err = %v, want nesting error", err)
+	}
+}
+
+func TestDecoderNestedLengths(t *testing.T) {
+	// Nested arrays each claiming the rest of the data, then a byte that
+	// isn't a value. Only a bounded amount is allocated before the error.
+	const pad = 1 << 20
+	var data []byte
+	for range maxDepth - 1 {
+		data = append(data, mpfArray32)
+		data = binary.BigEndian.AppendUint32(data, pad)
+	}
+	data = append(data, bytes.Repeat([]byte{0xc1}, pad)...)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	d := &decoder{data: data}
+	if _, err := d.value(maxDepth); err == nil {
+		t.Fatal("expected an error")
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > pad {
+		t.Errorf("allocated %d bytes decoding %d", n, len(data))
+	}
+}
@@ -0,0 +1,533 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Query is a filter over games in the query syntax of libretro's
+// libretrodb_tool: a map of RDB field names to the values they must have,
+// e.g. {"developer":"Sega","releaseyear":1991}. Strings use single or
+// double quotes, b"..." is hex binary, true, false and nil are literals,
+// and a value can be a nested map or one of libretrodb's functions:
+//
+//	glob("*Sonic*")       string matches a pattern of * and ?
+//	between(1990, 1995)   number is in an inclusive range
+//	is_true()             flag is set
+//	equals(value)         same as value
+//	operator_and(a, ...)  every argument matches
+//	operator_or(a, ...)   any argument matches
+//
+// Checksums are binary, so b"F9394E97" and "f9394e97" both match a CRC.
+type Query struct {
+	src   string
+	match matcher
+}
+
+// matcher reports whether a field value matches. set is false when the
+// game doesn't have the field.
+type matcher func(v any, set bool) bool
+
+// ParseQuery parses a query. Syntax errors give the offset in s.
+func ParseQuery(s string) (*Query, error) {
+	p := &queryParser{s: s}
+	p.skipSpace()
+	if p.peek() != '{' {
+		return nil, p.errorf("query must be a map")
+	}
+	m, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after query", p.s[p.pos])
+	}
+	return &Query{src: s, match: m}, nil
+}
+
+// String returns the query as it was parsed
+func (q *Query) String() string {
+	return q.src
+}
+
+// Match reports whether a game matches the query
+func (q *Query) Match(g *Game) bool {
+	fields := gameFields(g)
+	values := make(map[string]any, len(fields))
+	for _, f := range fields {
+		values[f.key] = f.value
+	}
+	return q.match(values, true)
+}
+
+// Query returns the games matching q in file order
+func (rdb *RDB) Query(q *Query) []*Game {
+	var out []*Game
+	for i := range rdb.games {
+		if q.Match(&rdb.games[i]) {
+			out = append(out, &rdb.games[i])
+		}
+	}
+	return out
+}
+
+// queryParser parses the query syntax into matchers
+type queryParser struct {
+	s   string
+	pos int
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("rdb: query offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek returns the next byte, or 0 at the end of the query
+func (p *queryParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// expect consumes c after any space
+func (p *queryParser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		if p.pos >= len(p.s) {
+			return p.errorf("expected %q, found end of query", c)
+		}
+		return p.errorf("expected %q, found %q", c, p.s[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+// expr parses a map, function call or literal
+func (p *queryParser) expr() (matcher, error) {
+	m, _, err := p.arg()
+	return m, err
+}
+
+// arg parses an expression. Literals are also returned as themselves for
+// functions taking values.
+func (p *queryParser) arg() (matcher, any, error) {
+	p.skipSpace()
+	if p.peek() == '{' {
+		m, err := p.mapExpr()
+		return m, nil, err
+	}
+	if isIdentByte(p.peek()) && !p.isBinary() {
+		start := p.pos
+		name := p.ident()
+		p.skipSpace()
+		if p.peek() == '(' {
+			m, err := p.call(name, start)
+			return m, nil, err
+		}
+		p.pos = start
+	}
+	v, err := p.literal()
+	if err != nil {
+		return nil, nil, err
+	}
+	return equalTo(v), v, nil
+}
+
+// mapExpr parses a map of field names to expressions. It matches a map
+// holding every field.
+func (p *queryParser) mapExpr() (matcher, error) {
+	p.pos++ // '{'
+	fields := make(map[string]matcher)
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+	} else {
+		for {
+			p.skipSpace()
+			key, err := p.literal()
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, p.errorf("map key must be a string")
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			m, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = m
+
+			p.skipSpace()
+			if p.peek() == '}' {
+				p.pos++
+				break
+			}
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return func(v any, set bool) bool {
+		values, ok := v.(map[string]any)
+		if !set || !ok {
+			return false
+		}
+		for name, m := range fields {
+			fv, fset := values[name]
+			if !m(fv, fset) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// call parses the arguments of the function name starting at start
+func (p *queryParser) call(name string, start int) (matcher, error) {
+	p.pos++ // '('
+	var args []matcher
+	var literals []any
+	p.skipSpace()
+	if p.peek() == ')' {
+		p.pos++
+	} else {
+		for {
+			m, v, err := p.arg()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, m)
+			literals = append(literals, v)
+
+			p.skipSpace()
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			if err := p.expect(','); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	argCount := func(n int) error {
+		if len(args) != n {
+			p.pos = start
+			return p.errorf("%s takes %d arguments, got %d", name, n, len(args))
+		}
+		return nil
+	}
+
+	switch name {
+	case "is_true":
+		if err := argCount(0); err != nil {
+			return nil, err
+		}
+		return func(v any, set bool) bool {
+			b, ok := truth(v)
+			return set && ok && b
+		}, nil
+	case "equals":
+		if err := argCount(1); err != nil {
+			return nil, err
+		}
+		return args[0], nil
+	case "glob":
+		if err := argCount(1); err != nil {
+			return nil, err
+		}
+		pattern, ok := literals[0].(string)
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("glob takes a string pattern")
+		}
+		return func(v any, set bool) bool {
+			switch v := v.(type) {
+			case string:
+				return globMatch(pattern, v)
+			case []byte:
+				return globMatch(pattern, string(v))
+			}
+			return false
+		}, nil
+	case "between":
+		if err := argCount(2); err != nil {
+			return nil, err
+		}
+		lo, hi := literals[0], literals[1]
+		if _, ok := compareNumbers(lo, lo); !ok {
+			p.pos = start
+			return nil, p.errorf("between takes numbers")
+		}
+		if _, ok := compareNumbers(hi, hi); !ok {
+			p.pos = start
+			return nil, p.errorf("between takes numbers")
+		}
+		return func(v any, set bool) bool {
+			cmpLo, ok := compareNumbers(v, lo)
+			if !set || !ok || cmpLo < 0 {
+				return false
+			}
+			cmpHi, _ := compareNumbers(v, hi)
+			return cmpHi <= 0
+		}, nil
+	case "operator_and":
+		return func(v any, set bool) bool {
+			for _, m := range args {
+				if !m(v, set) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "operator_or":
+		return func(v any, set bool) bool {
+			for _, m := range args {
+				if m(v, set) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	p.pos = start
+	return nil, p.errorf("unknown function %s", name)
+}
+
+// isBinary reports whether a b"..." binary literal is next
+func (p *queryParser) isBinary() bool {
+	if p.peek() != 'b' || p.pos+1 >= len(p.s) {
+		return false
+	}
+	c := p.s[p.pos+1]
+	return c == '"' || c == '\''
+}
+
+// literal parses a string, binary, number, true, false or nil
+func (p *queryParser) literal() (any, error) {
+	p.skipSpace()
+	start := p.pos
+	c := p.peek()
+	switch {
+	case c == '"' || c == '\'':
+		return p.str()
+	case p.isBinary():
+		p.pos++
+		s, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid hex in binary")
+		}
+		return b, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		p.pos++
+		for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+			p.pos++
+		}
+		num := p.s[start:p.pos]
+		if c == '-' {
+			n, err := strconv.ParseInt(num, 10, 64)
+			if err != nil {
+				p.pos = start
+				return nil, p.errorf("invalid number %q", num)
+			}
+			return n, nil
+		}
+		n, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number %q", num)
+		}
+		return n, nil
+	case isIdentByte(c):
+		switch name := p.ident(); name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "nil":
+			return nil, nil
+		default:
+			p.pos = start
+			return nil, p.errorf("unexpected %q", name)
+		}
+	case c == 0:
+		return nil, p.errorf("unexpected end of query")
+	}
+	return nil, p.errorf("unexpected %q", c)
+}
+
+// str parses a quoted string. A backslash escapes the next character.
+func (p *queryParser) str() (string, error) {
+	start := p.pos
+	quote := p.s[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && p.pos < len(p.s):
+			sb.WriteByte(p.s[p.pos])
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// ident parses a function or literal name
+func (p *queryParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.s) && isIdentByte(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// equalTo matches a field equal to a literal. nil matches an unset field.
+func equalTo(want any) matcher {
+	return func(v any, set bool) bool {
+		if !set {
+			return want == nil
+		}
+		switch w := want.(type) {
+		case nil:
+			return v == nil
+		case bool:
+			b, ok := truth(v)
+			return ok && b == w
+		case string:
+			switch v := v.(type) {
+			case string:
+				return v == w
+			case []byte:
+				return string(v) == w || strings.EqualFold(hex.EncodeToString(v), w)
+			}
+		case []byte:
+			switch v := v.(type) {
+			case []byte:
+				return bytes.Equal(v, w)
+			case string:
+				return v == string(w)
+			}
+		case uint64, int64:
+			cmp, ok := compareNumbers(v, w)
+			return ok && cmp == 0
+		}
+		return false
+	}
+}
+
+// truth returns a flag value. RDB flags are usually integers.
+func truth(v any) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case uint64:
+		return v != 0, true
+	case int64:
+		return v != 0, true
+	}
+	return false, false
+}
+
+// compareNumbers compares two integers of either sign, or big-endian
+// binary such as a CRC. ok is false if either isn't a number.
+func compareNumbers(a, b any) (int, bool) {
+	aNeg, aMag, ok := numberValue(a)
+	if !ok {
+		return 0, false
+	}
+	bNeg, bMag, ok := numberValue(b)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case aNeg != bNeg:
+		if aNeg {
+			return -1, true
+		}
+		return 1, true
+	case aMag == bMag:
+		return 0, true
+	case (aMag < bMag) != aNeg:
+		return -1, true
+	}
+	return 1, true
+}
+
+// numberValue returns the sign and magnitude of an integer
+func numberValue(v any) (neg bool, mag uint64, ok bool) {
+	switch v := v.(type) {
+	case uint64:
+		return false, v, true
+	case int64:
+		if v < 0 {
+			return true, uint64(-(v + 1)) + 1, true
+		}
+		return false, uint64(v), true
+	case []byte:
+		if len(v) == 0 || len(v) > 8 {
+			return false, 0, false
+		}
+		return false, uintValue(v, 64), true
+	}
+	return false, 0, false
+}
+
+// globMatch reports whether s matches pattern, where * matches any run of
+// characters and ? matches one
+func globMatch(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	pi, si := 0, 0
+	star, starSi := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, starSi = pi, si
+			pi++
+		case star >= 0:
+			// Let the last * match one more character
+			starSi++
+			pi, si = star+1, starSi
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
@@ -0,0 +1,102 @@
+package rdb
+
+import (
+	"strings"
+	"testing"
+)
+
+func queryTestRDB() *RDB {
+	return New([]Game{
+		{Name: "Sonic the Hedgehog (USA, Europe)", Developer: "Sega", ReleaseYear: 1991, Users: 1, CRC32: 0xF9394E97, MD5: "1bc674be034e43c96b86487ac69d9293"},
+		{Name: "Sonic the Hedgehog 2 (World)", Developer: "Sega", ReleaseYear: 1992, Users: 2, Coop: true},
+		{Name: "Streets of Rage (World)", Developer: "Sega", ReleaseYear: 1991, Users: 2, Coop: true},
+		{Name: "Gunstar Heroes (USA)", Developer: "Treasure", ReleaseYear: 1993, Users: 2, Extra: map[string]any{"tags": map[string]any{"genre": "Run and gun"}}},
+	})
+}
+
+func TestQuery(t *testing.T) {
+	rdb := queryTestRDB()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`{"developer":"Sega","releaseyear":1991}`, []string{"Sonic the Hedgehog (USA, Europe)", "Streets of Rage (World)"}},
+		{`{'developer': 'Treasure'}`, []string{"Gunstar Heroes (USA)"}},
+		{`{"name":glob("Sonic*")}`, []string{"Sonic the Hedgehog (USA, Europe)", "Sonic the Hedgehog 2 (World)"}},
+		{`{"name":glob("*(?orld)")}`, []string{"Sonic the Hedgehog 2 (World)", "Streets of Rage (World)"}},
+		{`{"releaseyear":between(1992, 1993)}`, []string{"Sonic the Hedgehog 2 (World)", "Gunstar Heroes (USA)"}},
+		{`{"coop":is_true()}`, []string{"Sonic the Hedgehog 2 (World)", "Streets of Rage (World)"}},
+		{`{"coop":true, "developer":"Sega"}`, []string{"Sonic the Hedgehog 2 (World)", "Streets of Rage (World)"}},
+		{`{"coop":nil}`, []string{"Sonic the Hedgehog (USA, Europe)", "Gunstar Heroes (USA)"}},
+		{`{"crc":b"F9394E97"}`, []string{"Sonic the Hedgehog (USA, Europe)"}},
+		{`{"crc":"f9394e97"}`, []string{"Sonic the Hedgehog (USA, Europe)"}},
+		{`{"crc":4181282455}`, []string{"Sonic the Hedgehog (USA, Europe)"}},
+		{`{"md5":"1BC674BE034E43C96B86487AC69D9293"}`, []string{"Sonic the Hedgehog (USA, Europe)"}},
+		{`{"users":operator_or(1, equals(3))}`, []string{"Sonic the Hedgehog (USA, Europe)"}},
+		{`{"releaseyear":operator_and(between(1990, 1995), 1993)}`, []string{"Gunstar Heroes (USA)"}},
+		{`{"tags":{"genre":glob("Run*")}}`, []string{"Gunstar Heroes (USA)"}},
+		{`{"releaseyear":-1}`, nil},
+		{`{}`, []string{"Sonic the Hedgehog (USA, Europe)", "Sonic the Hedgehog 2 (World)", "Streets of Rage (World)", "Gunstar Heroes (USA)"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			q, err := ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("ParseQuery: %v", err)
+			}
+			var got []string
+			for _, g := range rdb.Query(q) {
+				got = append(got, g.Name)
+			}
+			if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query  string
+		offset string
+	}{
+		{`"developer"`, "offset 0"},
+		{`{"developer" "Sega"}`, "offset 13"},
+		{`{"developer":"Sega"`, "offset 19"},
+		{`{"developer":"Sega}`, "offset 13"},
+		{`{"name":prefix("S")}`, "offset 8"},
+		{`{"name":glob(1)}`, "offset 8"},
+		{`{"year":between(1990)}`, "offset 8"},
+		{`{"crc":b"XYZ"}`, "offset 7"},
+		{`{"a":1} x`, "offset 8"},
+		{`{1:2}`, "offset 2"},
+	}
+	for _, tc := range tests {
+		_, err := ParseQuery(tc.query)
+		if err == nil || !strings.Contains(err.Error(), tc.offset) {
+			t.Errorf("ParseQuery(%s): err = %v, want %s", tc.query, err, tc.offset)
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"a*b", "ab", true},
+		{"a*b", "axxb", true},
+		{"a*b", "axxbc", false},
+		{"*b*", "abc", true},
+		{"?", "é", true},
+		{"a?c", "abbc", false},
+		{"**x", "yyx", true},
+	}
+	for _, tc := range tests {
+		if got := globMatch(tc.pattern, tc.s); got != tc.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tc.pattern, tc.s, got, tc.want)
+		}
+	}
+}
@@ -6,9 +6,12 @@
 package rdb
 
 import (
-	"fmt"
-	"math"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -21,42 +24,62 @@ type Game struct {
 	Developer    string
 	Publisher    string
 	Franchise    string
+	Origin       string // Country of origin
+	Region       string
 	ESRBRating   string
+	BBFCRating   string
+	ELSPARating  string
+	PEGIRating   string
+	CERORating   string
 	ROMName      string // ROM filename
 	ReleaseMonth uint
 	ReleaseYear  uint
-	Size         uint64
-	CRC32        uint32
-	Serial       string
-	MD5          string // MD5 hash for RetroAchievements lookup
+	Users        uint // Number of players
+	Rumble       bool
+	Analog       bool
+	Coop         bool
+
+	// Enhancement hardware on the cartridge (e.g., "Super FX")
+	EnhancementHW string
+
+	EdgeMagazineRating uint
+	EdgeMagazineIssue  uint
+	EdgeMagazineReview string
+	FamitsuRating      uint
+	TGDBRating         uint
+
+	Size   uint64
+	CRC32  uint32
+	Serial string
+	MD5    string // MD5 hash for RetroAchievements lookup
+	SHA1   string // Lowercase hex
+
+	// Extra holds fields without a typed field above, keyed by RDB field
+	// name. Values are nil, bool, uint64 (positive integers), int64,
+	// float64, string, []byte (binary), []any, map[string]any or Ext.
+	Extra map[string]any
 }
 
 // RDB contains all game entries from a parsed RDB file
 type RDB struct {
-	games   []Game
-	byCRC32 map[uint32]*Game // Index for fast CRC32 lookups
-	byMD5   map[string]*Game // Index for fast MD5 lookups
+	games     []Game
+	byCRC32   map[uint32]*Game // Index for fast CRC32 lookups
+	byMD5     map[string]*Game // Index for fast MD5 lookups
+	bySHA1    map[string]*Game
+	bySerial  map[string]*Game   // Keyed by normalized serial
+	byROMName map[string]*Game   // Keyed by lowercase ROM name
+	bySize    map[uint64][]*Game // Sizes are shared, so every game is kept
 }
 
-// MessagePack format constants
-const (
-	mpfFixMap   = 0x80
-	mpfMap16    = 0xde
-	mpfMap32    = 0xdf
-	mpfFixArray = 0x90
-	mpfFixStr   = 0xa0
-	mpfStr8     = 0xd9
-	mpfStr16    = 0xda
-	mpfStr32    = 0xdb
-	mpfBin8     = 0xc4
-	mpfBin16    = 0xc5
-	mpfBin32    = 0xc6
-	mpfUint8    = 0xcc
-	mpfUint16   = 0xcd
-	mpfUint32   = 0xce
-	mpfUint64   = 0xcf
-	mpfNil      = 0xc0
-)
+// rdbMagic starts every RDB file. It's followed by the big-endian offset
+// of the metadata map.
+const rdbMagic = "RARCHDB\x00"
+
+// headerSize is the size of the magic and metadata offset
+const headerSize = 0x10
+
+// ErrInvalidHeader is wrapped by the ParseError for data that isn't an RDB.
+var ErrInvalidHeader = errors.New("missing RARCHDB header")
 
 // LoadRDB loads and parses an RDB file from disk
 func LoadRDB(path string) (*RDB, error) {
@@ -64,26 +87,56 @@ func LoadRDB(path string) (*RDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return Parse(data), nil
+	return Parse(data)
+}
+
+// Parse parses RDB file content and returns an RDB database. Malformed or
+// truncated data returns a *ParseError rather than the games read so far.
+func Parse(data []byte) (*RDB, error) {
+	games, err := parseGames(data)
+	if err != nil {
+		return nil, err
+	}
+	return newRDB(games), nil
 }
 
-// Parse parses RDB file content and returns an RDB database
-func Parse(data []byte) *RDB {
-	games := parseGames(data)
+// New returns an RDB of games, e.g. to query games built in memory before
+// writing them with Encode
+func New(games []Game) *RDB {
+	return newRDB(slices.Clone(games))
+}
 
+// newRDB indexes games for lookups
+func newRDB(games []Game) *RDB {
 	rdb := &RDB{
-		games:   games,
-		byCRC32: make(map[uint32]*Game, len(games)),
-		byMD5:   make(map[string]*Game, len(games)),
+		games:     games,
+		byCRC32:   make(map[uint32]*Game, len(games)),
+		byMD5:     make(map[string]*Game, len(games)),
+		bySHA1:    make(map[string]*Game, len(games)),
+		bySerial:  make(map[string]*Game),
+		byROMName: make(map[string]*Game, len(games)),
+		bySize:    make(map[uint64][]*Game),
 	}
 
-	// Build CRC32 and MD5 indexes
 	for i := range rdb.games {
-		if rdb.games[i].CRC32 != 0 {
-			rdb.byCRC32[rdb.games[i].CRC32] = &rdb.games[i]
+		g := &rdb.games[i]
+		if g.CRC32 != 0 {
+			rdb.byCRC32[g.CRC32] = g
+		}
+		if g.MD5 != "" {
+			rdb.byMD5[g.MD5] = g
+		}
+		if g.SHA1 != "" {
+			rdb.bySHA1[g.SHA1] = g
 		}
-		if rdb.games[i].MD5 != "" {
-			rdb.byMD5[rdb.games[i].MD5] = &rdb.games[i]
+		if g.Serial != "" {
+			rdb.bySerial[normalizeSerial(g.Serial)] = g
+		}
+		if g.ROMName != "" {
+			rdb.byROMName[strings.ToLower(g.ROMName)] = g
+		}
+		if g.Size != 0 {
+			rdb.bySize[g.Size] = append(rdb.bySize[g.Size], g)
 		}
 	}
 
@@ -100,6 +153,32 @@ func (rdb *RDB) FindByMD5(md5 string) *Game {
 	return rdb.byMD5[md5]
 }
 
+// FindBySHA1 looks up a game by its SHA1 hash, in hex of either case
+func (rdb *RDB) FindBySHA1(sha1 string) *Game {
+	return rdb.bySHA1[strings.ToLower(sha1)]
+}
+
+// FindBySerial looks up a game by its serial (e.g., "SLUS-00594"), as
+// used to identify disc images. Case and surrounding space are ignored.
+func (rdb *RDB) FindBySerial(serial string) *Game {
+	return rdb.bySerial[normalizeSerial(serial)]
+}
+
+// FindByROMName looks up a game by its ROM filename, ignoring case
+func (rdb *RDB) FindByROMName(name string) *Game {
+	return rdb.byROMName[strings.ToLower(name)]
+}
+
+// FindBySize returns every game whose ROM is size bytes, in file order
+func (rdb *RDB) FindBySize(size uint64) []*Game {
+	return rdb.bySize[size]
+}
+
+// normalizeSerial returns the index key for a serial
+func normalizeSerial(serial string) string {
+	return strings.ToUpper(strings.TrimSpace(serial))
+}
+
 // GetMD5ByCRC32 returns the MD5 hash for a game found by CRC32
 func (rdb *RDB) GetMD5ByCRC32(crc32 uint32) string {
 	if g := rdb.byCRC32[crc32]; g != nil {
@@ -113,6 +192,12 @@ func (rdb *RDB) GameCount() int {
 	return len(rdb.games)
 }
 
+// Games returns the games in the database in file order. The slice is
+// shared with the database and must not be modified.
+func (rdb *RDB) Games() []Game {
+	return rdb.games
+}
+
 // GetDisplayName extracts a clean display name from a No-Intro name
 // by removing region/version information in parentheses
 func GetDisplayName(name string) string {
@@ -155,158 +240,239 @@ func GetRegionFromName(name string) string {
 	return ""
 }
 
-// parseGames parses the MessagePack-encoded RDB data
-func parseGames(data []byte) []Game {
-	if len(data) < 0x11 {
-		return nil
-	}
-
+// parseGames parses the MessagePack map of each game
+func parseGames(data []byte) ([]Game, error) {
 	var output []Game
-	pos := 0x10
-	iskey := false
-	key := ""
-	g := Game{}
-
-	for pos < len(data) && int(data[pos]) != mpfNil {
-		fieldtype := int(data[pos])
-		var value []byte
-
-		if fieldtype < mpfFixMap {
-			// Positive fixint - skip
-		} else if fieldtype < mpfFixArray {
-			// fixmap - new game entry
-			if g.Name != "" || g.CRC32 != 0 {
-				output = append(output, g)
+	err := parseRecords(data, func(d *decoder, start, pairs int) error {
+		g := Game{}
+		for range pairs {
+			key, err := d.key()
+			if err != nil {
+				return err
 			}
-			g = Game{}
-			pos++
-			iskey = true
-			continue
-		} else if fieldtype < mpfNil {
-			// fixstr
-			length := int(data[pos]) - mpfFixStr
-			pos++
-			if pos+length > len(data) {
-				break
+			value, err := d.value(maxDepth)
+			if err != nil {
+				return err
 			}
-			value = data[pos : pos+length]
-			pos += length
+			setGameField(&g, key, value)
 		}
+		if g.Name != "" || g.CRC32 != 0 {
+			output = append(output, g)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
 
-		switch fieldtype {
-		case mpfStr8, mpfStr16, mpfStr32:
-			pos++
-			lenlen := fieldtype - mpfStr8 + 1
-			if pos+lenlen > len(data) {
-				break
-			}
-			lenhex := fmt.Sprintf("%x", string(data[pos:pos+lenlen]))
-			i64, _ := strconv.ParseInt(lenhex, 16, 32)
-			length := int(i64)
-			pos += lenlen
-			if pos+length > len(data) {
-				break
-			}
-			value = data[pos : pos+length]
-			pos += length
-
-		case mpfUint8, mpfUint16, mpfUint32, mpfUint64:
-			pow := float64(data[pos]) - 0xC9
-			length := int(math.Pow(2, pow)) / 8
-			pos++
-			if pos+length > len(data) {
-				break
-			}
-			value = data[pos : pos+length]
-			pos += length
-
-		case mpfBin8, mpfBin16, mpfBin32:
-			pos++
-			if pos >= len(data) {
-				break
-			}
-			length := int(data[pos])
-			pos++
-			if pos+length > len(data) {
-				break
-			}
-			value = data[pos : pos+length]
-			pos += length
+// parseRecords checks the header, then calls record with the decoder at
+// the first key of each record map up to the nil terminator. record must
+// consume all pairs of the map. The record count is checked against the
+// metadata map.
+func parseRecords(data []byte, record func(d *decoder, start, pairs int) error) error {
+	magic := data[:min(len(data), len(rdbMagic))]
+	if !strings.HasPrefix(rdbMagic, string(magic)) {
+		return &ParseError{Offset: 0, Err: ErrInvalidHeader}
+	}
+	if len(data) < headerSize {
+		return &ParseError{Offset: len(data), Err: io.ErrUnexpectedEOF}
+	}
+	metaOffset := binary.BigEndian.Uint64(data[len(rdbMagic):])
 
-		case mpfMap16, mpfMap32:
-			// Map16/Map32 mark a new game entry (same as fixmap but for 16+ fields)
-			if g.Name != "" || g.CRC32 != 0 {
-				output = append(output, g)
-			}
-			g = Game{}
-			length := 2
-			if int(data[pos]) == mpfMap32 {
-				length = 4
-			}
-			pos++
-			if pos+length > len(data) {
-				break
-			}
-			pos += length
-			iskey = true
-			continue
+	d := &decoder{data: data, pos: headerSize}
+	records := 0
+	for {
+		t, err := d.peek()
+		if err != nil {
+			return err
+		}
+		if t == mpfNil {
+			d.pos++
+			break
 		}
 
-		if iskey {
-			key = string(value)
-		} else {
-			setGameField(&g, key, string(value))
+		start := d.pos
+		n, err := d.mapHeader()
+		if err != nil {
+			return err
+		}
+		if err := record(d, start, n); err != nil {
+			return err
 		}
-		iskey = !iskey
+		records++
 	}
 
-	// Don't forget the last entry
-	if g.Name != "" || g.CRC32 != 0 {
-		output = append(output, g)
+	if metaOffset != 0 {
+		return checkMetadata(data, metaOffset, records)
+	}
+	return nil
+}
+
+// checkMetadata checks the record count in the metadata map, which
+// libretrodb writes after the nil terminator
+func checkMetadata(data []byte, offset uint64, records int) error {
+	if offset < headerSize {
+		return errorf(len(rdbMagic), "metadata offset %d is inside the header", offset)
+	}
+	if offset >= uint64(len(data)) {
+		return &ParseError{Offset: len(data), Err: io.ErrUnexpectedEOF}
 	}
 
-	return output
+	d := &decoder{data: data, pos: int(offset)}
+	v, err := d.value(maxDepth)
+	if err != nil {
+		return err
+	}
+	meta, ok := v.(map[string]any)
+	if !ok {
+		return errorf(int(offset), "metadata is %T, not a map", v)
+	}
+	if count, ok := meta["count"]; ok && uintValue(count, 64) != uint64(records) {
+		return errorf(int(offset), "metadata count %v doesn't match %d records", count, records)
+	}
+	return nil
 }
 
-// setGameField sets a field in the game entry
-func setGameField(g *Game, key string, value string) {
+// setGameField sets a field in the game entry. RDB tools store some
+// fields in different types (a CRC as binary or as a hex string, a serial
+// as binary or string), so each field accepts any of them. Unknown fields
+// are kept in Extra.
+func setGameField(g *Game, key string, value any) {
 	switch key {
 	case "name":
-		g.Name = value
+		g.Name = stringValue(value)
 	case "description":
-		g.Description = value
+		g.Description = stringValue(value)
 	case "genre":
-		g.Genre = value
+		g.Genre = stringValue(value)
 	case "developer":
-		g.Developer = value
+		g.Developer = stringValue(value)
 	case "publisher":
-		g.Publisher = value
+		g.Publisher = stringValue(value)
 	case "franchise":
-		g.Franchise = value
+		g.Franchise = stringValue(value)
+	case "origin":
+		g.Origin = stringValue(value)
+	case "region":
+		g.Region = stringValue(value)
 	case "esrb_rating":
-		g.ESRBRating = value
+		g.ESRBRating = stringValue(value)
+	case "bbfc_rating":
+		g.BBFCRating = stringValue(value)
+	case "elspa_rating":
+		g.ELSPARating = stringValue(value)
+	case "pegi_rating":
+		g.PEGIRating = stringValue(value)
+	case "cero_rating":
+		g.CERORating = stringValue(value)
+	case "enhancement_hw":
+		g.EnhancementHW = stringValue(value)
+	case "edge_magazine_review":
+		g.EdgeMagazineReview = stringValue(value)
 	case "serial":
-		g.Serial = value
+		g.Serial = stringValue(value)
 	case "rom_name":
-		g.ROMName = value
+		g.ROMName = stringValue(value)
 	case "size":
-		v := fmt.Sprintf("%x", value)
-		u64, _ := strconv.ParseUint(v, 16, 64)
-		g.Size = u64
+		g.Size = uintValue(value, 64)
 	case "releasemonth":
-		v := fmt.Sprintf("%x", value)
-		u64, _ := strconv.ParseUint(v, 16, 32)
-		g.ReleaseMonth = uint(u64)
+		g.ReleaseMonth = uint(uintValue(value, 32))
 	case "releaseyear":
-		v := fmt.Sprintf("%x", value)
-		u64, _ := strconv.ParseUint(v, 16, 32)
-		g.ReleaseYear = uint(u64)
+		g.ReleaseYear = uint(uintValue(value, 32))
+	case "users":
+		g.Users = uint(uintValue(value, 32))
+	case "edge_magazine_rating":
+		g.EdgeMagazineRating = uint(uintValue(value, 32))
+	case "edge_magazine_issue":
+		g.EdgeMagazineIssue = uint(uintValue(value, 32))
+	case "famitsu_magazine_rating":
+		g.FamitsuRating = uint(uintValue(value, 32))
+	case "tgdb_rating":
+		g.TGDBRating = uint(uintValue(value, 32))
+	case "rumble":
+		g.Rumble = uintValue(value, 64) != 0
+	case "analog":
+		g.Analog = uintValue(value, 64) != 0
+	case "coop":
+		g.Coop = uintValue(value, 64) != 0
 	case "crc":
-		v := fmt.Sprintf("%x", value)
-		u64, _ := strconv.ParseUint(v, 16, 32)
-		g.CRC32 = uint32(u64)
+		g.CRC32 = crcValue(value)
 	case "md5":
 		// MD5 is stored as raw 16 bytes in RDB, convert to hex string
-		g.MD5 = fmt.Sprintf("%x", value)
+		g.MD5 = hexValue(value)
+	case "sha1":
+		g.SHA1 = hexValue(value)
+	default:
+		if g.Extra == nil {
+			g.Extra = make(map[string]any)
+		}
+		g.Extra[key] = value
 	}
 }
+
+// stringValue returns a string or binary field as a string
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// uintValue returns an integer field. Binary values are big-endian and
+// strings are decimal. Values that don't fit in bits are 0.
+func uintValue(value any, bits int) uint64 {
+	var u64 uint64
+	switch v := value.(type) {
+	case uint64:
+		u64 = v
+	case int64:
+		if v < 0 {
+			return 0
+		}
+		u64 = uint64(v)
+	case bool:
+		if v {
+			u64 = 1
+		}
+	case []byte:
+		if len(v) > 8 {
+			return 0
+		}
+		var buf [8]byte
+		copy(buf[8-len(v):], v)
+		u64 = binary.BigEndian.Uint64(buf[:])
+	case string:
+		u64, _ = strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+	}
+	if bits < 64 && u64>>bits != 0 {
+		return 0
+	}
+	return u64
+}
+
+// crcValue returns a CRC32 field. Strings are hex, as written by RDB
+// tools and DATs for checksums.
+func crcValue(value any) uint32 {
+	if v, ok := value.(string); ok {
+		crc, _ := strconv.ParseUint(strings.TrimSpace(v), 16, 32)
+		return uint32(crc)
+	}
+	return uint32(uintValue(value, 32))
+}
+
+// hexValue returns a hash field as lowercase hex. Binary values are raw
+// hash bytes; strings are already hex.
+func hexValue(value any) string {
+	switch v := value.(type) {
+	case []byte:
+		return hex.EncodeToString(v)
+	case string:
+		return strings.ToLower(v)
+	}
+	return ""
+}
@@ -1,6 +1,13 @@
 package rdb
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -215,26 +222,341 @@ func TestSetGameField(t *testing.T) {
 }
 
 func TestParseEmptyData(t *testing.T) {
-	// Data too short (< 0x11 bytes)
-	rdb := Parse([]byte{})
-	if rdb.GameCount() != 0 {
-		t.Errorf("expected 0 games for empty data, got %d", rdb.GameCount())
+	var perr *ParseError
+	if _, err := Parse([]byte{}); !errors.As(err, &perr) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("empty data: err = %v, want truncation", err)
 	}
 
-	// Exactly at boundary
-	shortData := make([]byte, 0x10)
-	rdb = Parse(shortData)
-	if rdb.GameCount() != 0 {
-		t.Errorf("expected 0 games for short data, got %d", rdb.GameCount())
+	// Header without any records or terminator
+	data := append([]byte("RARCHDB\x00"), make([]byte, 8)...)
+	if _, err := Parse(data); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("header only: err = %v, want truncation", err)
+	}
+}
+
+func TestParseInvalidHeader(t *testing.T) {
+	for _, data := range [][]byte{
+		[]byte("not an rdb"),
+		make([]byte, 0x11),
+	} {
+		_, err := Parse(data)
+		var perr *ParseError
+		if !errors.Is(err, ErrInvalidHeader) || !errors.As(err, &perr) || perr.Offset != 0 {
+			t.Errorf("Parse(%q): err = %v, want ErrInvalidHeader at offset 0", data, err)
+		}
 	}
 }
 
 func TestParseNilTerminated(t *testing.T) {
-	// 0x10 header bytes + mpfNil byte should yield 0 games
-	data := make([]byte, 0x11)
-	data[0x10] = mpfNil
-	rdb := Parse(data)
+	// Header + mpfNil byte should yield 0 games
+	data := append([]byte("RARCHDB\x00"), make([]byte, 8)...)
+	data = append(data, mpfNil)
+	rdb, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
 	if rdb.GameCount() != 0 {
 		t.Errorf("expected 0 games for nil-terminated data, got %d", rdb.GameCount())
 	}
 }
+
+// testField is a key and value written by encodeTestRDB
+type testField struct {
+	key   string
+	value any // string, []byte or uint64
+}
+
+// encodeTestRDB encodes games as an RDB file, one MessagePack map per game
+func encodeTestRDB(games ...[]testField) []byte {
+	data := append([]byte("RARCHDB\x00"), make([]byte, 8)...)
+	str := func(s string) {
+		if len(s) < 32 {
+			data = append(data, byte(mpfFixStr+len(s)))
+		} else {
+			data = append(data, mpfStr8, byte(len(s)))
+		}
+		data = append(data, s...)
+	}
+	for _, fields := range games {
+		if len(fields) < 16 {
+			data = append(data, byte(mpfFixMap+len(fields)))
+		} else {
+			data = append(data, mpfMap16)
+			data = binary.BigEndian.AppendUint16(data, uint16(len(fields)))
+		}
+		for _, f := range fields {
+			str(f.key)
+			switch v := f.value.(type) {
+			case string:
+				str(v)
+			case []byte:
+				data = append(data, mpfBin8, byte(len(v)))
+				data = append(data, v...)
+			case uint64:
+				data = append(data, mpfUint64)
+				data = binary.BigEndian.AppendUint64(data, v)
+			}
+		}
+	}
+	data = append(data, mpfNil)
+
+	// Metadata map with the record count
+	binary.BigEndian.PutUint64(data[8:], uint64(len(data)))
+	data = append(data, mpfFixMap+1)
+	str("count")
+	data = append(data, mpfUint64)
+	return binary.BigEndian.AppendUint64(data, uint64(len(games)))
+}
+
+func TestParseAllFields(t *testing.T) {
+	data := encodeTestRDB([]testField{
+		{"name", "Sonic the Hedgehog (USA, Europe)"},
+		{"description", "Sonic the Hedgehog"},
+		{"genre", "Platform"},
+		{"developer", "Sonic Team"},
+		{"publisher", "Sega"},
+		{"franchise", "Sonic"},
+		{"origin", "Japan"},
+		{"region", "USA, Europe"},
+		{"esrb_rating", "E"},
+		{"pegi_rating", "3"},
+		{"enhancement_hw", "SVP"},
+		{"edge_magazine_review", "Fast"},
+		{"edge_magazine_rating", uint64(8)},
+		{"edge_magazine_issue", uint64(96)},
+		{"famitsu_magazine_rating", uint64(33)},
+		{"tgdb_rating", uint64(4)},
+		{"users", uint64(2)},
+		{"rumble", uint64(1)},
+		{"analog", uint64(0)},
+		{"coop", uint64(1)},
+		{"releasemonth", uint64(6)},
+		{"releaseyear", uint64(1991)},
+		{"rom_name", "Sonic the Hedgehog (USA, Europe).md"},
+		{"size", uint64(524288)},
+		{"serial", []byte("MK-1079")},
+		{"crc", []byte{0xF9, 0x39, 0x4E, 0x97}},
+		{"md5", []byte{0x1b, 0xc6, 0x74, 0xbe, 0x03, 0x4e, 0x43, 0xc9, 0x6b, 0x86, 0x48, 0x7a, 0xc6, 0x9d, 0x92, 0x93}},
+		{"sha1", []byte{0x6d, 0xde, 0xb3, 0x34, 0x7b, 0x13, 0x3b, 0x88, 0x6e, 0x55, 0xd5, 0x1e, 0x8b, 0x3c, 0xf1, 0x1f, 0x05, 0x01, 0xfe, 0xa6}},
+		{"achievements", "yes"},
+		{"checksum_version", uint64(2)},
+	})
+
+	rdb, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if rdb.GameCount() != 1 {
+		t.Fatalf("expected 1 game, got %d", rdb.GameCount())
+	}
+	g := rdb.games[0]
+
+	want := Game{
+		Name:               "Sonic the Hedgehog (USA, Europe)",
+		Description:        "Sonic the Hedgehog",
+		Genre:              "Platform",
+		Developer:          "Sonic Team",
+		Publisher:          "Sega",
+		Franchise:          "Sonic",
+		Origin:             "Japan",
+		Region:             "USA, Europe",
+		ESRBRating:         "E",
+		PEGIRating:         "3",
+		EnhancementHW:      "SVP",
+		EdgeMagazineReview: "Fast",
+		EdgeMagazineRating: 8,
+		EdgeMagazineIssue:  96,
+		FamitsuRating:      33,
+		TGDBRating:         4,
+		Users:              2,
+		Rumble:             true,
+		Coop:               true,
+		ReleaseMonth:       6,
+		ReleaseYear:        1991,
+		ROMName:            "Sonic the Hedgehog (USA, Europe).md",
+		Size:               524288,
+		Serial:             "MK-1079",
+		CRC32:              0xF9394E97,
+		MD5:                "1bc674be034e43c96b86487ac69d9293",
+		SHA1:               "6ddeb3347b133b886e55d51e8b3cf11f0501fea6",
+	}
+	extra := g.Extra
+	g.Extra = nil
+	if !reflect.DeepEqual(g, want) {
+		t.Errorf("game = %+v\nwant %+v", g, want)
+	}
+	if len(extra) != 2 || extra["achievements"] != "yes" || extra["checksum_version"] != uint64(2) {
+		t.Errorf("Extra = %v", extra)
+	}
+}
+
+func TestSetGameFieldValueTypes(t *testing.T) {
+	g := &Game{}
+	setGameField(g, "crc", "F9394E97")
+	setGameField(g, "md5", "1BC674BE034E43C96B86487AC69D9293")
+	setGameField(g, "size", []byte{0x08, 0x00, 0x00})
+	setGameField(g, "releaseyear", uint64(1<<40))
+	setGameField(g, "users", "10")
+	setGameField(g, "releasemonth", "0c")
+
+	if g.CRC32 != 0xF9394E97 {
+		t.Errorf("hex string CRC32 = %08x", g.CRC32)
+	}
+	if g.MD5 != "1bc674be034e43c96b86487ac69d9293" {
+		t.Errorf("hex string MD5 = %s", g.MD5)
+	}
+	if g.Size != 0x080000 {
+		t.Errorf("binary size = %d", g.Size)
+	}
+	if g.ReleaseYear != 0 {
+		t.Errorf("out of range year = %d, want 0", g.ReleaseYear)
+	}
+	// Only checksums are hex
+	if g.Users != 10 {
+		t.Errorf("decimal string users = %d, want 10", g.Users)
+	}
+	if g.ReleaseMonth != 0 {
+		t.Errorf("hex string month = %d, want 0", g.ReleaseMonth)
+	}
+}
+
+func TestRDBFieldLookups(t *testing.T) {
+	rdb, err := Parse(encodeTestRDB(
+		[]testField{
+			{"name", "Game A (USA)"},
+			{"rom_name", "Game A (USA).sms"},
+			{"serial", "SLUS-00594"},
+			{"size", uint64(131072)},
+			{"sha1", []byte{0xaa, 0xbb}},
+		},
+		[]testField{
+			{"name", "Game B (Japan)"},
+			{"rom_name", "Game B (Japan).sms"},
+			{"size", uint64(131072)},
+		},
+		[]testField{
+			{"name", "Game C (Europe)"},
+			{"size", uint64(262144)},
+		},
+	))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if g := rdb.FindBySHA1("AABB"); g == nil || g.Name != "Game A (USA)" {
+		t.Errorf("FindBySHA1 = %+v", g)
+	}
+	if g := rdb.FindBySerial(" slus-00594 "); g == nil || g.Name != "Game A (USA)" {
+		t.Errorf("FindBySerial = %+v", g)
+	}
+	if g := rdb.FindBySerial("SLUS-00595"); g != nil {
+		t.Errorf("FindBySerial unknown = %+v", g)
+	}
+	if g := rdb.FindByROMName("game b (japan).SMS"); g == nil || g.Name != "Game B (Japan)" {
+		t.Errorf("FindByROMName = %+v", g)
+	}
+
+	games := rdb.FindBySize(131072)
+	if len(games) != 2 || games[0].Name != "Game A (USA)" || games[1].Name != "Game B (Japan)" {
+		t.Errorf("FindBySize = %v", games)
+	}
+	if games := rdb.FindBySize(1); games != nil {
+		t.Errorf("FindBySize unknown = %v", games)
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	data := encodeTestRDB(
+		[]testField{{"name", "Game A (USA)"}, {"crc", []byte{1, 2, 3, 4}}},
+		[]testField{{"name", "Game B (USA)"}, {"size", uint64(131072)}},
+	)
+	if _, err := Parse(data); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	for n := range len(data) {
+		rdb, err := Parse(data[:n])
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("Parse of %d/%d bytes: rdb = %v, err = %v, want truncation", n, len(data), rdb, err)
+		}
+	}
+}
+
+func TestParseErrorOffset(t *testing.T) {
+	valid := encodeTestRDB([]testField{{"name", "Game A (USA)"}})
+	recordStart := 0x10
+
+	tests := []struct {
+		name   string
+		modify func(data []byte) []byte
+		offset int
+	}{
+		{"record not a map", func(data []byte) []byte {
+			data[recordStart] = mpfFixArray + 1
+			return data
+		}, recordStart},
+		{"invalid type", func(data []byte) []byte {
+			data[recordStart+1] = 0xc1
+			return data
+		}, recordStart + 1},
+		{"non-string key", func(data []byte) []byte {
+			data[recordStart+1] = 0x05
+			return data
+		}, recordStart + 1},
+		{"count mismatch", func(data []byte) []byte {
+			data[len(data)-1] = 2
+			return data
+		}, len(valid) - 16},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.modify(bytes.Clone(valid)))
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("err = %v, want *ParseError", err)
+			}
+			if perr.Offset != tc.offset {
+				t.Errorf("offset = %d, want %d (%v)", perr.Offset, tc.offset, err)
+			}
+		})
+	}
+}
+
+func TestLoadRDBError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.rdb")
+	data := encodeTestRDB([]testField{{"name", "Game A (USA)"}})
+	if err := os.WriteFile(path, data[:len(data)-4], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if rdb, err := LoadRDB(path); rdb != nil || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("LoadRDB = %v, %v, want truncation error", rdb, err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	valid := encodeTestRDB(
+		[]testField{{"name", "Game A (USA)"}, {"md5", []byte{1, 2, 3}}},
+		[]testField{{"name", "Game B (USA)"}, {"releaseyear", uint64(1991)}},
+	)
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add([]byte("RARCHDB\x00"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rdb, err := Parse(data)
+		if err != nil {
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("err = %v, not a *ParseError", err)
+			}
+			if perr.Offset < 0 || perr.Offset > len(data) {
+				t.Fatalf("offset %d outside %d bytes", perr.Offset, len(data))
+			}
+			return
+		}
+		if rdb.GameCount() > len(data) {
+			t.Fatalf("%d games from %d bytes", rdb.GameCount(), len(data))
+		}
+	})
+}
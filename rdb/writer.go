@@ -0,0 +1,169 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// field is a key and value of a game record
+type field struct {
+	key   string
+	value any
+}
+
+// typedFields are the RDB field names with a typed Game field, which take
+// precedence over the same key in Extra
+var typedFields = map[string]bool{
+	"name": true, "description": true, "genre": true, "developer": true,
+	"publisher": true, "franchise": true, "origin": true, "region": true,
+	"esrb_rating": true, "bbfc_rating": true, "elspa_rating": true,
+	"pegi_rating": true, "cero_rating": true, "enhancement_hw": true,
+	"edge_magazine_review": true, "serial": true, "rom_name": true,
+	"size": true, "releasemonth": true, "releaseyear": true, "users": true,
+	"edge_magazine_rating": true, "edge_magazine_issue": true,
+	"famitsu_magazine_rating": true, "tgdb_rating": true, "rumble": true,
+	"analog": true, "coop": true, "crc": true, "md5": true, "sha1": true,
+}
+
+// gameFields returns the non-empty fields of a game as written to an RDB,
+// with Extra fields last in key order. Checksums are binary as in
+// libretro's databases, and flags are 1 when set.
+func gameFields(g *Game) []field {
+	var fields []field
+	str := func(key, value string) {
+		if value != "" {
+			fields = append(fields, field{key, value})
+		}
+	}
+	num := func(key string, value uint64) {
+		if value != 0 {
+			fields = append(fields, field{key, value})
+		}
+	}
+	flag := func(key string, value bool) {
+		if value {
+			fields = append(fields, field{key, uint64(1)})
+		}
+	}
+	hash := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if b, err := hex.DecodeString(value); err == nil {
+			fields = append(fields, field{key, b})
+		} else {
+			fields = append(fields, field{key, value})
+		}
+	}
+
+	str("name", g.Name)
+	str("description", g.Description)
+	str("genre", g.Genre)
+	str("developer", g.Developer)
+	str("publisher", g.Publisher)
+	str("franchise", g.Franchise)
+	str("origin", g.Origin)
+	str("region", g.Region)
+	str("esrb_rating", g.ESRBRating)
+	str("bbfc_rating", g.BBFCRating)
+	str("elspa_rating", g.ELSPARating)
+	str("pegi_rating", g.PEGIRating)
+	str("cero_rating", g.CERORating)
+	str("enhancement_hw", g.EnhancementHW)
+	str("edge_magazine_review", g.EdgeMagazineReview)
+	str("serial", g.Serial)
+	str("rom_name", g.ROMName)
+	num("size", g.Size)
+	num("releasemonth", uint64(g.ReleaseMonth))
+	num("releaseyear", uint64(g.ReleaseYear))
+	num("users", uint64(g.Users))
+	num("edge_magazine_rating", uint64(g.EdgeMagazineRating))
+	num("edge_magazine_issue", uint64(g.EdgeMagazineIssue))
+	num("famitsu_magazine_rating", uint64(g.FamitsuRating))
+	num("tgdb_rating", uint64(g.TGDBRating))
+	flag("rumble", g.Rumble)
+	flag("analog", g.Analog)
+	flag("coop", g.Coop)
+	if g.CRC32 != 0 {
+		fields = append(fields, field{"crc", binary.BigEndian.AppendUint32(nil, g.CRC32)})
+	}
+	hash("md5", g.MD5)
+	hash("sha1", g.SHA1)
+
+	for _, key := range slices.Sorted(maps.Keys(g.Extra)) {
+		if !typedFields[key] {
+			fields = append(fields, field{key, g.Extra[key]})
+		}
+	}
+	return fields
+}
+
+// Encode returns games encoded as an RDB file, readable by Parse and by
+// RetroArch. Extra values must be types returned by Parse or other Go
+// integer and float types.
+func Encode(games []Game) ([]byte, error) {
+	e := &encoder{data: make([]byte, headerSize, headerSize+len(games)*128)}
+	copy(e.data, rdbMagic)
+
+	for i := range games {
+		fields := gameFields(&games[i])
+		e.mapHeader(len(fields))
+		for _, f := range fields {
+			e.str(f.key)
+			if err := e.value(f.value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	e.data = append(e.data, mpfNil)
+
+	// Metadata map with the record count, as written by libretrodb
+	binary.BigEndian.PutUint64(e.data[len(rdbMagic):], uint64(len(e.data)))
+	e.mapHeader(1)
+	e.str("count")
+	e.uint(uint64(len(games)))
+	return e.data, nil
+}
+
+// Write writes games to w as an RDB file
+func Write(w io.Writer, games []Game) error {
+	data, err := Encode(games)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteFile writes games to an RDB file at path. The file is written to a
+// temporary file first and renamed, so readers never see a partial file.
+func WriteFile(path string, games []Game) error {
+	data, err := Encode(games)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,116 @@
+package rdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	games := []Game{
+		{
+			Name:          "Sonic the Hedgehog (USA, Europe)",
+			Description:   "Sonic the Hedgehog",
+			Developer:     "Sonic Team",
+			Publisher:     "Sega",
+			ROMName:       "Sonic the Hedgehog (USA, Europe).md",
+			ReleaseMonth:  6,
+			ReleaseYear:   1991,
+			Users:         1,
+			Rumble:        true,
+			EnhancementHW: "None",
+			Size:          524288,
+			CRC32:         0xF9394E97,
+			Serial:        "MK-1079",
+			MD5:           "1bc674be034e43c96b86487ac69d9293",
+			SHA1:          "6ddeb3347b133b886e55d51e8b3cf11f0501fea6",
+			Extra: map[string]any{
+				"achievements": "yes",
+				"negative":     int64(-300),
+				"float":        1.5,
+				"flag":         true,
+				"list":         []any{uint64(1), "two", nil},
+				"nested":       map[string]any{"a": uint64(70000)},
+				"ext":          Ext{Type: 3, Data: []byte{1, 2, 3}},
+				"long":         strings.Repeat("x", 300),
+				"blob":         bytes.Repeat([]byte{0xab}, 70000),
+			},
+		},
+		{Name: "Homebrew (World)", Genre: "Puzzle"},
+	}
+
+	data, err := Encode(games)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	rdb, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(rdb.Games(), games) {
+		t.Errorf("round trip = %+v\nwant %+v", rdb.Games(), games)
+	}
+	if g := rdb.FindByCRC32(0xF9394E97); g == nil || g.Name != games[0].Name {
+		t.Errorf("FindByCRC32 = %+v", g)
+	}
+}
+
+func TestEncodeIntegers(t *testing.T) {
+	for _, v := range []int64{0, 127, 128, 255, 256, 65535, 65536, 1 << 32, -1, -32, -33, -128, -129, -32768, -32769, -1 << 31, -1<<31 - 1, -1 << 63} {
+		e := &encoder{}
+		e.int(v)
+		d := &decoder{data: e.data}
+		got, err := d.value(maxDepth)
+		if err != nil {
+			t.Fatalf("decode %d: %v", v, err)
+		}
+		if (v >= 0 && got != uint64(v)) || (v < 0 && got != v) {
+			t.Errorf("int %d decoded as %#v from % x", v, got, e.data)
+		}
+	}
+}
+
+func TestEncodeUnsupportedExtra(t *testing.T) {
+	_, err := Encode([]Game{{Name: "Game", Extra: map[string]any{"bad": struct{}{}}}})
+	if err == nil {
+		t.Error("expected error for unsupported Extra type")
+	}
+}
+
+func TestEncodeTypedFieldsWin(t *testing.T) {
+	data, err := Encode([]Game{{Name: "Game", Extra: map[string]any{"name": "Other"}}})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	rdb, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if g := rdb.Games()[0]; g.Name != "Game" || g.Extra != nil {
+		t.Errorf("game = %+v", g)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "homebrew.rdb")
+	games := []Game{{Name: "Homebrew (World)", CRC32: 0x12345678}}
+	if err := WriteFile(path, games); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	rdb, err := LoadRDB(path)
+	if err != nil {
+		t.Fatalf("LoadRDB: %v", err)
+	}
+	if rdb.GameCount() != 1 || rdb.FindByCRC32(0x12345678) == nil {
+		t.Errorf("games = %+v", rdb.Games())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
@@ -0,0 +1,742 @@
+package romloader
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// CHD (MAME Compressed Hunks of Data) images store a disc as fixed size
+// hunks, each compressed on its own, so any part of the image can be read
+// without decompressing the rest. Only version 5 images without a parent
+// are supported.
+
+// errCorruptCHD is returned for CHD images that fail to decode.
+var errCorruptCHD = errors.New("corrupt CHD image")
+
+const (
+	chdHeaderSize = 124
+	chdVersion    = 5
+
+	// chdMaxHunkBytes bounds the hunk size read from the header. chdman
+	// uses 4KB to 19KB hunks.
+	chdMaxHunkBytes = 1 << 24
+)
+
+// CHD codecs, stored in the header as four character codes
+const (
+	chdCodecNone   = 0
+	chdCodecZlib   = 0x7a6c6962 // "zlib"
+	chdCodecZstd   = 0x7a737464 // "zstd"
+	chdCodecLZMA   = 0x6c7a6d61 // "lzma"
+	chdCodecCDZlib = 0x63647a6c // "cdzl"
+	chdCodecCDZstd = 0x63647a73 // "cdzs"
+	chdCodecCDLZMA = 0x63646c7a // "cdlz"
+)
+
+// Hunk types in a compressed CHD map. Types 0-3 select one of the codecs
+// in the header; the pseudo-types after chdCompressRLELarge are resolved
+// to chdCompressSelf or chdCompressParent while reading the map.
+const (
+	chdCompressType0 = iota
+	chdCompressType1
+	chdCompressType2
+	chdCompressType3
+	chdCompressNone
+	chdCompressSelf
+	chdCompressParent
+	chdCompressRLESmall
+	chdCompressRLELarge
+	chdCompressSelf0
+	chdCompressSelf1
+	chdCompressParentSelf
+	chdCompressParent0
+	chdCompressParent1
+)
+
+// CD frames in CHD images are a raw sector followed by its subcode.
+const (
+	cdSectorSize  = 2352
+	cdSubcodeSize = 96
+	cdFrameSize   = cdSectorSize + cdSubcodeSize
+)
+
+// cdSyncHeader starts every data sector. CD codecs drop it, along with
+// the ECC, when they can be regenerated.
+var cdSyncHeader = []byte{0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00}
+
+// chdLZMAProperties are the lc=3, lp=0, pb=2 properties chdman's LZMA
+// encoder uses. CHD stores raw LZMA data without a header.
+const chdLZMAProperties = (2*5+0)*9 + 3
+
+// chdHunk locates one hunk of a CHD image.
+type chdHunk struct {
+	kind   uint8  // chdCompress* type
+	length uint32 // Compressed length in the file
+	offset uint64 // File offset, or the source hunk for chdCompressSelf
+	crc    uint16 // CRC16 of the decompressed hunk
+}
+
+// chdReader reads the data of a CHD image. It implements io.ReaderAt,
+// decompressing the hunks a read covers.
+type chdReader struct {
+	r          io.ReaderAt
+	size       int64 // Logical size of the image
+	hunkBytes  int
+	unitBytes  int
+	codecs     [4]uint32
+	mapOffset  uint64
+	compressed bool // Map is compressed and hunks carry CRC16s
+
+	mu      sync.Mutex
+	hunks   []chdHunk // Read on first use
+	zstd    *zstd.Decoder
+	cached  int // Hunk held in cache; -1 if none
+	cache   []byte
+	scratch []byte // Compressed hunk
+}
+
+// openCHD opens the data of a CHD image.
+func openCHD(path string) (*entryReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chd: %w", err)
+	}
+	c, err := newCHDReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &entryReader{
+		Reader:  io.NewSectionReader(c, 0, c.size),
+		name:    filepath.Base(path),
+		size:    c.size,
+		at:      c,
+		closers: []io.Closer{c, f},
+	}, nil
+}
+
+// listCHD lists the single image in a CHD file, unless the name inside
+// the .chd, as in game.iso.chd, doesn't match extensions. The header only
+// holds SHA1s, not a CRC32.
+func listCHD(path string, extensions []string) ([]Entry, error) {
+	name := filepath.Base(path)
+	if !contentMatch(strings.TrimSuffix(name, filepath.Ext(name)), extensions) {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open chd: %w", err)
+	}
+	defer f.Close()
+
+	c, err := newCHDReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return []Entry{{Name: name, Size: c.size}}, nil
+}
+
+// newCHDReader reads a CHD header. The hunk map is read on first use so
+// oversized images can be rejected without reading it.
+func newCHDReader(r io.ReaderAt) (*chdReader, error) {
+	header := make([]byte, chdHeaderSize)
+	if _, err := r.ReadAt(header[:16], 0); err != nil {
+		return nil, fmt.Errorf("failed to read chd header: %w", err)
+	}
+	if !bytes.HasPrefix(header, magicCHD) {
+		return nil, ErrUnsupportedFormat
+	}
+	if version := binary.BigEndian.Uint32(header[12:]); version != chdVersion {
+		return nil, fmt.Errorf("%w: CHD version %d", ErrUnsupportedFormat, version)
+	}
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read chd header: %w", err)
+	}
+
+	c := &chdReader{
+		r:         r,
+		size:      int64(binary.BigEndian.Uint64(header[32:])),
+		mapOffset: binary.BigEndian.Uint64(header[40:]),
+		hunkBytes: int(binary.BigEndian.Uint32(header[56:])),
+		unitBytes: int(binary.BigEndian.Uint32(header[60:])),
+		cached:    -1,
+	}
+	if c.size < 0 || c.hunkBytes <= 0 || c.hunkBytes > chdMaxHunkBytes || c.unitBytes <= 0 {
+		return nil, fmt.Errorf("%w: bad header", errCorruptCHD)
+	}
+	if !bytes.Equal(header[104:124], make([]byte, 20)) {
+		return nil, fmt.Errorf("%w: CHD requires a parent image", ErrUnsupportedFormat)
+	}
+
+	for i := range c.codecs {
+		codec := binary.BigEndian.Uint32(header[16+i*4:])
+		switch codec {
+		case chdCodecNone, chdCodecZlib, chdCodecLZMA, chdCodecCDZlib, chdCodecCDLZMA:
+		case chdCodecZstd, chdCodecCDZstd:
+			if c.zstd == nil {
+				d, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+				if err != nil {
+					return nil, fmt.Errorf("failed to create zstd decoder: %w", err)
+				}
+				c.zstd = d
+			}
+		default:
+			c.Close()
+			return nil, fmt.Errorf("%w: CHD codec %q", ErrUnsupportedFormat, header[16+i*4:20+i*4])
+		}
+		c.codecs[i] = codec
+	}
+	c.compressed = c.codecs[0] != chdCodecNone
+	return c, nil
+}
+
+// Close releases the decoders. It doesn't close the underlying file.
+func (c *chdReader) Close() error {
+	if c.zstd != nil {
+		c.zstd.Close()
+	}
+	return nil
+}
+
+// ReadAt reads the image data at off.
+func (c *chdReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("chd: negative offset")
+	}
+	if off >= c.size {
+		return 0, io.EOF
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.hunks == nil {
+		if err := c.readMap(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := 0
+	for n < len(p) && off < c.size {
+		hunk := int(off / int64(c.hunkBytes))
+		if err := c.readHunk(hunk); err != nil {
+			return n, err
+		}
+		data := c.cache[off%int64(c.hunkBytes):]
+		if rest := c.size - off; int64(len(data)) > rest {
+			data = data[:rest]
+		}
+		m := copy(p[n:], data)
+		n += m
+		off += int64(m)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readHunk decompresses a hunk into the cache.
+func (c *chdReader) readHunk(n int) error {
+	if c.cached == n {
+		return nil
+	}
+	if c.cache == nil {
+		c.cache = make([]byte, c.hunkBytes)
+	}
+	c.cached = -1
+	if err := c.decodeHunk(n, c.cache); err != nil {
+		return fmt.Errorf("failed to read CHD hunk %d: %w", n, err)
+	}
+	c.cached = n
+	return nil
+}
+
+// decodeHunk decompresses hunk n into dst.
+func (c *chdReader) decodeHunk(n int, dst []byte) error {
+	h := c.hunks[n]
+	switch h.kind {
+	case chdCompressType0, chdCompressType1, chdCompressType2, chdCompressType3:
+		if cap(c.scratch) < int(h.length) {
+			c.scratch = make([]byte, h.length)
+		}
+		src := c.scratch[:h.length]
+		if _, err := c.r.ReadAt(src, int64(h.offset)); err != nil {
+			return err
+		}
+		if err := c.decompress(c.codecs[h.kind], src, dst); err != nil {
+			return err
+		}
+	case chdCompressNone:
+		// Uncompressed images map unwritten hunks to offset 0
+		if h.offset == 0 {
+			clear(dst)
+			return nil
+		}
+		if _, err := c.r.ReadAt(dst, int64(h.offset)); err != nil {
+			return err
+		}
+	case chdCompressSelf:
+		// Copies always refer back to an earlier hunk
+		if h.offset >= uint64(n) {
+			return fmt.Errorf("%w: hunk %d copies hunk %d", errCorruptCHD, n, h.offset)
+		}
+		return c.decodeHunk(int(h.offset), dst)
+	case chdCompressParent:
+		return fmt.Errorf("%w: CHD requires a parent image", ErrUnsupportedFormat)
+	default:
+		return fmt.Errorf("%w: hunk type %d", errCorruptCHD, h.kind)
+	}
+
+	if c.compressed && crc16(dst) != h.crc {
+		return fmt.Errorf("%w: CRC mismatch", errCorruptCHD)
+	}
+	return nil
+}
+
+// decompress decodes a hunk compressed with codec. The data must fill dst.
+func (c *chdReader) decompress(codec uint32, src, dst []byte) error {
+	switch codec {
+	case chdCodecZlib:
+		return inflate(src, dst)
+	case chdCodecZstd:
+		return c.unzstd(src, dst)
+	case chdCodecLZMA:
+		return unlzma(src, dst)
+	case chdCodecCDZlib:
+		return decompressCD(src, dst, inflate, inflate)
+	case chdCodecCDZstd:
+		return decompressCD(src, dst, c.unzstd, c.unzstd)
+	case chdCodecCDLZMA:
+		return decompressCD(src, dst, unlzma, inflate)
+	default:
+		return fmt.Errorf("%w: hunk uses an empty codec slot", errCorruptCHD)
+	}
+}
+
+// inflate decodes raw deflate data.
+func inflate(src, dst []byte) error {
+	fr := flate.NewReader(bytes.NewReader(src))
+	defer fr.Close()
+	_, err := io.ReadFull(fr, dst)
+	return err
+}
+
+// unzstd decodes a zstd frame.
+func (c *chdReader) unzstd(src, dst []byte) error {
+	out, err := c.zstd.DecodeAll(src, dst[:0])
+	if err != nil {
+		return err
+	}
+	if len(out) != len(dst) {
+		return fmt.Errorf("%w: zstd hunk is %d bytes, want %d", errCorruptCHD, len(out), len(dst))
+	}
+	copy(dst, out)
+	return nil
+}
+
+// unlzma decodes headerless LZMA data by supplying the header chdman
+// leaves out.
+func unlzma(src, dst []byte) error {
+	header := make([]byte, lzma.HeaderLen)
+	header[0] = chdLZMAProperties
+	binary.LittleEndian.PutUint32(header[1:], uint32(max(len(dst), lzma.MinDictCap)))
+	binary.LittleEndian.PutUint64(header[5:], uint64(len(dst)))
+
+	lr, err := lzma.NewReader(io.MultiReader(bytes.NewReader(header), bytes.NewReader(src)))
+	if err != nil {
+		return err
+	}
+	_, err = io.ReadFull(lr, dst)
+	return err
+}
+
+// cdHeaderBytes is the size of the header of a compressed CD hunk of
+// hunkBytes: the ECC bitmap and the compressed sector data length.
+func cdHeaderBytes(hunkBytes int) int {
+	eccBytes := (hunkBytes/cdFrameSize + 7) / 8
+	if hunkBytes >= 65536 {
+		return eccBytes + 3
+	}
+	return eccBytes + 2
+}
+
+// decompressCD decodes a hunk of CD frames. The sector data and the
+// subcode are compressed separately, after a bitmap of the frames whose
+// sync header and ECC were dropped and the compressed sector data length.
+func decompressCD(src, dst []byte, sectors, subcode func(src, dst []byte) error) error {
+	if len(dst)%cdFrameSize != 0 {
+		return fmt.Errorf("%w: hunk is not whole CD frames", errCorruptCHD)
+	}
+	frames := len(dst) / cdFrameSize
+	eccBytes := (frames + 7) / 8
+	headerBytes := cdHeaderBytes(len(dst))
+	if len(src) < headerBytes {
+		return fmt.Errorf("%w: short CD hunk", errCorruptCHD)
+	}
+	sectorLen := 0
+	for _, b := range src[eccBytes:headerBytes] {
+		sectorLen = sectorLen<<8 | int(b)
+	}
+	if headerBytes+sectorLen > len(src) {
+		return fmt.Errorf("%w: short CD hunk", errCorruptCHD)
+	}
+
+	buf := make([]byte, frames*(cdSectorSize+cdSubcodeSize))
+	sectorData, subcodeData := buf[:frames*cdSectorSize], buf[frames*cdSectorSize:]
+	if err := sectors(src[headerBytes:headerBytes+sectorLen], sectorData); err != nil {
+		return err
+	}
+	if err := subcode(src[headerBytes+sectorLen:], subcodeData); err != nil {
+		return err
+	}
+
+	for i := 0; i < frames; i++ {
+		frame := dst[i*cdFrameSize : (i+1)*cdFrameSize]
+		copy(frame, sectorData[i*cdSectorSize:(i+1)*cdSectorSize])
+		copy(frame[cdSectorSize:], subcodeData[i*cdSubcodeSize:(i+1)*cdSubcodeSize])
+		if src[i/8]&(1<<(i%8)) != 0 {
+			copy(frame, cdSyncHeader)
+			eccGenerate(frame)
+		}
+	}
+	return nil
+}
+
+// readMap reads the hunk map.
+func (c *chdReader) readMap() error {
+	count := int((c.size + int64(c.hunkBytes) - 1) / int64(c.hunkBytes))
+	if !c.compressed {
+		// One big-endian offset per hunk, in units of the hunk size
+		raw := make([]byte, count*4)
+		if _, err := c.r.ReadAt(raw, int64(c.mapOffset)); err != nil {
+			return fmt.Errorf("failed to read CHD map: %w", err)
+		}
+		hunks := make([]chdHunk, count)
+		for i := range hunks {
+			hunks[i] = chdHunk{
+				kind:   chdCompressNone,
+				offset: uint64(binary.BigEndian.Uint32(raw[i*4:])) * uint64(c.hunkBytes),
+			}
+		}
+		c.hunks = hunks
+		return nil
+	}
+
+	header := make([]byte, 16)
+	if _, err := c.r.ReadAt(header, int64(c.mapOffset)); err != nil {
+		return fmt.Errorf("failed to read CHD map: %w", err)
+	}
+	mapBytes := int64(binary.BigEndian.Uint32(header))
+	offset := uint64(binary.BigEndian.Uint16(header[4:]))<<32 | uint64(binary.BigEndian.Uint32(header[6:]))
+	mapCRC := binary.BigEndian.Uint16(header[10:])
+	lengthBits, selfBits, parentBits := int(header[12]), int(header[13]), int(header[14])
+	if lengthBits > 32 || selfBits > 32 || parentBits > 32 || mapBytes > int64(count)*16+1024 {
+		return fmt.Errorf("%w: bad map header", errCorruptCHD)
+	}
+
+	data := make([]byte, mapBytes)
+	if _, err := c.r.ReadAt(data, int64(c.mapOffset)+16); err != nil {
+		return fmt.Errorf("failed to read CHD map: %w", err)
+	}
+	br := &bitReader{data: data}
+
+	// Hunk types are Huffman coded with run-length encoding
+	dec := &huffmanDecoder{maxBits: 8, lengths: make([]uint8, 16)}
+	if err := dec.importTreeRLE(br); err != nil {
+		return err
+	}
+	kinds := make([]uint8, count)
+	var last uint8
+	repeat := 0
+	for i := range kinds {
+		if repeat > 0 {
+			kinds[i] = last
+			repeat--
+			continue
+		}
+		switch v := dec.decode(br); v {
+		case chdCompressRLESmall:
+			kinds[i] = last
+			repeat = 2 + int(dec.decode(br))
+		case chdCompressRLELarge:
+			kinds[i] = last
+			repeat = 2 + 16 + int(dec.decode(br))<<4
+			repeat += int(dec.decode(br))
+		default:
+			kinds[i] = v
+			last = v
+		}
+	}
+
+	// Then the fields for each type, with pseudo-types resolved. The map
+	// CRC covers the resolved map in chdman's 12 byte entry layout.
+	hunks := make([]chdHunk, count)
+	raw := make([]byte, count*12)
+	unitsPerHunk := uint64(c.hunkBytes / c.unitBytes)
+	// chdman stores hunks that don't compress uncompressed, so a
+	// compressed hunk is never larger than the hunk plus a CD header
+	maxLength := uint32(c.hunkBytes + cdHeaderBytes(c.hunkBytes))
+	var lastSelf, lastParent uint64
+	for i, kind := range kinds {
+		h := chdHunk{kind: kind, offset: offset}
+		switch kind {
+		case chdCompressType0, chdCompressType1, chdCompressType2, chdCompressType3:
+			h.length = br.read(lengthBits)
+			if h.length > maxLength {
+				return fmt.Errorf("%w: hunk %d length %d", errCorruptCHD, i, h.length)
+			}
+			h.crc = uint16(br.read(16))
+			offset += uint64(h.length)
+		case chdCompressNone:
+			h.length = uint32(c.hunkBytes)
+			h.crc = uint16(br.read(16))
+			offset += uint64(h.length)
+		case chdCompressSelf:
+			h.offset = uint64(br.read(selfBits))
+			lastSelf = h.offset
+		case chdCompressParent:
+			h.offset = uint64(br.read(parentBits))
+			lastParent = h.offset
+		case chdCompressSelf1:
+			lastSelf++
+			fallthrough
+		case chdCompressSelf0:
+			h.kind = chdCompressSelf
+			h.offset = lastSelf
+		case chdCompressParentSelf:
+			h.kind = chdCompressParent
+			h.offset = uint64(i) * unitsPerHunk
+			lastParent = h.offset
+		case chdCompressParent1:
+			lastParent += unitsPerHunk
+			fallthrough
+		case chdCompressParent0:
+			h.kind = chdCompressParent
+			h.offset = lastParent
+		default:
+			return fmt.Errorf("%w: hunk type %d", errCorruptCHD, kind)
+		}
+		hunks[i] = h
+
+		entry := raw[i*12 : (i+1)*12]
+		entry[0] = h.kind
+		entry[1], entry[2], entry[3] = byte(h.length>>16), byte(h.length>>8), byte(h.length)
+		binary.BigEndian.PutUint16(entry[4:], uint16(h.offset>>32))
+		binary.BigEndian.PutUint32(entry[6:], uint32(h.offset))
+		binary.BigEndian.PutUint16(entry[10:], h.crc)
+	}
+	if br.overflow() {
+		return fmt.Errorf("%w: truncated map", errCorruptCHD)
+	}
+	if crc16(raw) != mapCRC {
+		return fmt.Errorf("%w: map CRC mismatch", errCorruptCHD)
+	}
+	c.hunks = hunks
+	return nil
+}
+
+// bitReader reads MSB-first bit fields. Reads past the end return zero
+// bits and set overflow.
+type bitReader struct {
+	data []byte
+	pos  int // In bits
+}
+
+// peek returns the next n bits without consuming them.
+func (b *bitReader) peek(n int) uint32 {
+	var v uint32
+	for i := b.pos; i < b.pos+n; i++ {
+		v <<= 1
+		if i/8 < len(b.data) {
+			v |= uint32(b.data[i/8]>>(7-i%8)) & 1
+		}
+	}
+	return v
+}
+
+// read consumes the next n bits.
+func (b *bitReader) read(n int) uint32 {
+	v := b.peek(n)
+	b.pos += n
+	return v
+}
+
+// overflow reports whether more bits were read than the data holds.
+func (b *bitReader) overflow() bool {
+	return b.pos > len(b.data)*8
+}
+
+// huffmanDecoder decodes canonical Huffman codes no longer than maxBits,
+// using a table indexed by the next maxBits bits.
+type huffmanDecoder struct {
+	maxBits int
+	lengths []uint8  // Code length of each symbol; 0 if unused
+	lookup  []uint16 // Symbol<<5 | code length
+}
+
+// importTreeRLE reads the code lengths, stored with a run-length escape:
+// 1 followed by 1 is a length of 1, and 1 followed by another length is a
+// run of that length with the count in the next field, less 3.
+func (d *huffmanDecoder) importTreeRLE(br *bitReader) error {
+	fieldBits := 3
+	if d.maxBits >= 16 {
+		fieldBits = 5
+	} else if d.maxBits >= 8 {
+		fieldBits = 4
+	}
+
+	for i := 0; i < len(d.lengths); {
+		length := uint8(br.read(fieldBits))
+		if length != 1 {
+			d.lengths[i] = length
+			i++
+			continue
+		}
+		length = uint8(br.read(fieldBits))
+		if length == 1 {
+			d.lengths[i] = length
+			i++
+			continue
+		}
+		count := int(br.read(fieldBits)) + 3
+		if i+count > len(d.lengths) {
+			return fmt.Errorf("%w: bad Huffman tree", errCorruptCHD)
+		}
+		for ; count > 0; count-- {
+			d.lengths[i] = length
+			i++
+		}
+	}
+	if br.overflow() {
+		return fmt.Errorf("%w: truncated Huffman tree", errCorruptCHD)
+	}
+	return d.buildLookup()
+}
+
+// buildLookup assigns canonical codes, longest codes first, and fills the
+// lookup table.
+func (d *huffmanDecoder) buildLookup() error {
+	var start [33]uint32
+	for _, length := range d.lengths {
+		if int(length) > d.maxBits {
+			return fmt.Errorf("%w: bad Huffman tree", errCorruptCHD)
+		}
+		start[length]++
+	}
+	var next uint32
+	for length := 32; length > 0; length-- {
+		count := start[length]
+		if length != 1 && (next+count)%2 != 0 {
+			return fmt.Errorf("%w: bad Huffman tree", errCorruptCHD)
+		}
+		start[length] = next
+		next = (next + count) >> 1
+	}
+
+	d.lookup = make([]uint16, 1<<d.maxBits)
+	for symbol, length := range d.lengths {
+		if length == 0 {
+			continue
+		}
+		code := start[length]
+		start[length]++
+		shift := d.maxBits - int(length)
+		first, last := int(code)<<shift, int(code+1)<<shift
+		if last > len(d.lookup) {
+			return fmt.Errorf("%w: bad Huffman tree", errCorruptCHD)
+		}
+		for i := first; i < last; i++ {
+			d.lookup[i] = uint16(symbol<<5) | uint16(length)
+		}
+	}
+	return nil
+}
+
+// decode reads one symbol.
+func (d *huffmanDecoder) decode(br *bitReader) uint8 {
+	v := d.lookup[br.peek(d.maxBits)]
+	br.read(int(v & 0x1f))
+	return uint8(v >> 5)
+}
+
+// crc16Table is the CRC-16/CCITT table used by CHD.
+var crc16Table = func() (t [256]uint16) {
+	for i := range t {
+		crc := uint16(i) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}()
+
+// crc16 returns the CRC-16/CCITT of data, as CHD uses for hunks and maps.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^b]
+	}
+	return crc
+}
+
+// eccF and eccB are the forward and backward tables for CD-ROM ECC
+// arithmetic in GF(2^8).
+var eccF, eccB = func() (f, b [256]byte) {
+	for i := range 256 {
+		j := i << 1
+		if i&0x80 != 0 {
+			j ^= 0x11d
+		}
+		f[i] = byte(j)
+		b[i^j] = byte(i)
+	}
+	return f, b
+}()
+
+// eccGenerate fills in the P and Q parity of a mode 1 data sector.
+func eccGenerate(sector []byte) {
+	eccBlock(sector[0x00C:], 86, 24, 2, 86, sector[0x81C:])
+	eccBlock(sector[0x00C:], 52, 43, 86, 88, sector[0x8C8:])
+}
+
+// eccBlock computes one set of Reed-Solomon parity bytes over src, read
+// as majorCount rows of minorCount bytes.
+func eccBlock(src []byte, majorCount, minorCount, majorMult, minorInc int, dst []byte) {
+	size := majorCount * minorCount
+	for major := 0; major < majorCount; major++ {
+		index := (major>>1)*majorMult + (major & 1)
+		var a, b byte
+		for minor := 0; minor < minorCount; minor++ {
+			t := src[index]
+			index += minorInc
+			if index >= size {
+				index -= size
+			}
+			a ^= t
+			b ^= t
+			a = eccF[a]
+		}
+		a = eccB[eccF[a]^b]
+		dst[major] = a
+		dst[major+majorCount] = a ^ b
+	}
+}
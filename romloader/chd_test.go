@@ -0,0 +1,476 @@
+package romloader
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// testCHDHunk describes one hunk written by createTestCHD
+type testCHDHunk struct {
+	kind    uint8  // chdCompress* type written to the map
+	payload []byte // Compressed data for types 0-3
+	self    int    // Source hunk of self and pseudo-self types
+}
+
+// bitWriter writes MSB-first bit fields
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *bitWriter) write(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[len(w.data)-1] |= byte(v>>i&1) << (7 - w.bits%8)
+		w.bits++
+	}
+}
+
+// testCHDHeader returns a v5 header for an image of size bytes
+func testCHDHeader(size int64, hunkBytes, unitBytes int, codecs [4]uint32, mapOffset int64) []byte {
+	header := make([]byte, chdHeaderSize)
+	copy(header, magicCHD)
+	binary.BigEndian.PutUint32(header[8:], chdHeaderSize)
+	binary.BigEndian.PutUint32(header[12:], chdVersion)
+	for i, codec := range codecs {
+		binary.BigEndian.PutUint32(header[16+i*4:], codec)
+	}
+	binary.BigEndian.PutUint64(header[32:], uint64(size))
+	binary.BigEndian.PutUint64(header[40:], uint64(mapOffset))
+	binary.BigEndian.PutUint32(header[56:], uint32(hunkBytes))
+	binary.BigEndian.PutUint32(header[60:], uint32(unitBytes))
+	return header
+}
+
+// writeTestCHD writes a CHD file and returns its path
+func writeTestCHD(t *testing.T, chd []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "game.chd")
+	if err := os.WriteFile(path, chd, 0644); err != nil {
+		t.Fatalf("Failed to write CHD: %v", err)
+	}
+	return path
+}
+
+// createTestCHD builds a CHD with a compressed map. data is the image,
+// split into hunks described by hunks. Runs of a repeated type are
+// written with chdCompressRLESmall, as chdman does.
+func createTestCHD(t *testing.T, data []byte, hunkBytes, unitBytes int, codecs [4]uint32, hunks []testCHDHunk) string {
+	t.Helper()
+	const lengthBits, selfBits = 24, 16
+
+	hunkData := func(i int) []byte {
+		raw := make([]byte, hunkBytes)
+		copy(raw, data[min(i*hunkBytes, len(data)):])
+		return raw
+	}
+
+	// Hunks follow the header, then the map
+	var body []byte
+	var bw bitWriter
+
+	// Every type gets a 4 bit code: a run of 16 lengths of 4
+	bw.write(1, 4)
+	bw.write(4, 4)
+	bw.write(16-3, 4)
+
+	var last uint8
+	for i := 0; i < len(hunks); i++ {
+		kind := hunks[i].kind
+		run := 0
+		for i+run < len(hunks) && hunks[i+run].kind == last && i > 0 {
+			run++
+		}
+		if run >= 3 {
+			n := min(run, 18)
+			bw.write(chdCompressRLESmall, 4)
+			bw.write(uint32(n-3), 4)
+			i += n - 1
+			continue
+		}
+		bw.write(uint32(kind), 4)
+		last = kind
+	}
+
+	raw := make([]byte, len(hunks)*12)
+	offset := uint64(chdHeaderSize)
+	for i, h := range hunks {
+		entry := raw[i*12 : (i+1)*12]
+		var length uint32
+		var crc uint16
+		entryOffset := offset
+		switch h.kind {
+		case chdCompressType0, chdCompressType1, chdCompressType2, chdCompressType3:
+			length = uint32(len(h.payload))
+			crc = crc16(hunkData(i))
+			bw.write(length, lengthBits)
+			bw.write(uint32(crc), 16)
+			body = append(body, h.payload...)
+		case chdCompressNone:
+			length = uint32(hunkBytes)
+			crc = crc16(hunkData(i))
+			bw.write(uint32(crc), 16)
+			body = append(body, hunkData(i)...)
+		case chdCompressSelf:
+			bw.write(uint32(h.self), selfBits)
+			entryOffset = uint64(h.self)
+		case chdCompressSelf0, chdCompressSelf1:
+			entryOffset = uint64(h.self)
+		}
+		offset += uint64(length)
+
+		kind := h.kind
+		if kind == chdCompressSelf0 || kind == chdCompressSelf1 {
+			kind = chdCompressSelf
+		}
+		entry[0] = kind
+		entry[1], entry[2], entry[3] = byte(length>>16), byte(length>>8), byte(length)
+		binary.BigEndian.PutUint16(entry[4:], uint16(entryOffset>>32))
+		binary.BigEndian.PutUint32(entry[6:], uint32(entryOffset))
+		binary.BigEndian.PutUint16(entry[10:], crc)
+	}
+
+	mapHeader := make([]byte, 16)
+	binary.BigEndian.PutUint32(mapHeader, uint32(len(bw.data)))
+	binary.BigEndian.PutUint16(mapHeader[4:], 0)
+	binary.BigEndian.PutUint32(mapHeader[6:], chdHeaderSize)
+	binary.BigEndian.PutUint16(mapHeader[10:], crc16(raw))
+	mapHeader[12], mapHeader[13] = lengthBits, selfBits
+
+	mapOffset := int64(chdHeaderSize + len(body))
+	chd := testCHDHeader(int64(len(data)), hunkBytes, unitBytes, codecs, mapOffset)
+	chd = append(chd, body...)
+	chd = append(chd, mapHeader...)
+	chd = append(chd, bw.data...)
+	return writeTestCHD(t, chd)
+}
+
+// deflateHunk compresses a hunk with raw deflate
+func deflateHunk(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatalf("deflate failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// zstdHunk compresses a hunk as a zstd frame
+func zstdHunk(t *testing.T, data []byte) []byte {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd failed: %v", err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll(data, nil)
+}
+
+// lzmaHunk compresses a hunk with LZMA, dropping the header like chdman
+func lzmaHunk(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := lzma.WriterConfig{Size: int64(len(data)), DictCap: lzma.MinDictCap}.NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("lzma failed: %v", err)
+	}
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatalf("lzma failed: %v", err)
+	}
+	return buf.Bytes()[lzma.HeaderLen:]
+}
+
+// testCHDData returns size bytes that differ in every hunk
+func testCHDData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*13 + i/4096)
+	}
+	return data
+}
+
+func TestCHD_Uncompressed(t *testing.T) {
+	const hunkBytes = 1024
+	data := testCHDData(2*hunkBytes + 500)
+	clear(data[hunkBytes : 2*hunkBytes])
+
+	// Header, then the map, then hunks aligned to the hunk size. The zero
+	// hunk isn't stored.
+	chd := testCHDHeader(int64(len(data)), hunkBytes, 512, [4]uint32{}, chdHeaderSize)
+	chd = binary.BigEndian.AppendUint32(chd, 1)
+	chd = binary.BigEndian.AppendUint32(chd, 0)
+	chd = binary.BigEndian.AppendUint32(chd, 2)
+	chd = append(chd, make([]byte, hunkBytes-len(chd))...)
+	chd = append(chd, data[:hunkBytes]...)
+	chd = append(chd, data[2*hunkBytes:]...)
+	chd = append(chd, make([]byte, 3*hunkBytes-len(data))...)
+	path := writeTestCHD(t, chd)
+
+	got, filename, err := Load(path, testExtensions)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("data mismatch")
+	}
+	if filename != "game.chd" {
+		t.Errorf("filename = %q, want %q", filename, "game.chd")
+	}
+}
+
+func TestCHD_Compressed(t *testing.T) {
+	const hunkBytes = 4096
+	data := testCHDData(10*hunkBytes - 100)
+	copy(data[4*hunkBytes:5*hunkBytes], data[:hunkBytes])
+	copy(data[9*hunkBytes:], data[hunkBytes:2*hunkBytes])
+	hunk := func(i int) []byte { return data[i*hunkBytes : (i+1)*hunkBytes] }
+
+	hunks := []testCHDHunk{
+		{kind: chdCompressType0, payload: deflateHunk(t, hunk(0))},
+		{kind: chdCompressType1, payload: zstdHunk(t, hunk(1))},
+		{kind: chdCompressType2, payload: lzmaHunk(t, hunk(2))},
+		{kind: chdCompressNone},
+		{kind: chdCompressSelf, self: 0},
+		{kind: chdCompressType0, payload: deflateHunk(t, hunk(5))},
+		// Run of the previous type
+		{kind: chdCompressType0, payload: deflateHunk(t, hunk(6))},
+		{kind: chdCompressType0, payload: deflateHunk(t, hunk(7))},
+		{kind: chdCompressType0, payload: deflateHunk(t, hunk(8))},
+		// The hunk after the last self copy
+		{kind: chdCompressSelf1, self: 1},
+	}
+	codecs := [4]uint32{chdCodecZlib, chdCodecZstd, chdCodecLZMA}
+	path := createTestCHD(t, data, hunkBytes, 512, codecs, hunks)
+
+	got, _, err := Load(path, testExtensions)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		for i := 0; i < len(data); i += hunkBytes {
+			if !bytes.Equal(got[i:min(i+hunkBytes, len(got))], data[i:min(i+hunkBytes, len(data))]) {
+				t.Errorf("hunk %d mismatch", i/hunkBytes)
+			}
+		}
+	}
+}
+
+// testCDFrame returns a mode 1 data frame with valid ECC
+func testCDFrame(n int) []byte {
+	frame := make([]byte, cdFrameSize)
+	copy(frame, cdSyncHeader)
+	frame[15] = 1 // Mode
+	for i := 16; i < 0x810; i++ {
+		frame[i] = byte(i*n + n)
+	}
+	eccGenerate(frame)
+	for i := cdSectorSize; i < cdFrameSize; i++ {
+		frame[i] = byte(i + n)
+	}
+	return frame
+}
+
+// cdHunk compresses CD frames the way chdman's CD codecs do. Frames with
+// their bit set in ecc have the sync header and ECC dropped.
+func cdHunk(t *testing.T, frames []byte, ecc byte, sectors, subcode func(*testing.T, []byte) []byte) []byte {
+	t.Helper()
+	count := len(frames) / cdFrameSize
+	var sectorData, subcodeData []byte
+	for i := 0; i < count; i++ {
+		sector := bytes.Clone(frames[i*cdFrameSize : i*cdFrameSize+cdSectorSize])
+		if ecc&(1<<i) != 0 {
+			clear(sector[:len(cdSyncHeader)])
+			clear(sector[0x81C:])
+		}
+		sectorData = append(sectorData, sector...)
+		subcodeData = append(subcodeData, frames[i*cdFrameSize+cdSectorSize:(i+1)*cdFrameSize]...)
+	}
+	base := sectors(t, sectorData)
+	out := []byte{ecc, byte(len(base) >> 8), byte(len(base))}
+	out = append(out, base...)
+	return append(out, subcode(t, subcodeData)...)
+}
+
+func TestCHD_CD(t *testing.T) {
+	const hunkBytes = 2 * cdFrameSize
+	var data []byte
+	for i := range 6 {
+		data = append(data, testCDFrame(i)...)
+	}
+	hunk := func(i int) []byte { return data[i*hunkBytes : (i+1)*hunkBytes] }
+
+	hunks := []testCHDHunk{
+		{kind: chdCompressType0, payload: cdHunk(t, hunk(0), 0x01, deflateHunk, deflateHunk)},
+		{kind: chdCompressType1, payload: cdHunk(t, hunk(1), 0x03, lzmaHunk, deflateHunk)},
+		{kind: chdCompressType2, payload: cdHunk(t, hunk(2), 0x00, zstdHunk, zstdHunk)},
+	}
+	codecs := [4]uint32{chdCodecCDZlib, chdCodecCDLZMA, chdCodecCDZstd}
+	path := createTestCHD(t, data, hunkBytes, cdFrameSize, codecs, hunks)
+
+	got, err := LoadWith(path, nil, Options{MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("LoadWith failed: %v", err)
+	}
+	if !bytes.Equal(got.Data, data) {
+		t.Error("data mismatch")
+	}
+}
+
+func TestECCGenerate(t *testing.T) {
+	// The parity of an empty sector is zero; any change to the data
+	// changes both P and Q
+	sector := make([]byte, cdSectorSize)
+	eccGenerate(sector)
+	if !bytes.Equal(sector, make([]byte, cdSectorSize)) {
+		t.Error("ECC of an empty sector is not zero")
+	}
+
+	sector[0x10] = 1
+	eccGenerate(sector)
+	if bytes.Equal(sector[0x81C:0x8C8], make([]byte, 0x8C8-0x81C)) {
+		t.Error("P parity not set")
+	}
+	if bytes.Equal(sector[0x8C8:], make([]byte, cdSectorSize-0x8C8)) {
+		t.Error("Q parity not set")
+	}
+}
+
+func TestCHD_Open(t *testing.T) {
+	const hunkBytes = 4096
+	data := testCHDData(3 * hunkBytes)
+	hunks := []testCHDHunk{
+		{kind: chdCompressType0, payload: deflateHunk(t, data[:hunkBytes])},
+		{kind: chdCompressType0, payload: deflateHunk(t, data[hunkBytes:2*hunkBytes])},
+		{kind: chdCompressNone},
+	}
+	path := createTestCHD(t, data, hunkBytes, 512, [4]uint32{chdCodecZlib}, hunks)
+
+	rom, err := Open(path, testExtensions, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer rom.Close()
+
+	if _, ok := rom.r.(*chdReader); !ok {
+		t.Errorf("reader is %T, want *chdReader", rom.r)
+	}
+	if rom.Size != int64(len(data)) {
+		t.Errorf("Size = %d, want %d", rom.Size, len(data))
+	}
+
+	// Reads spanning hunks, out of order
+	for _, off := range []int{2*hunkBytes - 10, 100, hunkBytes - 5} {
+		buf := make([]byte, 20)
+		if _, err := rom.ReadAt(buf, int64(off)); err != nil {
+			t.Fatalf("ReadAt(%d) failed: %v", off, err)
+		}
+		if !bytes.Equal(buf, data[off:off+20]) {
+			t.Errorf("ReadAt(%d) mismatch", off)
+		}
+	}
+	buf := make([]byte, 20)
+	if n, err := rom.ReadAt(buf, int64(len(data)-10)); n != 10 || err == nil {
+		t.Errorf("ReadAt past end = %d, %v", n, err)
+	}
+}
+
+func TestCHD_List(t *testing.T) {
+	data := testCHDData(4096)
+	hunks := []testCHDHunk{{kind: chdCompressNone}}
+	path := createTestCHD(t, data, 4096, 512, [4]uint32{chdCodecZlib}, hunks)
+
+	entries, err := List(path, testExtensions)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "game.chd" || entries[0].Size != 4096 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	// The name inside the .chd is checked like a plain stream's content
+	isoPath := filepath.Join(filepath.Dir(path), "game.iso.chd")
+	if err := os.Rename(path, isoPath); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := List(isoPath, testExtensions); !errors.Is(err, ErrNoFile) {
+		t.Errorf("List game.iso.chd error = %v, want ErrNoFile", err)
+	}
+	if entries, err := List(isoPath, []string{".iso"}); err != nil || len(entries) != 1 {
+		t.Errorf("List game.iso.chd with .iso = %+v, %v", entries, err)
+	}
+
+	// A CHD holds only its image, never a patch
+	patches, err := FindPatches(isoPath, []string{".iso"})
+	if err != nil || len(patches) != 0 {
+		t.Errorf("FindPatches = %v, %v, want none", patches, err)
+	}
+}
+
+func TestCHD_Unsupported(t *testing.T) {
+	header := func(edit func([]byte)) string {
+		h := testCHDHeader(4096, 4096, 512, [4]uint32{chdCodecZlib}, chdHeaderSize)
+		edit(h)
+		return writeTestCHD(t, h)
+	}
+	paths := map[string]string{
+		"version 4": header(func(h []byte) { binary.BigEndian.PutUint32(h[12:], 4) }),
+		"flac":      header(func(h []byte) { copy(h[20:], "flac") }),
+		"parent":    header(func(h []byte) { h[110] = 1 }),
+	}
+
+	for name, path := range paths {
+		if _, _, err := Load(path, testExtensions); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("%s: expected ErrUnsupportedFormat, got %v", name, err)
+		}
+	}
+}
+
+func TestCHD_Corrupt(t *testing.T) {
+	data := testCHDData(4096)
+	hunks := []testCHDHunk{{kind: chdCompressNone}}
+	path := createTestCHD(t, data, 4096, 512, [4]uint32{chdCodecZlib}, hunks)
+
+	chd, _ := os.ReadFile(path)
+	chd[chdHeaderSize+10] ^= 0xff
+	os.WriteFile(path, chd, 0644)
+
+	if _, _, err := Load(path, testExtensions); !errors.Is(err, errCorruptCHD) {
+		t.Errorf("expected errCorruptCHD, got %v", err)
+	}
+}
+
+func TestCHD_HunkTooLong(t *testing.T) {
+	// Compressed hunks never exceed the hunk size plus a CD header
+	data := testCHDData(4096)
+	hunks := []testCHDHunk{{kind: chdCompressType0, payload: make([]byte, 2*4096)}}
+	path := createTestCHD(t, data, 4096, 512, [4]uint32{chdCodecZlib}, hunks)
+
+	if _, _, err := Load(path, testExtensions); !errors.Is(err, errCorruptCHD) {
+		t.Errorf("expected errCorruptCHD, got %v", err)
+	}
+}
+
+func TestCHD_MaxSize(t *testing.T) {
+	// The size check happens before the (missing) map is read
+	path := writeTestCHD(t, testCHDHeader(DefaultMaxSize+1, 4096, 512, [4]uint32{chdCodecZlib}, 1<<30))
+
+	if _, _, err := Load(path, testExtensions); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected ErrFileTooLarge, got %v", err)
+	}
+	if _, err := List(path, testExtensions); !errors.Is(err, ErrNoFile) {
+		t.Errorf("expected ErrNoFile, got %v", err)
+	}
+}
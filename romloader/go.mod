@@ -4,7 +4,10 @@ go 1.25.7
 
 require (
 	github.com/bodgit/sevenzip v1.6.1
+	github.com/klauspost/compress v1.17.11
 	github.com/nwaples/rardecode/v2 v2.2.2
+	github.com/pierrec/lz4/v4 v4.1.22
+	github.com/ulikunitz/xz v0.5.12
 )
 
 require (
@@ -12,10 +15,7 @@ require (
 	github.com/bodgit/plumbing v1.3.0 // indirect
 	github.com/bodgit/windows v1.0.1 // indirect
 	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
-	github.com/klauspost/compress v1.17.11 // indirect
-	github.com/pierrec/lz4/v4 v4.1.22 // indirect
 	github.com/spf13/afero v1.11.0 // indirect
-	github.com/ulikunitz/xz v0.5.12 // indirect
 	go4.org v0.0.0-20200411211856-f5505b9728dd // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
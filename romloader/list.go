@@ -0,0 +1,101 @@
+package romloader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// entrySeparator separates an archive path from an entry name.
+const entrySeparator = "#"
+
+// Entry is a ROM file found by List.
+type Entry struct {
+	Name     string // Path inside the archive, or the file name for raw ROMs
+	Size     int64  // Uncompressed size; 0 if unknown
+	CRC32    uint32 // CRC32 from the archive header, valid when HasCRC32
+	HasCRC32 bool
+}
+
+// List returns every ROM file in the archive at path, in archive order,
+// matching the given extensions the same way Load does. Raw ROM files
+// return a single entry without a CRC32. Entries larger than the ROM size
+// limit are left out since they can't be loaded.
+func List(path string, extensions []string) ([]Entry, error) {
+	return ListWith(path, extensions, Options{})
+}
+
+// ListWith lists ROM files like List, leaving out entries larger than
+// Options.MaxSize instead of the default limit.
+func ListWith(path string, extensions []string, opts Options) ([]Entry, error) {
+	format, err := openFormat(path, extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	switch format {
+	case formatRaw:
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat file: %w", err)
+		}
+		entries = []Entry{{Name: filepath.Base(path), Size: info.Size()}}
+	case formatZIP:
+		entries, err = listZIP(path, extensions)
+	case format7z:
+		entries, err = list7z(path, extensions)
+	case formatGzip, formatZstd, formatXz, formatBzip2, formatLz4:
+		entries, err = listStream(path, extensions, streamFormats[format])
+	case formatRAR:
+		entries, err = listRAR(path, extensions)
+	case formatCHD:
+		entries, err = listCHD(path, extensions)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.maxSize()
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Size <= limit {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, ErrNoFile
+	}
+	return kept, nil
+}
+
+// EntryPath returns the path Load uses to read one entry of an archive:
+// "archive#entry".
+func EntryPath(archive, entry string) string {
+	return archive + entrySeparator + entry
+}
+
+// splitEntryPath splits an "archive#entry" path. Paths that name an
+// existing file are never split, so files with '#' in their name still
+// load. The archive is the shortest prefix before a '#' that is an
+// existing regular file.
+func splitEntryPath(path string) (archive, entry string, ok bool) {
+	if !strings.Contains(path, entrySeparator) {
+		return "", "", false
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", "", false
+	}
+	for i := 0; i < len(path); i++ {
+		if !strings.HasPrefix(path[i:], entrySeparator) {
+			continue
+		}
+		if info, err := os.Stat(path[:i]); err == nil && info.Mode().IsRegular() {
+			return path[:i], path[i+len(entrySeparator):], true
+		}
+	}
+	return "", "", false
+}
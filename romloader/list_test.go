@@ -0,0 +1,219 @@
+package romloader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testFile is a named file written into a test archive
+type testFile struct {
+	name string
+	data []byte
+}
+
+// createTestMultiZip creates a .zip file containing the given files
+func createTestMultiZip(t *testing.T, files []testFile) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "set.zip")
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatalf("Failed to create %s in zip: %v", f.name, err)
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			t.Fatalf("Failed to write %s: %v", f.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return path
+}
+
+// multiROMFiles is a set with two revisions and a readme
+var multiROMFiles = []testFile{
+	{"Game (USA).sms", []byte("revision zero")},
+	{"readme.txt", []byte("not a rom")},
+	{"Game (USA) (Rev 1).sms", []byte("revision one!")},
+}
+
+// TestList_ZipMultipleROMs lists every ROM in a set with header CRCs
+func TestList_ZipMultipleROMs(t *testing.T) {
+	path := createTestMultiZip(t, multiROMFiles)
+
+	entries, err := List(path, testExtensions)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	for i, want := range []testFile{multiROMFiles[0], multiROMFiles[2]} {
+		e := entries[i]
+		if e.Name != want.name {
+			t.Errorf("entries[%d].Name = %q, want %q", i, e.Name, want.name)
+		}
+		if e.Size != int64(len(want.data)) {
+			t.Errorf("entries[%d].Size = %d, want %d", i, e.Size, len(want.data))
+		}
+		if !e.HasCRC32 || e.CRC32 != crc32.ChecksumIEEE(want.data) {
+			t.Errorf("entries[%d] CRC32 = %08x (has %v), want %08x", i, e.CRC32, e.HasCRC32, crc32.ChecksumIEEE(want.data))
+		}
+	}
+}
+
+// TestLoad_EntryPath loads a specific entry from an archive
+func TestLoad_EntryPath(t *testing.T) {
+	path := createTestMultiZip(t, multiROMFiles)
+
+	data, name, err := Load(EntryPath(path, "Game (USA) (Rev 1).sms"), testExtensions)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !bytes.Equal(data, multiROMFiles[2].data) {
+		t.Errorf("data = %q, want %q", data, multiROMFiles[2].data)
+	}
+	if name != "Game (USA) (Rev 1).sms" {
+		t.Errorf("name = %q", name)
+	}
+
+	// Without an entry the first ROM is still returned
+	data, _, err = Load(path, testExtensions)
+	if err != nil || !bytes.Equal(data, multiROMFiles[0].data) {
+		t.Errorf("Load(archive) = %q, %v; want first ROM", data, err)
+	}
+}
+
+// TestLoad_EntryPathMissingEntry reports an entry missing from the archive
+func TestLoad_EntryPathMissingEntry(t *testing.T) {
+	path := createTestMultiZip(t, multiROMFiles)
+
+	_, _, err := Load(EntryPath(path, "Other.sms"), testExtensions)
+	if !errors.Is(err, ErrNoFile) {
+		t.Errorf("expected ErrNoFile, got %v", err)
+	}
+}
+
+// TestLoad_HashInFileName loads files with '#' in their name as-is
+func TestLoad_HashInFileName(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Game #1.sms")
+	if err := os.WriteFile(path, []byte("rom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _, err := Load(path, testExtensions)
+	if err != nil || string(data) != "rom" {
+		t.Errorf("Load = %q, %v; want file with '#' loaded as-is", data, err)
+	}
+}
+
+// TestSplitEntryPath splits only at an existing archive
+func TestSplitEntryPath(t *testing.T) {
+	path := createTestMultiZip(t, multiROMFiles)
+
+	archive, entry, ok := splitEntryPath(EntryPath(path, "dir/Game #2.sms"))
+	if !ok || archive != path || entry != "dir/Game #2.sms" {
+		t.Errorf("splitEntryPath = %q, %q, %v", archive, entry, ok)
+	}
+
+	if _, _, ok := splitEntryPath(path); ok {
+		t.Error("plain path split")
+	}
+	if _, _, ok := splitEntryPath("/nonexistent/set.zip#Game.sms"); ok {
+		t.Error("missing archive split")
+	}
+}
+
+// TestList_RawROM returns a raw ROM as a single entry
+func TestList_RawROM(t *testing.T) {
+	path := createTestROMFile(t, []byte("raw rom"), ".sms")
+
+	entries, err := List(path, testExtensions)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "test.sms" || entries[0].Size != 7 || entries[0].HasCRC32 {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+// TestList_Gzip reads the CRC32 and size from the gzip trailer
+func TestList_Gzip(t *testing.T) {
+	romData := []byte("gzipped rom data")
+	path := createTestGzipFile(t, romData, ".sms")
+
+	entries, err := List(path, testExtensions)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Name != "test.sms" || e.Size != int64(len(romData)) {
+		t.Errorf("entry = %+v", e)
+	}
+	if !e.HasCRC32 || e.CRC32 != crc32.ChecksumIEEE(romData) {
+		t.Errorf("CRC32 = %08x, want %08x", e.CRC32, crc32.ChecksumIEEE(romData))
+	}
+}
+
+// TestList_TarGz lists tar.gz entries without CRCs and loads one by name
+func TestList_TarGz(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "set.tar.gz")
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range multiROMFiles {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(path, testExtensions)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Name != "Game (USA) (Rev 1).sms" || entries[1].HasCRC32 {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	data, _, err := Load(EntryPath(path, entries[1].Name), testExtensions)
+	if err != nil || !bytes.Equal(data, multiROMFiles[2].data) {
+		t.Errorf("Load(entry) = %q, %v", data, err)
+	}
+}
+
+// TestList_NoROMs reports archives without ROM files
+func TestList_NoROMs(t *testing.T) {
+	path := createTestMultiZip(t, []testFile{{"readme.txt", []byte("text")}})
+
+	_, err := List(path, testExtensions)
+	if !errors.Is(err, ErrNoFile) {
+		t.Errorf("expected ErrNoFile, got %v", err)
+	}
+}
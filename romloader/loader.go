@@ -1,5 +1,6 @@
 // Package romloader handles loading ROM files from various sources,
-// including compressed archives (ZIP, 7z, gzip, tar.gz, RAR).
+// including compressed archives (ZIP, 7z, RAR), compressed streams and
+// tarballs (gzip, zstd, xz, bzip2, lz4) and CHD disc images.
 package romloader
 
 import (
@@ -19,11 +20,19 @@ var (
 	magic7z     = []byte{0x37, 0x7A, 0xBC, 0xAF, 0x27, 0x1C}
 	magicGzip   = []byte{0x1F, 0x8B}
 	magicRAR    = []byte{0x52, 0x61, 0x72, 0x21} // "Rar!"
+	magicZstd   = []byte{0x28, 0xB5, 0x2F, 0xFD}
+	magicXz     = []byte{0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00}
+	magicBzip2  = []byte{0x42, 0x5A, 0x68} // "BZh", then the block size '1'-'9'
+	magicLz4    = []byte{0x04, 0x22, 0x4D, 0x18}
+	magicCHD    = []byte("MComprHD")
 )
 
 // Maximum ROM size (8MB safety limit)
 const maxROMSize = 8 * 1024 * 1024
 
+// DefaultMaxSize is the ROM size limit used when Options.MaxSize is 0.
+const DefaultMaxSize = maxROMSize
+
 // ErrNoFile is returned when no matching file is found in an archive
 var ErrNoFile = errors.New("no matching file found in archive")
 
@@ -43,63 +52,179 @@ const (
 	format7z
 	formatGzip
 	formatRAR
+	formatZstd
+	formatXz
+	formatBzip2
+	formatLz4
+	formatCHD
 )
 
+// matchFunc selects which archive file to extract by its name in the
+// archive.
+type matchFunc func(name string) bool
+
+// romMatch matches files with one of the given ROM extensions.
+func romMatch(extensions []string) matchFunc {
+	return func(name string) bool {
+		return isROMFile(name, extensions)
+	}
+}
+
 // Load reads a ROM from a file path. It auto-detects compressed archives
 // via magic bytes and extracts the first file matching one of the given
 // extensions. For raw (non-archive) files, the extension must match or
 // the file is loaded as-is if no archive format is detected.
 //
+// A path of the form "archive#entry" (see EntryPath) loads that entry
+// from the archive instead of the first match.
+//
 // Returns the ROM data, the filename (basename only, useful for display),
 // and any error.
 func Load(path string, extensions []string) ([]byte, string, error) {
-	f, err := os.Open(path)
+	res, err := LoadWith(path, extensions, Options{})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+		return nil, "", err
 	}
-	defer f.Close()
+	return res.Data, res.Name, nil
+}
 
-	// Read header for magic byte detection
-	header := make([]byte, 16)
-	n, err := f.Read(header)
-	if err != nil && err != io.EOF {
-		return nil, "", fmt.Errorf("failed to read file header: %w", err)
+// LoadWith reads a ROM like Load with options for the size limit,
+// hashing while reading, normalization and patching. With Discard set and
+// no patches or normalization the ROM is hashed in one streaming pass
+// without being kept in memory. Otherwise hashes are of the final ROM.
+func LoadWith(path string, extensions []string, opts Options) (*Result, error) {
+	e, err := openEntry(path, extensions)
+	if err != nil {
+		return nil, err
+	}
+	defer e.Close()
+
+	limit := opts.maxSize()
+	if e.size > limit {
+		return nil, ErrFileTooLarge
 	}
-	header = header[:n]
 
-	// Detect format
-	format := detectFormat(header, path, extensions)
+	h := newHasher(opts.Hashes)
+	res := &Result{Name: e.name}
 
-	// Reset file position
-	if _, err := f.Seek(0, io.SeekStart); err != nil {
-		return nil, "", fmt.Errorf("failed to seek file: %w", err)
+	// When the data read is the final ROM, hash as it streams
+	var r io.Reader = e
+	if opts.streams() {
+		r = io.TeeReader(e, h)
 	}
 
-	switch format {
-	case formatRaw:
-		data, err := limitedRead(f)
+	if opts.Discard && opts.streams() {
+		n, err := io.Copy(io.Discard, io.LimitReader(r, limit+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.name, err)
+		}
+		if n > limit {
+			return nil, ErrFileTooLarge
+		}
+		res.Size = n
+	} else {
+		data, err := limitedRead(r, limit)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to read ROM: %w", err)
+			return nil, fmt.Errorf("failed to read %s: %w", e.name, err)
+		}
+		if opts.Normalize != nil {
+			data, res.Normalized = opts.Normalize(strings.ToLower(filepath.Ext(e.name)), data)
+		}
+		if len(opts.Patches) > 0 {
+			if data, err = applyPatchFiles(data, opts.Patches, limit); err != nil {
+				return nil, err
+			}
+		}
+		if !opts.streams() {
+			h.Write(data)
+		}
+		res.Size = int64(len(data))
+		if !opts.Discard {
+			res.Data = data
 		}
-		return data, filepath.Base(path), nil
+	}
 
-	case formatZIP:
-		return extractFromZIP(path, extensions)
+	h.sum(res)
+	return res, nil
+}
 
-	case format7z:
-		return extractFrom7z(path, extensions)
+// Open opens a ROM for random access. Raw files, ZIP entries stored
+// without compression and CHD images are read from disk on demand, so
+// large ROMs aren't held in memory; other compressed entries are
+// decompressed into memory. Paths are resolved like Load. Only
+// Options.MaxSize is used. The ROM must be closed.
+func Open(path string, extensions []string, opts Options) (*ROM, error) {
+	e, err := openEntry(path, extensions)
+	if err != nil {
+		return nil, err
+	}
 
-	case formatGzip:
-		return extractFromGzip(path, extensions)
+	limit := opts.maxSize()
+	if e.size > limit {
+		e.Close()
+		return nil, ErrFileTooLarge
+	}
+	if e.at != nil && e.size >= 0 {
+		return &ROM{Name: e.name, Size: e.size, r: e.at, closer: e}, nil
+	}
 
-	case formatRAR:
-		return extractFromRAR(path, extensions)
+	defer e.Close()
+	data, err := limitedRead(e, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", e.name, err)
+	}
+	return &ROM{Name: e.name, Size: int64(len(data)), r: bytes.NewReader(data)}, nil
+}
+
+// openEntry opens the ROM at path, resolving "archive#entry" paths and
+// detecting archives.
+func openEntry(path string, extensions []string) (*entryReader, error) {
+	match := romMatch(extensions)
+	if archive, entry, ok := splitEntryPath(path); ok {
+		path = archive
+		match = func(name string) bool { return name == entry }
+	}
+
+	format, err := openFormat(path, extensions)
+	if err != nil {
+		return nil, err
+	}
 
+	switch format {
+	case formatRaw:
+		return openRaw(path)
+	case formatZIP:
+		return openZIP(path, match)
+	case format7z:
+		return open7z(path, match)
+	case formatGzip, formatZstd, formatXz, formatBzip2, formatLz4:
+		return openStream(path, match, streamFormats[format])
+	case formatRAR:
+		return openRAR(path, match)
+	case formatCHD:
+		return openCHD(path)
 	default:
-		return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
 	}
 }
 
+// openFormat reads the header of the file at path and detects its format.
+func openFormat(path string, extensions []string) (formatType, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return formatUnknown, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	// Read header for magic byte detection
+	header := make([]byte, 16)
+	n, err := f.Read(header)
+	if err != nil && err != io.EOF {
+		return formatUnknown, fmt.Errorf("failed to read file header: %w", err)
+	}
+	return detectFormat(header[:n], path, extensions), nil
+}
+
 // detectFormat determines the file format based on magic bytes and extension.
 // The extensions parameter lists valid ROM file extensions (e.g. []string{".sms"}).
 func detectFormat(header []byte, path string, extensions []string) formatType {
@@ -113,9 +238,26 @@ func detectFormat(header []byte, path string, extensions []string) formatType {
 		if bytes.HasPrefix(header, magicRAR) {
 			return formatRAR
 		}
+		if bytes.HasPrefix(header, magicZstd) {
+			return formatZstd
+		}
+		if bytes.HasPrefix(header, magicLz4) {
+			return formatLz4
+		}
+		if bytes.HasPrefix(header, magicBzip2) && header[3] >= '1' && header[3] <= '9' {
+			return formatBzip2
+		}
 	}
-	if len(header) >= 6 && bytes.HasPrefix(header, magic7z) {
-		return format7z
+	if len(header) >= 6 {
+		if bytes.HasPrefix(header, magic7z) {
+			return format7z
+		}
+		if bytes.HasPrefix(header, magicXz) {
+			return formatXz
+		}
+	}
+	if len(header) >= 8 && bytes.HasPrefix(header, magicCHD) {
+		return formatCHD
 	}
 	if len(header) >= 2 && bytes.HasPrefix(header, magicGzip) {
 		return formatGzip
@@ -131,6 +273,16 @@ func detectFormat(header []byte, path string, extensions []string) formatType {
 		return formatGzip
 	case ".rar":
 		return formatRAR
+	case ".zst", ".tzst":
+		return formatZstd
+	case ".xz", ".txz":
+		return formatXz
+	case ".bz2", ".tbz2", ".tbz":
+		return formatBzip2
+	case ".lz4":
+		return formatLz4
+	case ".chd":
+		return formatCHD
 	}
 
 	// Check for .tar.gz
@@ -169,22 +321,22 @@ func isROMFile(name string, extensions []string) bool {
 }
 
 // LoadBIOS reads a BIOS file from the given path, supporting the same archive
-// formats as Load (ZIP, 7z, gzip/tar, RAR). Unlike Load, it does not require
-// the contained file to have a specific extension - the first non-directory
-// file in an archive is returned.
+// formats as Load (ZIP, 7z, RAR, compressed tarballs). Unlike Load, it does
+// not require the contained file to have a specific extension - the first
+// non-directory file in an archive is returned.
 func LoadBIOS(path string) ([]byte, error) {
 	data, _, err := Load(path, nil)
 	return data, err
 }
 
-// limitedRead reads from r up to maxROMSize bytes, returning an error if exceeded
-func limitedRead(r io.Reader) ([]byte, error) {
-	lr := io.LimitReader(r, maxROMSize+1)
+// limitedRead reads from r up to limit bytes, returning an error if exceeded
+func limitedRead(r io.Reader, limit int64) ([]byte, error) {
+	lr := io.LimitReader(r, limit+1)
 	data, err := io.ReadAll(lr)
 	if err != nil {
 		return nil, err
 	}
-	if len(data) > maxROMSize {
+	if int64(len(data)) > limit {
 		return nil, ErrFileTooLarge
 	}
 	return data, nil
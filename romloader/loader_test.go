@@ -1,12 +1,19 @@
 package romloader
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
 )
 
 // testExtensions is a common set of ROM extensions used across tests
@@ -71,6 +78,65 @@ func createTestGzipFile(t *testing.T, romData []byte, ext string) string {
 	return path
 }
 
+// streamWriters create compressing writers for the stream formats Go can
+// write. bzip2 has no writer; see testBzip2.
+var streamWriters = map[string]func(w io.Writer) (io.WriteCloser, error){
+	".zst": func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) },
+	".xz":  func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) },
+	".lz4": func(w io.Writer) (io.WriteCloser, error) { return lz4.NewWriter(w), nil },
+}
+
+// testBzip2 is "bzip2 ROM data" compressed with bzip2 -9
+var testBzip2 = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0xd8, 0xb8,
+	0x8a, 0xa8, 0x00, 0x00, 0x04, 0x1f, 0x80, 0x40, 0x00, 0x10, 0x00, 0x00,
+	0x02, 0x90, 0x00, 0x34, 0x20, 0x44, 0x10, 0x20, 0x00, 0x22, 0x06, 0x80,
+	0x68, 0x40, 0xd0, 0x34, 0x19, 0xf6, 0x26, 0x7b, 0x70, 0x12, 0x0f, 0x8b,
+	0xb9, 0x22, 0x9c, 0x28, 0x48, 0x6c, 0x5c, 0x45, 0x54, 0x00,
+}
+
+// createTestStreamFile creates a temporary file named name holding data
+// compressed with the writer for ext
+func createTestStreamFile(t *testing.T, data []byte, name, ext string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := streamWriters[ext](&buf)
+	if err != nil {
+		t.Fatalf("Failed to create %s writer: %v", ext, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("Failed to write %s: %v", ext, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close %s writer: %v", ext, err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// createTestTar returns a tar archive of the given files
+func createTestTar(t *testing.T, files []testFile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
 // TestLoad_RawROM tests loading plain ROM files
 func TestLoad_RawROM(t *testing.T) {
 	testData := []byte{0x01, 0x02, 0x03, 0x04, 0x05}
@@ -144,6 +210,87 @@ func TestLoad_GzipFile(t *testing.T) {
 	}
 }
 
+// TestLoad_StreamFiles tests loading ROMs from plain zstd, xz and lz4 files
+func TestLoad_StreamFiles(t *testing.T) {
+	testData := []byte("stream ROM data")
+	for ext := range streamWriters {
+		t.Run(ext, func(t *testing.T) {
+			path := createTestStreamFile(t, testData, "game.sms"+ext, ext)
+
+			data, filename, err := Load(path, testExtensions)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if !bytes.Equal(data, testData) {
+				t.Errorf("Data mismatch: expected %q, got %q", testData, data)
+			}
+			if filename != "game.sms" {
+				t.Errorf("filename = %q, want %q", filename, "game.sms")
+			}
+		})
+	}
+}
+
+// TestLoad_Bzip2File tests loading a ROM from a bzip2 file
+func TestLoad_Bzip2File(t *testing.T) {
+	path := createTestROMFile(t, testBzip2, ".sms.bz2")
+
+	data, filename, err := Load(path, testExtensions)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if string(data) != "bzip2 ROM data" {
+		t.Errorf("Data mismatch: got %q", data)
+	}
+	if filename != "test.sms" {
+		t.Errorf("filename = %q, want %q", filename, "test.sms")
+	}
+}
+
+// TestLoad_StreamTarballs tests loading the first ROM in compressed tarballs
+func TestLoad_StreamTarballs(t *testing.T) {
+	archive := createTestTar(t, []testFile{
+		{"readme.txt", []byte("not a ROM")},
+		{"roms/game.sms", []byte("tarball ROM")},
+		{"roms/other.sms", []byte("second ROM")},
+	})
+	names := map[string]string{
+		".zst": "set.tar.zst",
+		".xz":  "set.txz",
+		".lz4": "set.tar.lz4",
+	}
+
+	for ext, name := range names {
+		t.Run(name, func(t *testing.T) {
+			path := createTestStreamFile(t, archive, name, ext)
+
+			data, filename, err := Load(path, testExtensions)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if string(data) != "tarball ROM" || filename != "game.sms" {
+				t.Errorf("got %q from %q", data, filename)
+			}
+
+			entries, err := List(path, testExtensions)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(entries) != 2 || entries[1].Name != "roms/other.sms" {
+				t.Fatalf("entries = %+v", entries)
+			}
+			data, _, err = Load(EntryPath(path, entries[1].Name), testExtensions)
+			if err != nil || string(data) != "second ROM" {
+				t.Errorf("Load entry = %q, %v", data, err)
+			}
+
+			if _, _, err := Load(path, []string{".gg"}); !errors.Is(err, ErrNoFile) {
+				t.Errorf("expected ErrNoFile, got %v", err)
+			}
+		})
+	}
+}
+
 // TestLoad_FormatDetectionMagic tests detection via magic bytes
 func TestLoad_FormatDetectionMagic(t *testing.T) {
 	testCases := []struct {
@@ -156,6 +303,12 @@ func TestLoad_FormatDetectionMagic(t *testing.T) {
 		{[]byte{0x37, 0x7A, 0xBC, 0xAF, 0x27, 0x1C}, "file.dat", format7z},
 		{[]byte{0x1F, 0x8B}, "file.dat", formatGzip},
 		{[]byte{0x52, 0x61, 0x72, 0x21}, "file.dat", formatRAR},
+		{[]byte{0x28, 0xB5, 0x2F, 0xFD}, "file.dat", formatZstd},
+		{[]byte{0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00}, "file.dat", formatXz},
+		{[]byte("BZh9"), "file.dat", formatBzip2},
+		{[]byte{0x04, 0x22, 0x4D, 0x18}, "file.dat", formatLz4},
+		{[]byte("MComprHD"), "file.dat", formatCHD},
+		{[]byte("BZhx"), "file.sms", formatRaw},
 	}
 
 	for _, tc := range testCases {
@@ -181,6 +334,15 @@ func TestLoad_FormatDetectionExtension(t *testing.T) {
 		{"game.tgz", formatGzip},
 		{"game.tar.gz", formatGzip},
 		{"game.rar", formatRAR},
+		{"game.zst", formatZstd},
+		{"game.tar.zst", formatZstd},
+		{"game.tzst", formatZstd},
+		{"game.xz", formatXz},
+		{"game.txz", formatXz},
+		{"game.bz2", formatBzip2},
+		{"game.tbz2", formatBzip2},
+		{"game.lz4", formatLz4},
+		{"game.chd", formatCHD},
 		{"game.unknown", formatUnknown},
 	}
 
@@ -399,6 +561,11 @@ func TestLoad_MagicBytesDefinition(t *testing.T) {
 	if !bytes.Equal(magicRAR, []byte{0x52, 0x61, 0x72, 0x21}) {
 		t.Error("RAR magic bytes incorrect")
 	}
+
+	// CHD magic: "MComprHD"
+	if !bytes.Equal(magicCHD, []byte{0x4D, 0x43, 0x6F, 0x6D, 0x70, 0x72, 0x48, 0x44}) {
+		t.Error("CHD magic bytes incorrect")
+	}
 }
 
 // TestLoad_UnsupportedExtension tests that unsupported extensions return error
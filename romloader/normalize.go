@@ -0,0 +1,79 @@
+package romloader
+
+import (
+	"bytes"
+	"strings"
+)
+
+// copierHeaderSize is the size of the header added by ROM copiers such
+// as the Super Magic Drive and Super Wild Card.
+const copierHeaderSize = 512
+
+// smdBlockSize is the size of an interleaved Super Magic Drive block.
+const smdBlockSize = 16 * 1024
+
+// Normalizer converts a ROM dump to the form ROM databases hash. It
+// returns the converted data and a short description of the change, or
+// the data unchanged and "" when the dump is already in that form.
+type Normalizer func(data []byte) ([]byte, string)
+
+// Normalizers maps lowercase file extensions, including the dot, to the
+// normalizers run on ROMs with that extension, in order. Cores build one
+// for their formats and use Normalize to implement
+// coreif.ROMNormalizer.
+type Normalizers map[string][]Normalizer
+
+// Normalize runs the normalizers registered for ext on data. The
+// descriptions of the changes applied are joined with ", ".
+func (n Normalizers) Normalize(ext string, data []byte) ([]byte, string) {
+	var applied []string
+	for _, fn := range n[strings.ToLower(ext)] {
+		var desc string
+		data, desc = fn(data)
+		if desc != "" {
+			applied = append(applied, desc)
+		}
+	}
+	return data, strings.Join(applied, ", ")
+}
+
+// StripCopierHeader returns a Normalizer that removes the 512-byte header
+// copier devices put in front of dumps. A header is detected when the
+// size is 512 bytes over a multiple of blockSize.
+func StripCopierHeader(blockSize int) Normalizer {
+	return func(data []byte) ([]byte, string) {
+		if len(data) <= copierHeaderSize || len(data)%blockSize != copierHeaderSize {
+			return data, ""
+		}
+		return data[copierHeaderSize:], "removed copier header"
+	}
+}
+
+// DeinterleaveSMD converts a Super Magic Drive dump to a plain Genesis
+// ROM. SMD files have a 512-byte header followed by 16KB blocks holding
+// the odd bytes of the block in the first half and the even bytes in the
+// second. Dumps are recognised by the header's 0xAA 0xBB signature or by
+// the "SEGA" system name appearing once deinterleaved.
+func DeinterleaveSMD(data []byte) ([]byte, string) {
+	if len(data) <= copierHeaderSize || (len(data)-copierHeaderSize)%smdBlockSize != 0 {
+		return data, ""
+	}
+
+	body := data[copierHeaderSize:]
+	out := make([]byte, len(body))
+	half := smdBlockSize / 2
+	for block := 0; block < len(body); block += smdBlockSize {
+		src := body[block : block+smdBlockSize]
+		dst := out[block : block+smdBlockSize]
+		for i := 0; i < half; i++ {
+			dst[i*2] = src[half+i]
+			dst[i*2+1] = src[i]
+		}
+	}
+
+	signed := data[8] == 0xAA && data[9] == 0xBB
+	if !signed && !bytes.HasPrefix(out[0x100:], []byte("SEGA")) {
+		return data, ""
+	}
+	return out, "deinterleaved SMD"
+}
@@ -0,0 +1,128 @@
+package romloader
+
+import (
+	"bytes"
+	"hash/crc32"
+	"path/filepath"
+	"testing"
+)
+
+// testGenesisROM returns a 32KB ROM with the Genesis system name at 0x100
+func testGenesisROM() []byte {
+	rom := make([]byte, 2*smdBlockSize)
+	for i := range rom {
+		rom[i] = byte(i * 7)
+	}
+	copy(rom[0x100:], "SEGA GENESIS")
+	return rom
+}
+
+// interleaveSMD converts a plain ROM to SMD format
+func interleaveSMD(rom []byte, signed bool) []byte {
+	out := make([]byte, copierHeaderSize+len(rom))
+	if signed {
+		out[8], out[9] = 0xAA, 0xBB
+	}
+	half := smdBlockSize / 2
+	for block := 0; block < len(rom); block += smdBlockSize {
+		dst := out[copierHeaderSize+block:]
+		for i := 0; i < half; i++ {
+			dst[i] = rom[block+i*2+1]
+			dst[half+i] = rom[block+i*2]
+		}
+	}
+	return out
+}
+
+func TestDeinterleaveSMD(t *testing.T) {
+	rom := testGenesisROM()
+	for _, signed := range []bool{true, false} {
+		got, desc := DeinterleaveSMD(interleaveSMD(rom, signed))
+		if !bytes.Equal(got, rom) {
+			t.Errorf("signed=%v: deinterleaved ROM does not match", signed)
+		}
+		if desc == "" {
+			t.Errorf("signed=%v: no description", signed)
+		}
+	}
+}
+
+func TestDeinterleaveSMD_PlainROM(t *testing.T) {
+	rom := testGenesisROM()
+	got, desc := DeinterleaveSMD(rom)
+	if !bytes.Equal(got, rom) || desc != "" {
+		t.Errorf("plain ROM changed: desc = %q", desc)
+	}
+
+	// Right size but neither signed nor a Genesis ROM once deinterleaved
+	headered := append(make([]byte, copierHeaderSize), rom...)
+	copy(headered[copierHeaderSize+0x100:], "ABCD")
+	if _, desc := DeinterleaveSMD(headered); desc != "" {
+		t.Errorf("unsigned non-SMD data converted: desc = %q", desc)
+	}
+}
+
+func TestStripCopierHeader(t *testing.T) {
+	strip := StripCopierHeader(1024)
+
+	rom := bytes.Repeat([]byte{0x42}, 4096)
+	headered := append(make([]byte, copierHeaderSize), rom...)
+	got, desc := strip(headered)
+	if !bytes.Equal(got, rom) || desc == "" {
+		t.Errorf("header not removed: len = %d, desc = %q", len(got), desc)
+	}
+
+	got, desc = strip(rom)
+	if len(got) != len(rom) || desc != "" {
+		t.Errorf("headerless ROM changed: len = %d, desc = %q", len(got), desc)
+	}
+}
+
+func TestNormalizers(t *testing.T) {
+	n := Normalizers{
+		".smd": {DeinterleaveSMD},
+		".md":  {StripCopierHeader(smdBlockSize)},
+	}
+	rom := testGenesisROM()
+
+	got, desc := n.Normalize(".SMD", interleaveSMD(rom, true))
+	if !bytes.Equal(got, rom) || desc != "deinterleaved SMD" {
+		t.Errorf(".smd: desc = %q", desc)
+	}
+
+	got, desc = n.Normalize(".md", append(make([]byte, copierHeaderSize), rom...))
+	if !bytes.Equal(got, rom) || desc != "removed copier header" {
+		t.Errorf(".md: desc = %q", desc)
+	}
+
+	got, desc = n.Normalize(".bin", interleaveSMD(rom, true))
+	if len(got) != len(rom)+copierHeaderSize || desc != "" {
+		t.Errorf(".bin: unregistered extension changed, desc = %q", desc)
+	}
+}
+
+func TestLoadWith_Normalize(t *testing.T) {
+	rom := testGenesisROM()
+	path := createTestMultiZip(t, []testFile{{"game.smd", interleaveSMD(rom, true)}})
+	n := Normalizers{".smd": {DeinterleaveSMD}}
+
+	res, err := LoadWith(path, []string{".smd"}, Options{
+		Hashes:    HashCRC32,
+		Normalize: n.Normalize,
+	})
+	if err != nil {
+		t.Fatalf("LoadWith failed: %v", err)
+	}
+	if !bytes.Equal(res.Data, rom) {
+		t.Error("data was not normalized")
+	}
+	if res.CRC32 != crc32.ChecksumIEEE(rom) {
+		t.Errorf("CRC32 = %08x, want CRC32 of the normalized ROM", res.CRC32)
+	}
+	if res.Normalized != "deinterleaved SMD" {
+		t.Errorf("Normalized = %q", res.Normalized)
+	}
+	if filepath.Ext(res.Name) != ".smd" {
+		t.Errorf("Name = %q", res.Name)
+	}
+}
@@ -0,0 +1,127 @@
+package romloader
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Hash selects the checksums LoadWith computes while reading.
+type Hash int
+
+const (
+	HashCRC32 Hash = 1 << iota
+	HashMD5
+	HashSHA1
+)
+
+// Options configures LoadWith and Open. The zero value loads like Load.
+type Options struct {
+	MaxSize int64    // Largest ROM accepted in bytes; 0 uses DefaultMaxSize
+	Hashes  Hash     // Checksums to compute while reading
+	Discard bool     // Only hash; Result.Data is nil
+	Patches []string // Patch files applied in order after normalization
+
+	// Normalize converts the ROM to its canonical form before patching
+	// and hashing, given the ROM's lowercase file extension. It returns a
+	// description of the change, or "" if the ROM was unchanged. Usually
+	// a core's coreif.ROMNormalizer.NormalizeROM.
+	Normalize func(ext string, data []byte) ([]byte, string)
+}
+
+// streams reports whether LoadWith can hash the ROM as it is read, which
+// requires the data read to be the final ROM.
+func (o Options) streams() bool {
+	return len(o.Patches) == 0 && o.Normalize == nil
+}
+
+// maxSize returns the size limit, applying the default.
+func (o Options) maxSize() int64 {
+	if o.MaxSize <= 0 {
+		return DefaultMaxSize
+	}
+	return o.MaxSize
+}
+
+// Result is a ROM read by LoadWith.
+type Result struct {
+	Data  []byte // nil when Options.Discard is set
+	Name  string // Filename (basename only)
+	Size  int64  // Size in bytes, after normalization and patching
+	CRC32 uint32 // Set with HashCRC32
+	MD5   string // Lowercase hex, set with HashMD5
+	SHA1  string // Lowercase hex, set with HashSHA1
+
+	Normalized string // Change made by Options.Normalize; "" if none
+}
+
+// ROM is a ROM opened for random access by Open.
+type ROM struct {
+	Name string // Filename (basename only)
+	Size int64
+
+	r      io.ReaderAt
+	closer io.Closer
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *ROM) ReadAt(p []byte, off int64) (int, error) {
+	return r.r.ReadAt(p, off)
+}
+
+// Close releases the files backing the ROM.
+func (r *ROM) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// hasher computes the requested checksums of everything written to it.
+type hasher struct {
+	crc  hash.Hash32
+	md5  hash.Hash
+	sha1 hash.Hash
+	w    io.Writer
+}
+
+// newHasher returns a hasher for the given checksums.
+func newHasher(hashes Hash) *hasher {
+	h := &hasher{}
+	var writers []io.Writer
+	if hashes&HashCRC32 != 0 {
+		h.crc = crc32.NewIEEE()
+		writers = append(writers, h.crc)
+	}
+	if hashes&HashMD5 != 0 {
+		h.md5 = md5.New()
+		writers = append(writers, h.md5)
+	}
+	if hashes&HashSHA1 != 0 {
+		h.sha1 = sha1.New()
+		writers = append(writers, h.sha1)
+	}
+	h.w = io.MultiWriter(writers...)
+	return h
+}
+
+// Write implements io.Writer.
+func (h *hasher) Write(p []byte) (int, error) {
+	return h.w.Write(p)
+}
+
+// sum stores the checksums in res.
+func (h *hasher) sum(res *Result) {
+	if h.crc != nil {
+		res.CRC32 = h.crc.Sum32()
+	}
+	if h.md5 != nil {
+		res.MD5 = hex.EncodeToString(h.md5.Sum(nil))
+	}
+	if h.sha1 != nil {
+		res.SHA1 = hex.EncodeToString(h.sha1.Sum(nil))
+	}
+}
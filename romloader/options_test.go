@@ -0,0 +1,226 @@
+package romloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// hashData is hashed by the LoadWith tests
+var hashData = []byte("The quick brown fox jumps over the lazy dog")
+
+// Known checksums of hashData
+const (
+	hashDataCRC32 = 0x414fa339
+	hashDataMD5   = "9e107d9d372bb6826bd81d3542a419d6"
+	hashDataSHA1  = "2fd4e1c67a2d28fced849ee1bb76e7391b93eb12"
+)
+
+// createTestStoredZip creates a .zip file with the ROM stored uncompressed
+func createTestStoredZip(t *testing.T, romData []byte, romName string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stored.zip")
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	fw, err := w.CreateHeader(&zip.FileHeader{Name: romName, Method: zip.Store})
+	if err != nil {
+		t.Fatalf("Failed to create file in zip: %v", err)
+	}
+	if _, err := fw.Write(romData); err != nil {
+		t.Fatalf("Failed to write to zip: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	return path
+}
+
+func TestLoadWith_Hashes(t *testing.T) {
+	paths := map[string]string{
+		"raw":  createTestROMFile(t, hashData, ".sms"),
+		"zip":  createTestZipFile(t, hashData, "game.sms"),
+		"gzip": createTestGzipFile(t, hashData, ".sms"),
+	}
+
+	for name, path := range paths {
+		t.Run(name, func(t *testing.T) {
+			res, err := LoadWith(path, testExtensions, Options{Hashes: HashCRC32 | HashMD5 | HashSHA1})
+			if err != nil {
+				t.Fatalf("LoadWith failed: %v", err)
+			}
+			if !bytes.Equal(res.Data, hashData) {
+				t.Errorf("Data = %q, want %q", res.Data, hashData)
+			}
+			if res.Size != int64(len(hashData)) {
+				t.Errorf("Size = %d, want %d", res.Size, len(hashData))
+			}
+			if res.CRC32 != hashDataCRC32 {
+				t.Errorf("CRC32 = %08x, want %08x", res.CRC32, hashDataCRC32)
+			}
+			if res.MD5 != hashDataMD5 {
+				t.Errorf("MD5 = %s, want %s", res.MD5, hashDataMD5)
+			}
+			if res.SHA1 != hashDataSHA1 {
+				t.Errorf("SHA1 = %s, want %s", res.SHA1, hashDataSHA1)
+			}
+		})
+	}
+}
+
+func TestLoadWith_OnlyRequestedHashes(t *testing.T) {
+	path := createTestROMFile(t, hashData, ".sms")
+
+	res, err := LoadWith(path, testExtensions, Options{Hashes: HashMD5})
+	if err != nil {
+		t.Fatalf("LoadWith failed: %v", err)
+	}
+	if res.CRC32 != 0 || res.SHA1 != "" {
+		t.Errorf("unrequested hashes set: CRC32 = %08x, SHA1 = %q", res.CRC32, res.SHA1)
+	}
+	if res.MD5 != hashDataMD5 {
+		t.Errorf("MD5 = %s, want %s", res.MD5, hashDataMD5)
+	}
+}
+
+func TestLoadWith_Discard(t *testing.T) {
+	path := createTestZipFile(t, hashData, "game.sms")
+
+	res, err := LoadWith(path, testExtensions, Options{Hashes: HashCRC32, Discard: true})
+	if err != nil {
+		t.Fatalf("LoadWith failed: %v", err)
+	}
+	if res.Data != nil {
+		t.Error("Data should be nil when discarding")
+	}
+	if res.Size != int64(len(hashData)) || res.CRC32 != hashDataCRC32 {
+		t.Errorf("Size = %d, CRC32 = %08x", res.Size, res.CRC32)
+	}
+	if res.Name != "game.sms" {
+		t.Errorf("Name = %q, want %q", res.Name, "game.sms")
+	}
+}
+
+func TestLoadWith_MaxSize(t *testing.T) {
+	data := make([]byte, DefaultMaxSize+1)
+	path := createTestROMFile(t, data, ".sms")
+
+	if _, err := LoadWith(path, testExtensions, Options{}); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("default limit: expected ErrFileTooLarge, got %v", err)
+	}
+
+	res, err := LoadWith(path, testExtensions, Options{MaxSize: 2 * DefaultMaxSize})
+	if err != nil {
+		t.Fatalf("raised limit: LoadWith failed: %v", err)
+	}
+	if len(res.Data) != len(data) {
+		t.Errorf("len = %d, want %d", len(res.Data), len(data))
+	}
+
+	small := createTestGzipFile(t, hashData, ".sms")
+	if _, err := LoadWith(small, testExtensions, Options{MaxSize: 10, Discard: true}); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("lowered limit: expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestLoadWith_PatchesHashPatchedROM(t *testing.T) {
+	dir := t.TempDir()
+	romPath := filepath.Join(dir, "game.sms")
+	patchPath := filepath.Join(dir, "game.bps")
+	os.WriteFile(romPath, bpsSource, 0644)
+	os.WriteFile(patchPath, createBPSPatch(), 0644)
+
+	res, err := LoadWith(romPath, testExtensions, Options{
+		Hashes:  HashCRC32,
+		Discard: true,
+		Patches: []string{patchPath},
+	})
+	if err != nil {
+		t.Fatalf("LoadWith failed: %v", err)
+	}
+	if res.Data != nil {
+		t.Error("Data should be nil when discarding")
+	}
+	if want := crc32.ChecksumIEEE(bpsTarget); res.CRC32 != want {
+		t.Errorf("CRC32 = %08x, want %08x", res.CRC32, want)
+	}
+	if res.Size != int64(len(bpsTarget)) {
+		t.Errorf("Size = %d, want %d", res.Size, len(bpsTarget))
+	}
+}
+
+func TestOpen(t *testing.T) {
+	paths := map[string]string{
+		"raw":        createTestROMFile(t, hashData, ".sms"),
+		"stored zip": createTestStoredZip(t, hashData, "game.sms"),
+		"zip":        createTestZipFile(t, hashData, "game.sms"),
+		"gzip":       createTestGzipFile(t, hashData, ".sms"),
+	}
+
+	for name, path := range paths {
+		t.Run(name, func(t *testing.T) {
+			rom, err := Open(path, testExtensions, Options{})
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer rom.Close()
+
+			if rom.Size != int64(len(hashData)) {
+				t.Errorf("Size = %d, want %d", rom.Size, len(hashData))
+			}
+			buf := make([]byte, 5)
+			if _, err := rom.ReadAt(buf, 10); err != nil {
+				t.Fatalf("ReadAt failed: %v", err)
+			}
+			if string(buf) != "brown" {
+				t.Errorf("ReadAt = %q, want %q", buf, "brown")
+			}
+			if _, err := rom.ReadAt(buf, rom.Size); err != io.EOF {
+				t.Errorf("ReadAt past end = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestOpen_StoredZipReadsFromDisk(t *testing.T) {
+	path := createTestStoredZip(t, hashData, "game.sms")
+	rom, err := Open(path, testExtensions, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer rom.Close()
+
+	if _, ok := rom.r.(*io.SectionReader); !ok {
+		t.Errorf("stored entry reader is %T, want *io.SectionReader", rom.r)
+	}
+}
+
+func TestOpen_MaxSize(t *testing.T) {
+	path := createTestROMFile(t, hashData, ".sms")
+	if _, err := Open(path, testExtensions, Options{MaxSize: 10}); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestListWith_MaxSize(t *testing.T) {
+	path := createTestMultiZip(t, []testFile{
+		{"small.sms", []byte("tiny")},
+		{"large.sms", hashData},
+	})
+
+	entries, err := ListWith(path, testExtensions, Options{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("ListWith failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "small.sms" {
+		t.Errorf("entries = %+v, want only small.sms", entries)
+	}
+}
@@ -0,0 +1,518 @@
+package romloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PatchExtensions are the file extensions of supported ROM patches.
+var PatchExtensions = []string{".ips", ".bps", ".ups"}
+
+// Magic bytes for patch format detection
+var (
+	magicIPS    = []byte("PATCH")
+	magicIPSEOF = []byte("EOF")
+	magicBPS    = []byte("BPS1")
+	magicUPS    = []byte("UPS1")
+)
+
+// ErrInvalidPatch is returned for unrecognized, truncated or corrupt patches
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// ErrPatchMismatch is returned when a patch was made for a different ROM
+var ErrPatchMismatch = errors.New("patch does not match ROM")
+
+// ApplyPatch applies an IPS, BPS or UPS patch to rom and returns the
+// patched ROM. The format is detected from the patch header. BPS and UPS
+// patches are checked against their source, target and patch CRC32s; IPS
+// has no checksums. rom is not modified.
+func ApplyPatch(rom, patch []byte) ([]byte, error) {
+	return applyPatch(rom, patch, DefaultMaxSize)
+}
+
+// applyPatch applies a patch, limiting the patched ROM to limit bytes.
+func applyPatch(rom, patch []byte, limit int64) ([]byte, error) {
+	var (
+		out []byte
+		err error
+	)
+	switch {
+	case bytes.HasPrefix(patch, magicIPS):
+		out, err = applyIPS(rom, patch, limit)
+	case bytes.HasPrefix(patch, magicBPS):
+		out, err = applyBPS(rom, patch, limit)
+	case bytes.HasPrefix(patch, magicUPS):
+		out, err = applyUPS(rom, patch, limit)
+	default:
+		return nil, fmt.Errorf("%w: unknown format", ErrInvalidPatch)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(out)) > limit {
+		return nil, ErrFileTooLarge
+	}
+	return out, nil
+}
+
+// LoadPatched loads a ROM like Load and applies the patch files in order.
+// Patch paths may be archives or "archive#entry" paths. With no patches
+// it is the same as Load.
+func LoadPatched(path string, extensions, patches []string) ([]byte, string, error) {
+	res, err := LoadWith(path, extensions, Options{Patches: patches})
+	if err != nil {
+		return nil, "", err
+	}
+	return res.Data, res.Name, nil
+}
+
+// applyPatchFiles loads the patch files and applies them to rom in order.
+func applyPatchFiles(rom []byte, patches []string, limit int64) ([]byte, error) {
+	for _, p := range patches {
+		patch, _, err := Load(p, PatchExtensions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load patch %s: %w", p, err)
+		}
+		rom, err = applyPatch(rom, patch, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply patch %s: %w", p, err)
+		}
+	}
+	return rom, nil
+}
+
+// FindPatches returns the patches for the ROM at path, which may be an
+// "archive#entry" path. Patches are files next to the ROM with the same
+// base name (game.sms or game.zip uses game.ips, game.bps and game.ups),
+// followed by patches inside the same archive that share the ROM's base
+// name. When the archive holds a single ROM, every patch in it is used.
+func FindPatches(path string, extensions []string) ([]string, error) {
+	return NewPatchIndex().Find(path, extensions)
+}
+
+// PatchIndex finds patches like FindPatches for many ROMs, reading each
+// directory and archive once. It's meant for scanning a library and
+// doesn't see files added after their directory or archive is read. A
+// PatchIndex is not safe for concurrent use.
+type PatchIndex struct {
+	dirs     map[string][]string      // Patch file names by directory
+	archives map[string]*archiveIndex // Archive contents by path
+}
+
+// archiveIndex is the ROMs and patches inside an archive
+type archiveIndex struct {
+	roms    []Entry
+	patches []Entry
+}
+
+// NewPatchIndex returns an empty PatchIndex.
+func NewPatchIndex() *PatchIndex {
+	return &PatchIndex{
+		dirs:     make(map[string][]string),
+		archives: make(map[string]*archiveIndex),
+	}
+}
+
+// Find returns the patches for the ROM at path the same way FindPatches
+// does.
+func (x *PatchIndex) Find(path string, extensions []string) ([]string, error) {
+	archive, entry, split := splitEntryPath(path)
+	if !split {
+		archive = path
+	}
+
+	format, err := openFormat(archive, extensions)
+	if err != nil {
+		return nil, err
+	}
+	isArchive := isMultiFile(format, archive)
+
+	var contents *archiveIndex
+	if isArchive {
+		contents, err = x.archive(archive, extensions)
+		if err != nil {
+			return nil, err
+		}
+		if !split {
+			entry = contents.roms[0].Name
+		}
+	}
+
+	// Multi-ROM archives name patches after the entry, everything else
+	// after the file on disk
+	name := filepath.Base(archive)
+	if split {
+		name = filepath.Base(entry)
+	}
+	base := trimROMExt(name)
+
+	patches, err := x.siblingPatches(filepath.Dir(archive), base)
+	if err != nil {
+		return nil, err
+	}
+	if !isArchive {
+		return patches, nil
+	}
+
+	romBase := trimROMExt(filepath.Base(entry))
+	for _, p := range contents.patches {
+		if len(contents.roms) == 1 || strings.EqualFold(trimROMExt(filepath.Base(p.Name)), romBase) {
+			patches = append(patches, EntryPath(archive, p.Name))
+		}
+	}
+	return patches, nil
+}
+
+// archive lists the ROMs and patches in an archive in a single pass.
+func (x *PatchIndex) archive(path string, extensions []string) (*archiveIndex, error) {
+	if a, ok := x.archives[path]; ok {
+		return a, nil
+	}
+
+	var all []string
+	if extensions != nil {
+		all = append(slices.Clone(extensions), PatchExtensions...)
+	}
+	entries, err := List(path, all)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &archiveIndex{}
+	for _, e := range entries {
+		if isROMFile(e.Name, PatchExtensions) {
+			a.patches = append(a.patches, e)
+		} else {
+			a.roms = append(a.roms, e)
+		}
+	}
+	if len(a.roms) == 0 {
+		return nil, ErrNoFile
+	}
+	x.archives[path] = a
+	return a, nil
+}
+
+// isMultiFile reports whether a file of format can hold more than one
+// file. Plain compressed streams and CHDs hold only the ROM itself.
+func isMultiFile(format formatType, path string) bool {
+	switch format {
+	case formatZIP, format7z, formatRAR:
+		return true
+	case formatGzip, formatZstd, formatXz, formatBzip2, formatLz4:
+		return streamFormats[format].isTar(path)
+	}
+	return false
+}
+
+// siblingPatches lists the patch files in dir named base plus a patch
+// extension, compared case-insensitively.
+func (x *PatchIndex) siblingPatches(dir, base string) ([]string, error) {
+	names, ok := x.dirs[dir]
+	if !ok {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, f := range files {
+			if !f.IsDir() && isROMFile(f.Name(), PatchExtensions) {
+				names = append(names, f.Name())
+			}
+		}
+		x.dirs[dir] = names
+	}
+
+	var patches []string
+	for _, name := range names {
+		for _, ext := range PatchExtensions {
+			if strings.EqualFold(name, base+ext) {
+				patches = append(patches, filepath.Join(dir, name))
+			}
+		}
+	}
+	return patches, nil
+}
+
+// trimROMExt removes the extension from a file name, treating .tar.gz as
+// one extension.
+func trimROMExt(name string) string {
+	if strings.HasSuffix(strings.ToLower(name), ".tar.gz") {
+		return name[:len(name)-len(".tar.gz")]
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// applyIPS applies an IPS patch. Records are a 3-byte offset and 2-byte
+// length followed by the data, or a zero length followed by a 2-byte run
+// length and fill byte. The optional 3 bytes after "EOF" truncate the
+// output.
+func applyIPS(rom, patch []byte, limit int64) ([]byte, error) {
+	out := append([]byte(nil), rom...)
+	pos := len(magicIPS)
+
+	for {
+		if pos+3 > len(patch) {
+			return nil, fmt.Errorf("%w: missing IPS end marker", ErrInvalidPatch)
+		}
+		if bytes.Equal(patch[pos:pos+3], magicIPSEOF) {
+			pos += 3
+			break
+		}
+		if pos+5 > len(patch) {
+			return nil, fmt.Errorf("%w: truncated IPS record", ErrInvalidPatch)
+		}
+		offset := int(patch[pos])<<16 | int(patch[pos+1])<<8 | int(patch[pos+2])
+		size := int(binary.BigEndian.Uint16(patch[pos+3:]))
+		pos += 5
+
+		var data []byte
+		if size == 0 {
+			if pos+3 > len(patch) {
+				return nil, fmt.Errorf("%w: truncated IPS run", ErrInvalidPatch)
+			}
+			size = int(binary.BigEndian.Uint16(patch[pos:]))
+			data = bytes.Repeat(patch[pos+2:pos+3], size)
+			pos += 3
+		} else {
+			if pos+size > len(patch) {
+				return nil, fmt.Errorf("%w: truncated IPS record", ErrInvalidPatch)
+			}
+			data = patch[pos : pos+size]
+			pos += size
+		}
+
+		if end := offset + size; end > len(out) {
+			if int64(end) > limit {
+				return nil, ErrFileTooLarge
+			}
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	if pos+3 <= len(patch) {
+		size := int(patch[pos])<<16 | int(patch[pos+1])<<8 | int(patch[pos+2])
+		if size < len(out) {
+			out = out[:size]
+		}
+	}
+	return out, nil
+}
+
+// patchReader reads the body of a BPS or UPS patch, stopping before the
+// 12-byte CRC32 footer.
+type patchReader struct {
+	data []byte
+	pos  int
+	end  int
+	max  uint64 // Largest valid number
+	err  error
+}
+
+// readByte returns the next byte, or 0 and records an error past the end.
+func (r *patchReader) readByte() byte {
+	if r.pos >= r.end {
+		r.err = fmt.Errorf("%w: truncated patch", ErrInvalidPatch)
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+// readNumber decodes the variable length numbers used by BPS and UPS.
+// Numbers larger than max are rejected.
+func (r *patchReader) readNumber() int {
+	var n, shift uint64 = 0, 1
+	for r.err == nil {
+		b := r.readByte()
+		n += uint64(b&0x7f) * shift
+		if b&0x80 != 0 {
+			break
+		}
+		shift <<= 7
+		n += shift
+		if n > r.max {
+			r.err = fmt.Errorf("%w: number out of range", ErrInvalidPatch)
+		}
+	}
+	if r.err == nil && n > r.max {
+		r.err = fmt.Errorf("%w: number out of range", ErrInvalidPatch)
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// checkFooter splits off a BPS/UPS footer and verifies the patch CRC32.
+// Returns a reader over the patch body and the source and target CRC32s.
+// Offsets and lengths fit in a ROM of limit bytes and signed offsets use
+// one extra bit, bounding the numbers read.
+func checkFooter(patch []byte, magic []byte, limit int64) (*patchReader, uint32, uint32, error) {
+	if len(patch) < len(magic)+12 {
+		return nil, 0, 0, fmt.Errorf("%w: truncated patch", ErrInvalidPatch)
+	}
+	footer := patch[len(patch)-12:]
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != binary.LittleEndian.Uint32(footer[8:]) {
+		return nil, 0, 0, fmt.Errorf("%w: patch checksum mismatch", ErrInvalidPatch)
+	}
+	r := &patchReader{data: patch, pos: len(magic), end: len(patch) - 12, max: 2 * uint64(limit)}
+	return r, binary.LittleEndian.Uint32(footer), binary.LittleEndian.Uint32(footer[4:]), nil
+}
+
+// applyBPS applies a BPS patch.
+func applyBPS(rom, patch []byte, limit int64) ([]byte, error) {
+	r, sourceCRC, targetCRC, err := checkFooter(patch, magicBPS, limit)
+	if err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(rom) != sourceCRC {
+		return nil, ErrPatchMismatch
+	}
+
+	sourceSize := r.readNumber()
+	targetSize := r.readNumber()
+	metadataSize := r.readNumber()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if sourceSize != len(rom) {
+		return nil, ErrPatchMismatch
+	}
+	if int64(targetSize) > limit {
+		return nil, ErrFileTooLarge
+	}
+	if metadataSize > r.end-r.pos {
+		return nil, fmt.Errorf("%w: truncated metadata", ErrInvalidPatch)
+	}
+	r.pos += metadataSize
+
+	out := make([]byte, targetSize)
+	outPos, sourceRel, targetRel := 0, 0, 0
+	for r.pos < r.end {
+		data := r.readNumber()
+		length := data>>2 + 1
+		if r.err != nil {
+			return nil, r.err
+		}
+		if outPos+length > targetSize {
+			return nil, fmt.Errorf("%w: write past end of target", ErrInvalidPatch)
+		}
+
+		switch data & 3 {
+		case 0: // SourceRead
+			if outPos+length > len(rom) {
+				return nil, fmt.Errorf("%w: read past end of source", ErrInvalidPatch)
+			}
+			copy(out[outPos:], rom[outPos:outPos+length])
+		case 1: // TargetRead
+			if r.pos+length > r.end {
+				return nil, fmt.Errorf("%w: truncated patch", ErrInvalidPatch)
+			}
+			copy(out[outPos:], patch[r.pos:r.pos+length])
+			r.pos += length
+		case 2: // SourceCopy
+			sourceRel += signedOffset(r.readNumber())
+			if r.err != nil {
+				return nil, r.err
+			}
+			if sourceRel < 0 || sourceRel+length > len(rom) {
+				return nil, fmt.Errorf("%w: read past end of source", ErrInvalidPatch)
+			}
+			copy(out[outPos:], rom[sourceRel:sourceRel+length])
+			sourceRel += length
+		case 3: // TargetCopy
+			targetRel += signedOffset(r.readNumber())
+			if r.err != nil {
+				return nil, r.err
+			}
+			if targetRel < 0 || targetRel >= outPos {
+				return nil, fmt.Errorf("%w: copy outside of target", ErrInvalidPatch)
+			}
+			// Byte by byte since the ranges may overlap to repeat a pattern
+			for i := 0; i < length; i++ {
+				out[outPos+i] = out[targetRel]
+				targetRel++
+			}
+		}
+		outPos += length
+	}
+
+	if crc32.ChecksumIEEE(out) != targetCRC {
+		return nil, fmt.Errorf("%w: target checksum mismatch", ErrInvalidPatch)
+	}
+	return out, nil
+}
+
+// signedOffset decodes a BPS relative offset: the low bit is the sign.
+func signedOffset(n int) int {
+	if n&1 != 0 {
+		return -(n >> 1)
+	}
+	return n >> 1
+}
+
+// applyUPS applies a UPS patch. UPS patches XOR the source so they also
+// apply in reverse: a ROM matching the target CRC32 is restored to the
+// source.
+func applyUPS(rom, patch []byte, limit int64) ([]byte, error) {
+	r, sourceCRC, targetCRC, err := checkFooter(patch, magicUPS, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceSize := r.readNumber()
+	targetSize := r.readNumber()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	romCRC := crc32.ChecksumIEEE(rom)
+	switch {
+	case len(rom) == sourceSize && romCRC == sourceCRC:
+	case len(rom) == targetSize && romCRC == targetCRC:
+		targetSize, targetCRC = sourceSize, sourceCRC
+	default:
+		return nil, ErrPatchMismatch
+	}
+	if int64(targetSize) > limit {
+		return nil, ErrFileTooLarge
+	}
+
+	out := make([]byte, targetSize)
+	copy(out, rom)
+	pos := 0
+	for r.pos < r.end {
+		pos += r.readNumber()
+		for r.err == nil {
+			x := r.readByte()
+			if pos < targetSize {
+				var b byte
+				if pos < len(rom) {
+					b = rom[pos]
+				}
+				out[pos] = b ^ x
+			}
+			pos++
+			if x == 0 {
+				break
+			}
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	if crc32.ChecksumIEEE(out) != targetCRC {
+		return nil, fmt.Errorf("%w: target checksum mismatch", ErrInvalidPatch)
+	}
+	return out, nil
+}
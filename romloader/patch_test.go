@@ -0,0 +1,366 @@
+package romloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodeNumber encodes a BPS/UPS variable length number
+func encodeNumber(n int) []byte {
+	var out []byte
+	for {
+		x := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			return append(out, 0x80|x)
+		}
+		out = append(out, x)
+		n--
+	}
+}
+
+// appendFooter adds the source, target and patch CRC32s
+func appendFooter(patch, source, target []byte) []byte {
+	patch = binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(source))
+	patch = binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(patch, crc32.ChecksumIEEE(patch))
+}
+
+// createUPSPatch builds a UPS patch turning source into target
+func createUPSPatch(source, target []byte) []byte {
+	patch := append([]byte("UPS1"), encodeNumber(len(source))...)
+	patch = append(patch, encodeNumber(len(target))...)
+
+	at := func(b []byte, i int) byte {
+		if i < len(b) {
+			return b[i]
+		}
+		return 0
+	}
+	size := max(len(source), len(target))
+	last := 0
+	for i := 0; i < size; i++ {
+		if at(source, i) == at(target, i) {
+			continue
+		}
+		patch = append(patch, encodeNumber(i-last)...)
+		for ; i < size && at(source, i) != at(target, i); i++ {
+			patch = append(patch, at(source, i)^at(target, i))
+		}
+		patch = append(patch, 0)
+		last = i + 1
+	}
+	return appendFooter(patch, source, target)
+}
+
+// bpsSource and bpsTarget are used by createBPSPatch
+var (
+	bpsSource = []byte("ABCDEFGH")
+	bpsTarget = []byte("ABxyFGHxyFGA")
+)
+
+// createBPSPatch builds a BPS patch using every action to turn bpsSource
+// into bpsTarget
+func createBPSPatch() []byte {
+	action := func(cmd, length int) []byte {
+		return encodeNumber((length-1)<<2 | cmd)
+	}
+	offset := func(n int) []byte {
+		if n < 0 {
+			return encodeNumber(-n<<1 | 1)
+		}
+		return encodeNumber(n << 1)
+	}
+
+	patch := append([]byte("BPS1"), encodeNumber(len(bpsSource))...)
+	patch = append(patch, encodeNumber(len(bpsTarget))...)
+	patch = append(patch, encodeNumber(4)...)
+	patch = append(patch, "meta"...)
+	patch = append(patch, action(0, 2)...) // SourceRead "AB"
+	patch = append(patch, action(1, 2)...) // TargetRead "xy"
+	patch = append(patch, "xy"...)
+	patch = append(patch, action(2, 3)...) // SourceCopy "FGH"
+	patch = append(patch, offset(5)...)
+	patch = append(patch, action(3, 4)...) // TargetCopy "xyFG"
+	patch = append(patch, offset(2)...)
+	patch = append(patch, action(2, 1)...) // SourceCopy "A"
+	patch = append(patch, offset(-8)...)
+	return appendFooter(patch, bpsSource, bpsTarget)
+}
+
+func TestApplyPatch_IPS(t *testing.T) {
+	rom := []byte("0123456789")
+	patch := []byte("PATCH")
+	// Replace 2 bytes at offset 1
+	patch = append(patch, 0x00, 0x00, 0x01, 0x00, 0x02, 'a', 'b')
+	// Run of 3 'z' at offset 8, growing the ROM
+	patch = append(patch, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x03, 'z')
+	patch = append(patch, "EOF"...)
+
+	got, err := ApplyPatch(rom, patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if want := "0ab34567zzz"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if string(rom) != "0123456789" {
+		t.Error("source ROM was modified")
+	}
+}
+
+func TestApplyPatch_IPSTruncate(t *testing.T) {
+	patch := append([]byte("PATCHEOF"), 0x00, 0x00, 0x04)
+	got, err := ApplyPatch([]byte("0123456789"), patch)
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if string(got) != "0123" {
+		t.Errorf("got %q, want %q", got, "0123")
+	}
+}
+
+func TestApplyPatch_IPSTruncated(t *testing.T) {
+	patches := [][]byte{
+		[]byte("PATCH"),
+		append([]byte("PATCH"), 0x00, 0x00, 0x01, 0x00, 0x05, 'a'),
+		append([]byte("PATCH"), 0x00, 0x00, 0x01, 0x00, 0x00, 0x00),
+	}
+	for i, p := range patches {
+		if _, err := ApplyPatch([]byte("rom"), p); !errors.Is(err, ErrInvalidPatch) {
+			t.Errorf("patch %d: expected ErrInvalidPatch, got %v", i, err)
+		}
+	}
+}
+
+func TestApplyPatch_BPS(t *testing.T) {
+	got, err := ApplyPatch(bpsSource, createBPSPatch())
+	if err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if !bytes.Equal(got, bpsTarget) {
+		t.Errorf("got %q, want %q", got, bpsTarget)
+	}
+}
+
+func TestApplyPatch_BPSWrongSource(t *testing.T) {
+	_, err := ApplyPatch([]byte("ABCDEFGX"), createBPSPatch())
+	if !errors.Is(err, ErrPatchMismatch) {
+		t.Errorf("expected ErrPatchMismatch, got %v", err)
+	}
+}
+
+func TestApplyPatch_BPSCorrupt(t *testing.T) {
+	patch := createBPSPatch()
+	patch[len(patch)-14] ^= 0xff
+
+	_, err := ApplyPatch(bpsSource, patch)
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("expected ErrInvalidPatch, got %v", err)
+	}
+}
+
+func TestApplyPatch_UPS(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		target string
+	}{
+		{"same size", "Hello, World!", "Hello, Gophr!"},
+		{"grow", "short", "shorter and longer"},
+		{"shrink", "a much longer rom", "a much"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			patch := createUPSPatch([]byte(tc.source), []byte(tc.target))
+			got, err := ApplyPatch([]byte(tc.source), patch)
+			if err != nil {
+				t.Fatalf("ApplyPatch failed: %v", err)
+			}
+			if string(got) != tc.target {
+				t.Errorf("got %q, want %q", got, tc.target)
+			}
+
+			// UPS patches also apply in reverse
+			got, err = ApplyPatch([]byte(tc.target), patch)
+			if err != nil {
+				t.Fatalf("reverse ApplyPatch failed: %v", err)
+			}
+			if string(got) != tc.source {
+				t.Errorf("reverse got %q, want %q", got, tc.source)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_UPSWrongSource(t *testing.T) {
+	patch := createUPSPatch([]byte("original"), []byte("modified"))
+	_, err := ApplyPatch([]byte("somethin"), patch)
+	if !errors.Is(err, ErrPatchMismatch) {
+		t.Errorf("expected ErrPatchMismatch, got %v", err)
+	}
+}
+
+func TestApplyPatch_UnknownFormat(t *testing.T) {
+	_, err := ApplyPatch([]byte("rom"), []byte("not a patch"))
+	if !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("expected ErrInvalidPatch, got %v", err)
+	}
+}
+
+func TestLoadPatched(t *testing.T) {
+	dir := t.TempDir()
+	romPath := filepath.Join(dir, "game.sms")
+	patchPath := filepath.Join(dir, "game.bps")
+	os.WriteFile(romPath, bpsSource, 0644)
+	os.WriteFile(patchPath, createBPSPatch(), 0644)
+
+	data, filename, err := LoadPatched(romPath, testExtensions, []string{patchPath})
+	if err != nil {
+		t.Fatalf("LoadPatched failed: %v", err)
+	}
+	if !bytes.Equal(data, bpsTarget) {
+		t.Errorf("data = %q, want %q", data, bpsTarget)
+	}
+	if filename != "game.sms" {
+		t.Errorf("filename = %q, want %q", filename, "game.sms")
+	}
+
+	// No patches loads the ROM unchanged
+	data, _, err = LoadPatched(romPath, testExtensions, nil)
+	if err != nil || !bytes.Equal(data, bpsSource) {
+		t.Errorf("unpatched = %q, %v", data, err)
+	}
+}
+
+func TestLoadPatched_FromArchive(t *testing.T) {
+	path := createTestMultiZip(t, []testFile{
+		{"game.sms", bpsSource},
+		{"game.bps", createBPSPatch()},
+	})
+
+	data, _, err := LoadPatched(path, testExtensions, []string{EntryPath(path, "game.bps")})
+	if err != nil {
+		t.Fatalf("LoadPatched failed: %v", err)
+	}
+	if !bytes.Equal(data, bpsTarget) {
+		t.Errorf("data = %q, want %q", data, bpsTarget)
+	}
+}
+
+func TestFindPatches_Sibling(t *testing.T) {
+	dir := t.TempDir()
+	romPath := filepath.Join(dir, "game.sms")
+	for _, name := range []string{"game.sms", "game.IPS", "game.ups", "other.bps", "game.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+
+	patches, err := FindPatches(romPath, testExtensions)
+	if err != nil {
+		t.Fatalf("FindPatches failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "game.IPS"), filepath.Join(dir, "game.ups")}
+	if len(patches) != len(want) {
+		t.Fatalf("patches = %v, want %v", patches, want)
+	}
+	for i := range want {
+		if patches[i] != want[i] {
+			t.Errorf("patches[%d] = %q, want %q", i, patches[i], want[i])
+		}
+	}
+}
+
+func TestFindPatches_InArchive(t *testing.T) {
+	path := createTestMultiZip(t, []testFile{
+		{"Game A.sms", []byte("a")},
+		{"Game A.ips", []byte("PATCHEOF")},
+		{"Game B.sms", []byte("b")},
+		{"Game B.bps", []byte("x")},
+	})
+
+	patches, err := FindPatches(EntryPath(path, "Game B.sms"), testExtensions)
+	if err != nil {
+		t.Fatalf("FindPatches failed: %v", err)
+	}
+	if len(patches) != 1 || patches[0] != EntryPath(path, "Game B.bps") {
+		t.Errorf("patches = %v, want only Game B.bps", patches)
+	}
+}
+
+func TestFindPatches_SingleROMArchive(t *testing.T) {
+	path := createTestMultiZip(t, []testFile{
+		{"Game.sms", []byte("a")},
+		{"Translation.ips", []byte("PATCHEOF")},
+	})
+	// set.zip gets patches named set.*
+	os.WriteFile(filepath.Join(filepath.Dir(path), "set.ups"), []byte("x"), 0644)
+
+	patches, err := FindPatches(path, testExtensions)
+	if err != nil {
+		t.Fatalf("FindPatches failed: %v", err)
+	}
+	want := []string{filepath.Join(filepath.Dir(path), "set.ups"), EntryPath(path, "Translation.ips")}
+	if len(patches) != len(want) {
+		t.Fatalf("patches = %v, want %v", patches, want)
+	}
+	for i := range want {
+		if patches[i] != want[i] {
+			t.Errorf("patches[%d] = %q, want %q", i, patches[i], want[i])
+		}
+	}
+}
+
+func TestFindPatches_PlainStream(t *testing.T) {
+	// test.sms.gz holds only the ROM, which must not be taken as a patch
+	path := createTestGzipFile(t, []byte("PATCHEOF"), ".sms")
+
+	patches, err := FindPatches(path, testExtensions)
+	if err != nil {
+		t.Fatalf("FindPatches failed: %v", err)
+	}
+	if len(patches) != 0 {
+		t.Errorf("patches = %v, want none", patches)
+	}
+	if _, err := List(path, PatchExtensions); !errors.Is(err, ErrNoFile) {
+		t.Errorf("List with patch extensions error = %v, want ErrNoFile", err)
+	}
+}
+
+func TestPatchIndex_ReadsDirectoryOnce(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.sms", "a.ips", "b.sms"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644)
+	}
+
+	x := NewPatchIndex()
+	patches, err := x.Find(filepath.Join(dir, "a.sms"), testExtensions)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(patches) != 1 || patches[0] != filepath.Join(dir, "a.ips") {
+		t.Errorf("patches = %v, want only a.ips", patches)
+	}
+
+	// Added after the directory was read, so only FindPatches sees it
+	os.WriteFile(filepath.Join(dir, "b.bps"), []byte("x"), 0644)
+	patches, err = x.Find(filepath.Join(dir, "b.sms"), testExtensions)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(patches) != 0 {
+		t.Errorf("patches = %v, want none from the indexed directory", patches)
+	}
+	patches, err = FindPatches(filepath.Join(dir, "b.sms"), testExtensions)
+	if err != nil {
+		t.Fatalf("FindPatches failed: %v", err)
+	}
+	if len(patches) != 1 {
+		t.Errorf("patches = %v, want b.bps", patches)
+	}
+}
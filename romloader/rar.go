@@ -8,13 +8,12 @@ import (
 	"github.com/nwaples/rardecode/v2"
 )
 
-// extractFromRAR extracts the first ROM file from a RAR archive
-func extractFromRAR(path string, extensions []string) ([]byte, string, error) {
+// openRAR opens the first matching file in a RAR archive
+func openRAR(path string, match matchFunc) (*entryReader, error) {
 	r, err := rardecode.OpenReader(path)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open rar: %w", err)
+		return nil, fmt.Errorf("failed to open rar: %w", err)
 	}
-	defer r.Close()
 
 	for {
 		header, err := r.Next()
@@ -22,22 +21,59 @@ func extractFromRAR(path string, extensions []string) ([]byte, string, error) {
 			break
 		}
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to read rar entry: %w", err)
+			r.Close()
+			return nil, fmt.Errorf("failed to read rar entry: %w", err)
 		}
 
 		if header.IsDir {
 			continue
 		}
-		if !isROMFile(header.Name, extensions) {
+		if !match(header.Name) {
 			continue
 		}
 
-		data, err := limitedRead(r)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to read %s: %w", header.Name, err)
+		size := header.UnPackedSize
+		if header.UnKnownSize {
+			size = -1
 		}
-		return data, filepath.Base(header.Name), nil
+		return &entryReader{
+			Reader:  r,
+			name:    filepath.Base(header.Name),
+			size:    size,
+			closers: []io.Closer{r},
+		}, nil
+	}
+
+	r.Close()
+	return nil, ErrNoFile
+}
+
+// listRAR lists the ROM files in a RAR archive. rardecode doesn't expose
+// file checksums, so entries have no CRC32.
+func listRAR(path string, extensions []string) ([]Entry, error) {
+	r, err := rardecode.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rar: %w", err)
 	}
+	defer r.Close()
 
-	return nil, "", ErrNoFile
+	var entries []Entry
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rar entry: %w", err)
+		}
+		if header.IsDir || !isROMFile(header.Name, extensions) {
+			continue
+		}
+		var size int64
+		if !header.UnKnownSize {
+			size = header.UnPackedSize
+		}
+		entries = append(entries, Entry{Name: header.Name, Size: size})
+	}
+	return entries, nil
 }
@@ -6,16 +6,16 @@ import (
 	"testing"
 )
 
-// TestExtractFromRAR_FileNotFound tests error handling for missing files
-func TestExtractFromRAR_FileNotFound(t *testing.T) {
-	_, _, err := extractFromRAR("/nonexistent/path/test.rar", testExtensions)
+// TestOpenRAR_FileNotFound tests error handling for missing files
+func TestOpenRAR_FileNotFound(t *testing.T) {
+	_, err := openRAR("/nonexistent/path/test.rar", romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for nonexistent file")
 	}
 }
 
-// TestExtractFromRAR_InvalidFormat tests error handling for non-RAR files
-func TestExtractFromRAR_InvalidFormat(t *testing.T) {
+// TestOpenRAR_InvalidFormat tests error handling for non-RAR files
+func TestOpenRAR_InvalidFormat(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "fake.rar")
 
@@ -24,14 +24,14 @@ func TestExtractFromRAR_InvalidFormat(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFromRAR(path, testExtensions)
+	_, err = openRAR(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for invalid RAR file")
 	}
 }
 
-// TestExtractFromRAR_EmptyFile tests error handling for empty files
-func TestExtractFromRAR_EmptyFile(t *testing.T) {
+// TestOpenRAR_EmptyFile tests error handling for empty files
+func TestOpenRAR_EmptyFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "empty.rar")
 
@@ -40,14 +40,14 @@ func TestExtractFromRAR_EmptyFile(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFromRAR(path, testExtensions)
+	_, err = openRAR(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for empty file")
 	}
 }
 
-// TestExtractFromRAR_PartialMagic tests files with partial RAR magic bytes
-func TestExtractFromRAR_PartialMagic(t *testing.T) {
+// TestOpenRAR_PartialMagic tests files with partial RAR magic bytes
+func TestOpenRAR_PartialMagic(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "partial.rar")
 
@@ -58,16 +58,16 @@ func TestExtractFromRAR_PartialMagic(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFromRAR(path, testExtensions)
+	_, err = openRAR(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for file with partial magic bytes")
 	}
 }
 
-// TestExtractFromRAR_CorruptedArchive tests handling of corrupted archives
+// TestOpenRAR_CorruptedArchive tests handling of corrupted archives
 // Note: The rardecode library may panic on severely corrupted files,
 // which is expected behavior for invalid input
-func TestExtractFromRAR_CorruptedArchive(t *testing.T) {
+func TestOpenRAR_CorruptedArchive(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "corrupt.rar")
 
@@ -88,7 +88,7 @@ func TestExtractFromRAR_CorruptedArchive(t *testing.T) {
 		}
 	}()
 
-	_, _, err = extractFromRAR(path, testExtensions)
+	_, err = openRAR(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for corrupted RAR file")
 	}
@@ -134,9 +134,9 @@ func TestLoad_RAR_Integration(t *testing.T) {
 	}
 }
 
-// TestExtractFromRAR_DirectorySkipping tests handling of directories in RAR
+// TestOpenRAR_DirectorySkipping tests handling of directories in RAR
 // (directories should be skipped)
-func TestExtractFromRAR_DirectorySkipping(t *testing.T) {
+func TestOpenRAR_DirectorySkipping(t *testing.T) {
 	// We can't easily create a valid RAR with directories without external tools,
 	// but we can verify the detection logic handles the case where no ROM file is found
 	tmpDir := t.TempDir()
@@ -148,7 +148,7 @@ func TestExtractFromRAR_DirectorySkipping(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFromRAR(path, testExtensions)
+	_, err = openRAR(path, romMatch(testExtensions))
 	// Should fail (can't read header or no ROM file)
 	if err == nil {
 		t.Error("Expected error for RAR with no valid entries")
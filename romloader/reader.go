@@ -0,0 +1,48 @@
+package romloader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// entryReader reads one file from disk or from inside an archive.
+type entryReader struct {
+	io.Reader
+	name    string      // Base name of the file
+	size    int64       // Uncompressed size; -1 if unknown
+	at      io.ReaderAt // Random access to stored data; nil if compressed
+	closers []io.Closer // Closed in order by Close
+}
+
+// Close closes the entry and the archive it came from.
+func (e *entryReader) Close() error {
+	var first error
+	for _, c := range e.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// openRaw opens a file that isn't an archive.
+func openRaw(path string) (*entryReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	return &entryReader{
+		Reader:  f,
+		name:    filepath.Base(path),
+		size:    info.Size(),
+		at:      f,
+		closers: []io.Closer{f},
+	}, nil
+}
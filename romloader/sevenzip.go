@@ -2,39 +2,64 @@ package romloader
 
 import (
 	"fmt"
+	"io"
 	"path/filepath"
 
 	"github.com/bodgit/sevenzip"
 )
 
-// extractFrom7z extracts the first ROM file from a 7z archive
-func extractFrom7z(path string, extensions []string) ([]byte, string, error) {
+// open7z opens the first matching file in a 7z archive
+func open7z(path string, match matchFunc) (*entryReader, error) {
 	r, err := sevenzip.OpenReader(path)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open 7z: %w", err)
+		return nil, fmt.Errorf("failed to open 7z: %w", err)
 	}
-	defer r.Close()
 
 	for _, f := range r.File {
 		if f.FileInfo().IsDir() {
 			continue
 		}
-		if !isROMFile(f.Name, extensions) {
+		if !match(f.Name) {
 			continue
 		}
 
 		rc, err := f.Open()
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to open %s in archive: %w", f.Name, err)
+			r.Close()
+			return nil, fmt.Errorf("failed to open %s in archive: %w", f.Name, err)
 		}
-		defer rc.Close()
+		return &entryReader{
+			Reader:  rc,
+			name:    filepath.Base(f.Name),
+			size:    int64(f.UncompressedSize),
+			closers: []io.Closer{rc, r},
+		}, nil
+	}
 
-		data, err := limitedRead(rc)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to read %s: %w", f.Name, err)
-		}
-		return data, filepath.Base(f.Name), nil
+	r.Close()
+	return nil, ErrNoFile
+}
+
+// list7z lists the ROM files in a 7z archive. 7z stores a CRC32 per file
+// unless the archiver left it out, in which case it reads as zero.
+func list7z(path string, extensions []string) ([]Entry, error) {
+	r, err := sevenzip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open 7z: %w", err)
 	}
+	defer r.Close()
 
-	return nil, "", ErrNoFile
+	var entries []Entry
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isROMFile(f.Name, extensions) {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:     f.Name,
+			Size:     int64(f.UncompressedSize),
+			CRC32:    f.CRC32,
+			HasCRC32: f.CRC32 != 0,
+		})
+	}
+	return entries, nil
 }
@@ -6,16 +6,16 @@ import (
 	"testing"
 )
 
-// TestExtractFrom7z_FileNotFound tests error handling for missing files
-func TestExtractFrom7z_FileNotFound(t *testing.T) {
-	_, _, err := extractFrom7z("/nonexistent/path/test.7z", testExtensions)
+// TestOpen7z_FileNotFound tests error handling for missing files
+func TestOpen7z_FileNotFound(t *testing.T) {
+	_, err := open7z("/nonexistent/path/test.7z", romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for nonexistent file")
 	}
 }
 
-// TestExtractFrom7z_InvalidFormat tests error handling for non-7z files
-func TestExtractFrom7z_InvalidFormat(t *testing.T) {
+// TestOpen7z_InvalidFormat tests error handling for non-7z files
+func TestOpen7z_InvalidFormat(t *testing.T) {
 	// Create a file with invalid 7z content
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "fake.7z")
@@ -25,14 +25,14 @@ func TestExtractFrom7z_InvalidFormat(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFrom7z(path, testExtensions)
+	_, err = open7z(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for invalid 7z file")
 	}
 }
 
-// TestExtractFrom7z_EmptyFile tests error handling for empty files
-func TestExtractFrom7z_EmptyFile(t *testing.T) {
+// TestOpen7z_EmptyFile tests error handling for empty files
+func TestOpen7z_EmptyFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "empty.7z")
 
@@ -41,14 +41,14 @@ func TestExtractFrom7z_EmptyFile(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFrom7z(path, testExtensions)
+	_, err = open7z(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for empty file")
 	}
 }
 
-// TestExtractFrom7z_PartialMagic tests files with partial 7z magic bytes
-func TestExtractFrom7z_PartialMagic(t *testing.T) {
+// TestOpen7z_PartialMagic tests files with partial 7z magic bytes
+func TestOpen7z_PartialMagic(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "partial.7z")
 
@@ -59,14 +59,14 @@ func TestExtractFrom7z_PartialMagic(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFrom7z(path, testExtensions)
+	_, err = open7z(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for file with partial magic bytes")
 	}
 }
 
-// TestExtractFrom7z_CorruptedArchive tests handling of corrupted archives
-func TestExtractFrom7z_CorruptedArchive(t *testing.T) {
+// TestOpen7z_CorruptedArchive tests handling of corrupted archives
+func TestOpen7z_CorruptedArchive(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "corrupt.7z")
 
@@ -77,7 +77,7 @@ func TestExtractFrom7z_CorruptedArchive(t *testing.T) {
 		t.Fatalf("Failed to create test file: %v", err)
 	}
 
-	_, _, err = extractFrom7z(path, testExtensions)
+	_, err = open7z(path, romMatch(testExtensions))
 	if err == nil {
 		t.Error("Expected error for corrupted 7z file")
 	}
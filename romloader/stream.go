@@ -0,0 +1,242 @@
+package romloader
+
+import (
+	"archive/tar"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+// streamFormat is a compression format holding a single stream, which is
+// either a ROM or a tar archive of ROMs.
+type streamFormat struct {
+	name      string   // Used in errors
+	ext       string   // Extension of a plain stream
+	tarExts   []string // Extensions of a compressed tar archive
+	newReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	streamGzip = &streamFormat{
+		name:    "gzip",
+		ext:     ".gz",
+		tarExts: []string{".tar.gz", ".tgz"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	}
+	streamZstd = &streamFormat{
+		name:    "zstd",
+		ext:     ".zst",
+		tarExts: []string{".tar.zst", ".tzst"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return nil, err
+			}
+			return d.IOReadCloser(), nil
+		},
+	}
+	streamXz = &streamFormat{
+		name:    "xz",
+		ext:     ".xz",
+		tarExts: []string{".tar.xz", ".txz"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			xr, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(xr), nil
+		},
+	}
+	streamBzip2 = &streamFormat{
+		name:    "bzip2",
+		ext:     ".bz2",
+		tarExts: []string{".tar.bz2", ".tbz2", ".tbz"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		},
+	}
+	streamLz4 = &streamFormat{
+		name:    "lz4",
+		ext:     ".lz4",
+		tarExts: []string{".tar.lz4"},
+		newReader: func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(lz4.NewReader(r)), nil
+		},
+	}
+)
+
+// streamFormats maps detected formats to their stream format.
+var streamFormats = map[formatType]*streamFormat{
+	formatGzip:  streamGzip,
+	formatZstd:  streamZstd,
+	formatXz:    streamXz,
+	formatBzip2: streamBzip2,
+	formatLz4:   streamLz4,
+}
+
+// isTar reports whether a path holds a compressed tar archive.
+func (sf *streamFormat) isTar(path string) bool {
+	lowerPath := strings.ToLower(path)
+	for _, ext := range sf.tarExts {
+		if strings.HasSuffix(lowerPath, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentName is the name of a plain stream's content: the base name
+// without the compression extension.
+func (sf *streamFormat) contentName(path string) string {
+	name := filepath.Base(path)
+	if strings.HasSuffix(strings.ToLower(name), sf.ext) {
+		name = name[:len(name)-len(sf.ext)]
+	}
+	return name
+}
+
+// openStream opens the first matching file in a compressed tar archive,
+// or the content of a plain compressed stream
+func openStream(path string, match matchFunc, sf *streamFormat) (*entryReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", sf.name, err)
+	}
+
+	rc, err := sf.newReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to create %s reader: %w", sf.name, err)
+	}
+	closers := []io.Closer{rc, f}
+
+	// Plain stream - assume the decompressed content is the ROM
+	if !sf.isTar(path) {
+		return &entryReader{
+			Reader:  rc,
+			name:    sf.contentName(path),
+			size:    -1,
+			closers: closers,
+		}, nil
+	}
+
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rc.Close()
+			f.Close()
+			return nil, fmt.Errorf("failed to read tar entry: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !match(header.Name) {
+			continue
+		}
+
+		return &entryReader{
+			Reader:  tr,
+			name:    filepath.Base(header.Name),
+			size:    header.Size,
+			closers: closers,
+		}, nil
+	}
+
+	rc.Close()
+	f.Close()
+	return nil, ErrNoFile
+}
+
+// listStream lists the ROM files in a compressed tar archive, or the
+// single file in a plain stream. Tar has no checksums and plain streams
+// other than gzip don't record the size of their content.
+func listStream(path string, extensions []string, sf *streamFormat) ([]Entry, error) {
+	if !sf.isTar(path) {
+		name := sf.contentName(path)
+		if !contentMatch(name, extensions) {
+			return nil, nil
+		}
+		if sf == streamGzip {
+			return listGzip(path)
+		}
+		return []Entry{{Name: name}}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", sf.name, err)
+	}
+	defer f.Close()
+
+	rc, err := sf.newReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s reader: %w", sf.name, err)
+	}
+	defer rc.Close()
+
+	var entries []Entry
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar entry: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !isROMFile(header.Name, extensions) {
+			continue
+		}
+		entries = append(entries, Entry{Name: header.Name, Size: header.Size})
+	}
+	return entries, nil
+}
+
+// contentMatch reports whether the content of a file holding a single
+// image, such as a plain gzip, matches extensions. Content named without
+// an extension is assumed to be a ROM, as Load does.
+func contentMatch(name string, extensions []string) bool {
+	return filepath.Ext(name) == "" || isROMFile(name, extensions)
+}
+
+// listGzip lists the single file in a plain gzip. The gzip trailer holds
+// the CRC32 and size of its content.
+func listGzip(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gzip: %w", err)
+	}
+	defer f.Close()
+
+	// Trailer: CRC32 then size mod 2^32, both little-endian
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat gzip: %w", err)
+	}
+	trailer := make([]byte, 8)
+	if _, err := f.ReadAt(trailer, info.Size()-8); err != nil {
+		return nil, fmt.Errorf("failed to read gzip trailer: %w", err)
+	}
+	return []Entry{{
+		Name:     streamGzip.contentName(path),
+		Size:     int64(binary.LittleEndian.Uint32(trailer[4:])),
+		CRC32:    binary.LittleEndian.Uint32(trailer),
+		HasCRC32: true,
+	}}, nil
+}
@@ -3,37 +3,80 @@ package romloader
 import (
 	"archive/zip"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 )
 
-// extractFromZIP extracts the first ROM file from a ZIP archive
-func extractFromZIP(path string, extensions []string) ([]byte, string, error) {
-	r, err := zip.OpenReader(path)
+// openZIP opens the first matching file in a ZIP archive. Files stored
+// without compression can be read at random from the archive.
+func openZIP(path string, match matchFunc) (*entryReader, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to open zip: %w", err)
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat zip: %w", err)
+	}
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open zip: %w", err)
 	}
-	defer r.Close()
 
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
+	for _, zf := range r.File {
+		if zf.FileInfo().IsDir() {
 			continue
 		}
-		if !isROMFile(f.Name, extensions) {
+		if !match(zf.Name) {
 			continue
 		}
 
-		rc, err := f.Open()
+		rc, err := zf.Open()
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to open %s in archive: %w", f.Name, err)
+			f.Close()
+			return nil, fmt.Errorf("failed to open %s in archive: %w", zf.Name, err)
 		}
-		defer rc.Close()
-
-		data, err := limitedRead(rc)
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to read %s: %w", f.Name, err)
+		e := &entryReader{
+			Reader:  rc,
+			name:    filepath.Base(zf.Name),
+			size:    int64(zf.UncompressedSize64),
+			closers: []io.Closer{rc, f},
 		}
-		return data, filepath.Base(f.Name), nil
+		if zf.Method == zip.Store {
+			if offset, err := zf.DataOffset(); err == nil {
+				e.at = io.NewSectionReader(f, offset, e.size)
+			}
+		}
+		return e, nil
 	}
 
-	return nil, "", ErrNoFile
+	f.Close()
+	return nil, ErrNoFile
+}
+
+// listZIP lists the ROM files in a ZIP archive. ZIP headers carry the
+// CRC32 of every file.
+func listZIP(path string, extensions []string) ([]Entry, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer r.Close()
+
+	var entries []Entry
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !isROMFile(f.Name, extensions) {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:     f.Name,
+			Size:     int64(f.UncompressedSize64),
+			CRC32:    f.CRC32,
+			HasCRC32: true,
+		})
+	}
+	return entries, nil
 }
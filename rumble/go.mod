@@ -0,0 +1,5 @@
+module github.com/user-none/eblitui/rumble
+
+go 1.25.7
+
+require github.com/user-none/eblitui/coreif v0.5.0
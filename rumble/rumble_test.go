@@ -1,4 +1,4 @@
-package standalone
+package rumble
 
 import (
 	"os"
@@ -6,7 +6,7 @@ import (
 	"testing"
 )
 
-func TestParseRumbleFile(t *testing.T) {
+func TestParseFile(t *testing.T) {
 	content := `cheats = "3"
 cheat0_address = "49152"
 cheat0_memory_search_size = "3"
@@ -42,7 +42,7 @@ cheat2_rumble_secondary_duration = "150"
 		t.Fatal(err)
 	}
 
-	entries, err := ParseRumbleFile(path)
+	entries, err := ParseFile(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -117,7 +117,7 @@ cheat2_rumble_secondary_duration = "150"
 	}
 }
 
-func TestParseRumbleFileBigEndian(t *testing.T) {
+func TestParseFileBigEndian(t *testing.T) {
 	content := `cheats = "2"
 cheat0_big_endian = "true"
 cheat0_address = "100"
@@ -138,7 +138,7 @@ cheat1_rumble_primary_duration = "200"
 		t.Fatal(err)
 	}
 
-	entries, err := ParseRumbleFile(path)
+	entries, err := ParseFile(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -153,10 +153,10 @@ cheat1_rumble_primary_duration = "200"
 	}
 }
 
-func TestRumbleEngineByteSwapPerEntry(t *testing.T) {
+func TestEngineByteSwapPerEntry(t *testing.T) {
 	// Two entries: one big-endian CHT, one little-endian CHT.
 	// System is big-endian. The little-endian entry needs swap, the big-endian does not.
-	entries := []RumbleEntry{
+	entries := []Entry{
 		{
 			Address:          100,
 			MemorySearchSize: 3,
@@ -175,7 +175,7 @@ func TestRumbleEngineByteSwapPerEntry(t *testing.T) {
 		},
 	}
 
-	engine := NewRumbleEngine(entries, true) // system is big-endian
+	engine := NewEngine(entries, true) // system is big-endian
 	mi := newMockMemoryInspector()
 
 	// For entry 0 (no swap): value at addr 100
@@ -199,7 +199,7 @@ func TestRumbleEngineByteSwapPerEntry(t *testing.T) {
 	}
 }
 
-func TestParseRumbleFileMissingFields(t *testing.T) {
+func TestParseFileMissingFields(t *testing.T) {
 	content := `cheats = "1"
 cheat0_address = "1000"
 cheat0_rumble_type = "1"
@@ -212,7 +212,7 @@ cheat0_rumble_primary_duration = "200"
 		t.Fatal(err)
 	}
 
-	entries, err := ParseRumbleFile(path)
+	entries, err := ParseFile(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -238,7 +238,7 @@ cheat0_rumble_primary_duration = "200"
 	}
 }
 
-func TestParseRumbleFileNoCheatsKey(t *testing.T) {
+func TestParseFileNoCheatsKey(t *testing.T) {
 	content := `cheat0_address = "1000"`
 	dir := t.TempDir()
 	path := filepath.Join(dir, "test.cht")
@@ -246,14 +246,14 @@ func TestParseRumbleFileNoCheatsKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err := ParseRumbleFile(path)
+	_, err := ParseFile(path)
 	if err == nil {
 		t.Fatal("expected error for missing cheats key")
 	}
 }
 
-func TestParseRumbleFileNotFound(t *testing.T) {
-	_, err := ParseRumbleFile("/nonexistent/path.cht")
+func TestParseFileNotFound(t *testing.T) {
+	_, err := ParseFile("/nonexistent/path.cht")
 	if err == nil {
 		t.Fatal("expected error for missing file")
 	}
@@ -464,8 +464,8 @@ func TestReadMemoryValue16BitByteSwap(t *testing.T) {
 	}
 }
 
-func TestRumbleEngineWarmup(t *testing.T) {
-	entries := []RumbleEntry{{
+func TestEngineWarmup(t *testing.T) {
+	entries := []Entry{{
 		Address:          100,
 		MemorySearchSize: 3,
 		RumbleType:       1, // changes
@@ -473,7 +473,7 @@ func TestRumbleEngineWarmup(t *testing.T) {
 		PrimaryDuration:  200,
 	}}
 
-	engine := NewRumbleEngine(entries, false)
+	engine := NewEngine(entries, false)
 	mi := newMockMemoryInspector()
 
 	// During warmup, no events should fire even if value changes
@@ -487,8 +487,8 @@ func TestRumbleEngineWarmup(t *testing.T) {
 	}
 }
 
-func TestRumbleEngineFiresAfterWarmup(t *testing.T) {
-	entries := []RumbleEntry{{
+func TestEngineFiresAfterWarmup(t *testing.T) {
+	entries := []Entry{{
 		Address:          100,
 		MemorySearchSize: 3,
 		RumbleType:       1, // changes
@@ -497,7 +497,7 @@ func TestRumbleEngineFiresAfterWarmup(t *testing.T) {
 		PrimaryDuration:  200,
 	}}
 
-	engine := NewRumbleEngine(entries, false)
+	engine := NewEngine(entries, false)
 	mi := newMockMemoryInspector()
 	mi.set8(100, 0)
 
@@ -517,8 +517,8 @@ func TestRumbleEngineFiresAfterWarmup(t *testing.T) {
 	}
 }
 
-func TestRumbleEngineNoFireWhenUnchanged(t *testing.T) {
-	entries := []RumbleEntry{{
+func TestEngineNoFireWhenUnchanged(t *testing.T) {
+	entries := []Entry{{
 		Address:          100,
 		MemorySearchSize: 3,
 		RumbleType:       1, // changes
@@ -526,7 +526,7 @@ func TestRumbleEngineNoFireWhenUnchanged(t *testing.T) {
 		PrimaryDuration:  200,
 	}}
 
-	engine := NewRumbleEngine(entries, false)
+	engine := NewEngine(entries, false)
 	mi := newMockMemoryInspector()
 	mi.set8(100, 42)
 
@@ -542,8 +542,8 @@ func TestRumbleEngineNoFireWhenUnchanged(t *testing.T) {
 	}
 }
 
-func TestRumbleEngineReset(t *testing.T) {
-	entries := []RumbleEntry{{
+func TestEngineReset(t *testing.T) {
+	entries := []Entry{{
 		Address:          100,
 		MemorySearchSize: 3,
 		RumbleType:       1,
@@ -551,7 +551,7 @@ func TestRumbleEngineReset(t *testing.T) {
 		PrimaryDuration:  200,
 	}}
 
-	engine := NewRumbleEngine(entries, false)
+	engine := NewEngine(entries, false)
 	mi := newMockMemoryInspector()
 	mi.set8(100, 0)
 
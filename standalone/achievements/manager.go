@@ -397,6 +397,19 @@ func (m *Manager) Idle() {
 	m.client.Idle()
 }
 
+// Reset resets achievement runtime state after the emulator is reset
+func (m *Manager) Reset() {
+	m.mu.Lock()
+	gameLoaded := m.gameLoaded
+	m.mu.Unlock()
+
+	if !gameLoaded {
+		return
+	}
+
+	m.client.Reset()
+}
+
 // UnloadGame unloads the current game
 func (m *Manager) UnloadGame() {
 	m.mu.Lock()
@@ -11,6 +11,7 @@ import (
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/user-none/eblitui/coreif"
+	"github.com/user-none/eblitui/romloader"
 	"github.com/user-none/eblitui/standalone/achievements"
 	"github.com/user-none/eblitui/standalone/display"
 	"github.com/user-none/eblitui/standalone/metadata"
@@ -192,6 +193,11 @@ func newApp(factory coreif.CoreFactory, info coreif.SystemInfo) (*App, error) {
 	app.screenshotManager = NewScreenshotManager(app.notification)
 	app.inputManager = NewInputManager()
 	app.shaderManager = shader.NewManager(info.PixelAspectRatio)
+	if dir, err := storage.GetShadersDir(); err == nil {
+		for _, loadErr := range shader.LoadUserShaders(dir) {
+			log.Printf("Warning: failed to load user shader %v", loadErr)
+		}
+	}
 	app.searchOverlay = NewSearchOverlay(func(text string) {
 		if app.state == StateLibrary {
 			app.libraryScreen.SetSearchText(text)
@@ -242,6 +248,9 @@ func newApp(factory coreif.CoreFactory, info coreif.SystemInfo) (*App, error) {
 		return app, nil
 	}
 
+	// Size the shared audio device buffer before anything opens it
+	setOtoBufferSize(newAudioLatency(storage.AudioLatencyMs(app.config.Audio)).osBuffer)
+
 	// Create achievement manager with config
 	app.achievementManager = achievements.NewManager(app.notification, app.config, app.systemInfo.Name, Version, uint32(app.systemInfo.ConsoleID))
 
@@ -333,6 +342,7 @@ func newApp(factory coreif.CoreFactory, info coreif.SystemInfo) (*App, error) {
 		app.library,
 		app.scanScreen,
 		app.systemInfo.Extensions,
+		loadOptions(app.factory),
 		app.metadata,
 		app.systemInfo.ConsoleID,
 		func() { app.rebuildCurrentScreen() }, // onProgress
@@ -752,7 +762,7 @@ func (a *App) Draw(screen *ebiten.Image) {
 	a.shaderManager.IncrementFrame()
 
 	// Determine which shaders to apply based on state and application mode
-	shaderIDs := a.getActiveShaders()
+	shaderIDs, shaderParams := a.getActiveShaders()
 
 	if len(shaderIDs) == 0 {
 		// No shaders/effects - direct draw
@@ -764,6 +774,9 @@ func (a *App) Draw(screen *ebiten.Image) {
 		default:
 			a.ui.Draw(screen)
 		}
+		if a.state == StatePlaying {
+			a.gameplay.DrawDiagnostics(screen)
+		}
 		a.notification.Draw(screen)
 		if a.state == StateLibrary {
 			a.searchOverlay.Draw(screen)
@@ -805,14 +818,17 @@ func (a *App) Draw(screen *ebiten.Image) {
 			a.gameplay.DrawAchievementOverlay(processed)
 		}
 
-		// Notification drawn after effects, before shaders
+		// Diagnostics and notification drawn after effects, before shaders
+		if a.state == StatePlaying {
+			a.gameplay.DrawDiagnostics(processed)
+		}
 		a.notification.Draw(processed)
 		if a.state == StateLibrary {
 			a.searchOverlay.Draw(processed)
 		}
 
 		// Apply shader chain to final screen (preprocess effects are skipped internally)
-		a.shaderManager.ApplyShaders(screen, processed, shaderIDs, a.systemInfo.MaxScreenHeight)
+		a.shaderManager.ApplyShaders(screen, processed, shaderIDs, shaderParams, a.systemInfo.MaxScreenHeight)
 	}
 
 	// Take screenshot if pending (after everything is drawn)
@@ -951,6 +967,11 @@ func (a *App) GetExtensions() []string {
 	return a.systemInfo.Extensions
 }
 
+// GetLoadOptions returns the romloader options for the core's ROMs
+func (a *App) GetLoadOptions() romloader.Options {
+	return loadOptions(a.factory)
+}
+
 // ShowNotification shows a brief notification message
 func (a *App) ShowNotification(msg string) {
 	a.notification.ShowDefault(msg)
@@ -1034,6 +1055,7 @@ func (a *App) handleDeleteAndContinue() {
 			a.library,
 			a.scanScreen,
 			a.systemInfo.Extensions,
+			loadOptions(a.factory),
 			a.metadata,
 			a.systemInfo.ConsoleID,
 			func() { a.rebuildCurrentScreen() },
@@ -1129,6 +1151,7 @@ func (a *App) handleResetAndContinue() {
 			a.library,
 			a.scanScreen,
 			a.systemInfo.Extensions,
+			loadOptions(a.factory),
 			a.metadata,
 			a.systemInfo.ConsoleID,
 			func() { a.rebuildCurrentScreen() },
@@ -1193,6 +1216,7 @@ func (a *App) handleLibraryResetAndContinue() {
 			a.library,
 			a.scanScreen,
 			a.systemInfo.Extensions,
+			loadOptions(a.factory),
 			a.metadata,
 			a.systemInfo.ConsoleID,
 			func() { a.rebuildCurrentScreen() },
@@ -1233,6 +1257,11 @@ func (a *App) preloadConfiguredShaders() {
 	for _, id := range a.config.Shaders.GameShaders {
 		allShaders[id] = true
 	}
+	for _, preset := range a.config.Shaders.Presets {
+		for _, id := range preset.Shaders {
+			allShaders[id] = true
+		}
+	}
 
 	ids := make([]string, 0, len(allShaders))
 	for id := range allShaders {
@@ -1241,13 +1270,20 @@ func (a *App) preloadConfiguredShaders() {
 	a.shaderManager.PreloadShaders(ids)
 }
 
-// getActiveShaders returns the shader IDs to apply for the current state
-func (a *App) getActiveShaders() []string {
+// getActiveShaders returns the shader IDs and parameter values to apply for
+// the current state. During gameplay a game's selected preset replaces the
+// global game shader list.
+func (a *App) getActiveShaders() ([]string, map[string]map[string]float64) {
 	switch a.state {
 	case StatePlaying:
-		return a.config.Shaders.GameShaders
+		if game := a.library.GetGame(a.gameplay.CurrentGameCRC()); game != nil {
+			if preset := a.config.Shaders.FindPreset(game.Settings.ShaderPreset); preset != nil {
+				return preset.Shaders, preset.Params
+			}
+		}
+		return a.config.Shaders.GameShaders, a.config.Shaders.Params
 	default:
-		return a.config.Shaders.UIShaders
+		return a.config.Shaders.UIShaders, a.config.Shaders.Params
 	}
 }
 
@@ -10,8 +10,51 @@ import (
 
 const audioSampleRate = 48000
 
-// ringBufferCapacity is ~167ms at 48kHz stereo 16-bit (~32KB).
-const ringBufferCapacity = 32768
+// audioBytesPerMs is the byte rate of 48kHz stereo 16-bit audio per millisecond.
+const audioBytesPerMs = audioSampleRate * 2 * 2 / 1000
+
+// defaultAudioLatencyMs is the target latency used when no setting applies.
+const defaultAudioLatencyMs = 50
+
+// audioLatency holds the buffer sizes derived from a target output latency.
+// All sizes scale together so the ADT thresholds always sit inside the
+// ring and player buffers.
+type audioLatency struct {
+	osBuffer     time.Duration // oto context (OS) buffer
+	playerBuffer int           // oto player internal buffer in bytes
+	ringBuffer   int           // Ring buffer capacity in bytes
+	adtMin       int           // ADT: speed up below this many buffered bytes
+	adtMax       int           // ADT: slow down above this many buffered bytes
+}
+
+// newAudioLatency derives buffer sizes for the given target latency in ms.
+// The 50ms default yields a 50ms OS buffer, a ~100ms player buffer, a
+// ~167ms ring buffer and ADT thresholds of ~3 and ~6 frames at 60fps.
+func newAudioLatency(ms int) audioLatency {
+	if ms <= 0 {
+		ms = defaultAudioLatencyMs
+	}
+	adtMin := (ms * audioBytesPerMs) &^ 3 // Keep stereo 16-bit frame alignment
+	return audioLatency{
+		osBuffer:     time.Duration(ms) * time.Millisecond,
+		playerBuffer: adtMin * 2,
+		ringBuffer:   (32768 * ms / defaultAudioLatencyMs) &^ 3,
+		adtMin:       adtMin,
+		adtMax:       adtMin * 2,
+	}
+}
+
+// appliedAudioLatency returns the sizes in effect for a configured latency
+// given the OS buffer the oto context was created with. That buffer is
+// fixed until restart, so every size follows it rather than the setting;
+// otherwise ADT thresholds for one latency would pair with an OS buffer
+// for another.
+func appliedAudioLatency(configuredMs int, ctxBuffer time.Duration) audioLatency {
+	if ctxBuffer <= 0 {
+		return newAudioLatency(configuredMs)
+	}
+	return newAudioLatency(int(ctxBuffer / time.Millisecond))
+}
 
 // AudioPlayer manages audio playback via oto.
 // It writes int16 stereo samples to a ring buffer which oto's player
@@ -20,15 +63,26 @@ type AudioPlayer struct {
 	player     *oto.Player
 	ringBuffer *AudioRingBuffer
 	audioBytes []byte // Pre-allocated buffer for int16-to-byte conversion
+	latency    audioLatency
+	setting    int // Configured latency in ms, applied on restart
 }
 
 // oto context singleton — shared between game audio and notification audio
 var (
-	otoCtx      *oto.Context
-	otoInitOnce sync.Once
-	otoInitErr  error
+	otoCtx        *oto.Context
+	otoInitOnce   sync.Once
+	otoInitErr    error
+	otoBufferSize = time.Duration(defaultAudioLatencyMs) * time.Millisecond
+	otoCtxBuffer  time.Duration // OS buffer size the context was created with
 )
 
+// setOtoBufferSize sets the OS buffer size used when the oto context is
+// created. The context is a process-wide singleton, so this only has an
+// effect before the first call to ensureOtoContext.
+func setOtoBufferSize(d time.Duration) {
+	otoBufferSize = d
+}
+
 // ensureOtoContext initializes the oto audio context on first use.
 func ensureOtoContext() (*oto.Context, error) {
 	otoInitOnce.Do(func() {
@@ -36,13 +90,14 @@ func ensureOtoContext() (*oto.Context, error) {
 			SampleRate:   audioSampleRate,
 			ChannelCount: 2,
 			Format:       oto.FormatSignedInt16LE,
-			BufferSize:   50 * time.Millisecond, // Reduce OS AudioQueue from default ~100ms
+			BufferSize:   otoBufferSize, // Reduce OS AudioQueue from default ~100ms
 		}
 		var readyChan chan struct{}
 		otoCtx, readyChan, otoInitErr = oto.NewContext(op)
 		if otoInitErr != nil {
 			return
 		}
+		otoCtxBuffer = op.BufferSize
 		<-readyChan
 	})
 	return otoCtx, otoInitErr
@@ -51,18 +106,21 @@ func ensureOtoContext() (*oto.Context, error) {
 // NewAudioPlayer creates and initializes audio playback via oto.
 // The volume parameter sets the initial volume before playback starts,
 // preventing audio pops when muted (matching iOS behavior).
-func NewAudioPlayer(volume float64) (*AudioPlayer, error) {
+// latencyMs is the configured latency; buffers are sized for the latency
+// the oto context was created with, which only changes on restart.
+func NewAudioPlayer(volume float64, latencyMs int) (*AudioPlayer, error) {
 	ctx, err := ensureOtoContext()
 	if err != nil {
 		return nil, fmt.Errorf("oto audio not available: %w", err)
 	}
 
-	rb := NewAudioRingBuffer(ringBufferCapacity)
+	latency := appliedAudioLatency(latencyMs, otoCtxBuffer)
+	rb := NewAudioRingBuffer(latency.ringBuffer)
 	player := ctx.NewPlayer(rb)
-	// Reduce mux player buffer from default 96000 bytes (0.5s) to ~19200 bytes
-	// (~50ms). Prevents large internal buffer accumulation at startup that
-	// causes ADT to over-correct.
-	player.SetBufferSize(19200)
+	// Reduce mux player buffer from default 96000 bytes (0.5s). Prevents
+	// large internal buffer accumulation at startup that causes ADT to
+	// over-correct.
+	player.SetBufferSize(latency.playerBuffer)
 	// Set volume before Play() to avoid pop when muted
 	player.SetVolume(volume)
 	player.Play()
@@ -71,6 +129,8 @@ func NewAudioPlayer(volume float64) (*AudioPlayer, error) {
 		player:     player,
 		ringBuffer: rb,
 		audioBytes: make([]byte, 0, 4096),
+		latency:    latency,
+		setting:    latencyMs,
 	}, nil
 }
 
@@ -100,6 +160,39 @@ func (a *AudioPlayer) GetBufferLevel() int {
 	return a.ringBuffer.Buffered() + a.player.BufferedSize()
 }
 
+// AdjustSleep scales an ADT frame sleep based on the current buffer level:
+// shorter when the buffer is draining, longer when it is filling up.
+func (a *AudioPlayer) AdjustSleep(sleepTime time.Duration) time.Duration {
+	bufferLevel := a.GetBufferLevel()
+	if bufferLevel < a.latency.adtMin {
+		return time.Duration(float64(sleepTime) * 0.9)
+	} else if bufferLevel > a.latency.adtMax {
+		return time.Duration(float64(sleepTime) * 1.1)
+	}
+	return sleepTime
+}
+
+// AudioStats holds audio playback diagnostics for the diagnostics overlay.
+type AudioStats struct {
+	BufferedMs int    // Audio currently queued (ring + player buffer)
+	LatencyMs  int    // Latency in effect, from the OS buffer the app started with
+	SettingMs  int    // Configured latency, which takes effect on restart
+	Underruns  uint64 // Times the output found the ring buffer empty
+	Overruns   uint64 // Times queued audio was dropped because the ring buffer was full
+}
+
+// Stats returns a snapshot of playback diagnostics.
+func (a *AudioPlayer) Stats() AudioStats {
+	underruns, overruns := a.ringBuffer.Counters()
+	return AudioStats{
+		BufferedMs: a.GetBufferLevel() / audioBytesPerMs,
+		LatencyMs:  int(a.latency.osBuffer / time.Millisecond),
+		SettingMs:  a.setting,
+		Underruns:  underruns,
+		Overruns:   overruns,
+	}
+}
+
 // ClearQueue flushes all buffered audio from the ring buffer.
 // Used when entering rewind mode to prevent stale audio playback.
 func (a *AudioPlayer) ClearQueue() {
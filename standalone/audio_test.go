@@ -0,0 +1,53 @@
+package standalone
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAudioLatency_DefaultMatchesLegacySizes(t *testing.T) {
+	l := newAudioLatency(defaultAudioLatencyMs)
+
+	if l.osBuffer != 50*time.Millisecond {
+		t.Errorf("osBuffer = %v, want 50ms", l.osBuffer)
+	}
+	if l.playerBuffer != 19200 {
+		t.Errorf("playerBuffer = %d, want 19200", l.playerBuffer)
+	}
+	if l.ringBuffer != 32768 {
+		t.Errorf("ringBuffer = %d, want 32768", l.ringBuffer)
+	}
+	if l.adtMin != 9600 || l.adtMax != 19200 {
+		t.Errorf("adt = %d/%d, want 9600/19200", l.adtMin, l.adtMax)
+	}
+}
+
+func TestNewAudioLatency_Scaling(t *testing.T) {
+	for _, ms := range []int{20, 32, 50, 100, 250} {
+		l := newAudioLatency(ms)
+		if l.adtMin >= l.adtMax {
+			t.Errorf("%dms: adtMin %d >= adtMax %d", ms, l.adtMin, l.adtMax)
+		}
+		if l.adtMax >= l.ringBuffer {
+			t.Errorf("%dms: adtMax %d >= ringBuffer %d", ms, l.adtMax, l.ringBuffer)
+		}
+		if l.ringBuffer%4 != 0 || l.adtMin%4 != 0 {
+			t.Errorf("%dms: sizes not frame aligned (ring %d, adtMin %d)", ms, l.ringBuffer, l.adtMin)
+		}
+	}
+}
+
+func TestNewAudioLatency_InvalidFallsBackToDefault(t *testing.T) {
+	if newAudioLatency(0) != newAudioLatency(defaultAudioLatencyMs) {
+		t.Error("expected zero latency to use the default")
+	}
+}
+
+func TestAppliedAudioLatency_FollowsContext(t *testing.T) {
+	if appliedAudioLatency(20, 50*time.Millisecond) != newAudioLatency(50) {
+		t.Error("expected sizes from the context's OS buffer, not the setting")
+	}
+	if appliedAudioLatency(20, 0) != newAudioLatency(20) {
+		t.Error("expected the setting before a context exists")
+	}
+}
@@ -18,6 +18,10 @@ type AudioRingBuffer struct {
 	mu       sync.Mutex
 	cond     *sync.Cond
 	closed   bool
+
+	// Diagnostics counters
+	underruns uint64 // Reads that found the buffer empty
+	overruns  uint64 // Writes that dropped data to make room
 }
 
 // NewAudioRingBuffer creates a ring buffer with the given capacity in bytes.
@@ -45,14 +49,19 @@ func (rb *AudioRingBuffer) Write(p []byte) {
 		return
 	}
 
+	// If we need more space, drop oldest data
+	overflow := rb.count + n - rb.capacity
+	if overflow > 0 {
+		rb.overruns++
+	}
+
 	// If data is larger than capacity, only write the last capacity bytes
 	if n > rb.capacity {
 		p = p[n-rb.capacity:]
 		n = rb.capacity
+		overflow = rb.count
 	}
 
-	// If we need more space, drop oldest data
-	overflow := rb.count + n - rb.capacity
 	if overflow > 0 {
 		rb.readPos = (rb.readPos + overflow) % rb.capacity
 		rb.count -= overflow
@@ -80,6 +89,9 @@ func (rb *AudioRingBuffer) Read(p []byte) (int, error) {
 	defer rb.mu.Unlock()
 
 	// Wait for data
+	if rb.count == 0 && !rb.closed {
+		rb.underruns++
+	}
 	for rb.count == 0 {
 		if rb.closed {
 			return 0, io.EOF
@@ -114,6 +126,14 @@ func (rb *AudioRingBuffer) Buffered() int {
 	return rb.count
 }
 
+// Counters returns the number of underruns (reads that found the buffer
+// empty) and overruns (writes that dropped old data) since creation.
+func (rb *AudioRingBuffer) Counters() (underruns, overruns uint64) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.underruns, rb.overruns
+}
+
 // Clear resets the buffer, discarding all data.
 func (rb *AudioRingBuffer) Clear() {
 	rb.mu.Lock()
@@ -4,6 +4,7 @@ import (
 	"io"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestAudioRingBuffer_BasicWriteRead(t *testing.T) {
@@ -237,3 +238,58 @@ func TestAudioRingBuffer_WriteAfterClose(t *testing.T) {
 		t.Fatalf("expected 0 buffered after write to closed buffer, got %d", rb.Buffered())
 	}
 }
+
+func TestAudioRingBuffer_Counters(t *testing.T) {
+	rb := NewAudioRingBuffer(8)
+
+	// Overflow twice
+	rb.Write([]byte{1, 2, 3, 4, 5, 6})
+	rb.Write([]byte{7, 8, 9, 10})
+	rb.Write([]byte{11, 12, 13, 14})
+
+	underruns, overruns := rb.Counters()
+	if overruns != 2 {
+		t.Fatalf("expected 2 overruns, got %d", overruns)
+	}
+	if underruns != 0 {
+		t.Fatalf("expected 0 underruns, got %d", underruns)
+	}
+
+	// Drain, then read from an empty buffer (unblocked by a later write)
+	out := make([]byte, 8)
+	rb.Read(out)
+
+	done := make(chan struct{})
+	go func() {
+		rb.Read(out)
+		close(done)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if u, _ := rb.Counters(); u == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the underrun")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	rb.Write([]byte{1, 2})
+	<-done
+
+	underruns, _ = rb.Counters()
+	if underruns != 1 {
+		t.Fatalf("expected 1 underrun, got %d", underruns)
+	}
+}
+
+func TestAudioRingBuffer_CountersSurviveClear(t *testing.T) {
+	rb := NewAudioRingBuffer(4)
+	rb.Write([]byte{1, 2, 3, 4, 5, 6})
+	rb.Clear()
+
+	_, overruns := rb.Counters()
+	if overruns != 1 {
+		t.Fatalf("expected 1 overrun after clear, got %d", overruns)
+	}
+}
@@ -0,0 +1,76 @@
+package standalone
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/user-none/eblitui/standalone/style"
+)
+
+// DiagnosticsOverlay draws a small top-left panel with audio buffer
+// statistics during gameplay. Used to tune the audio latency setting.
+type DiagnosticsOverlay struct {
+	// Pre-allocated background (avoid per-frame allocations)
+	bg *ebiten.Image
+}
+
+// NewDiagnosticsOverlay creates a new diagnostics overlay
+func NewDiagnosticsOverlay() *DiagnosticsOverlay {
+	return &DiagnosticsOverlay{}
+}
+
+// diagnosticsLines formats audio stats into the overlay text lines.
+func diagnosticsLines(stats AudioStats) []string {
+	return []string{
+		fmt.Sprintf("Audio latency: %d ms", stats.LatencyMs),
+		fmt.Sprintf("Latency setting: %d ms", stats.SettingMs),
+		fmt.Sprintf("Buffered: %d ms", stats.BufferedMs),
+		fmt.Sprintf("Underruns: %d", stats.Underruns),
+		fmt.Sprintf("Overruns: %d", stats.Overruns),
+	}
+}
+
+// Draw renders the overlay with the given audio stats
+func (d *DiagnosticsOverlay) Draw(screen *ebiten.Image, stats AudioStats) {
+	lines := diagnosticsLines(stats)
+	face := *style.FontFace()
+
+	// Measure all lines to size the background
+	var maxWidth, lineHeight float64
+	for _, line := range lines {
+		w, h := text.Measure(line, face, 0)
+		if w > maxWidth {
+			maxWidth = w
+		}
+		if h > lineHeight {
+			lineHeight = h
+		}
+	}
+
+	padding := style.OverlayPadding
+	margin := style.OverlayMargin
+	bgWidth := int(maxWidth) + padding*2
+	bgHeight := int(lineHeight)*len(lines) + padding*2
+
+	// Reuse or create background image
+	if d.bg == nil || d.bg.Bounds().Dx() < bgWidth || d.bg.Bounds().Dy() < bgHeight {
+		d.bg = ebiten.NewImage(bgWidth, bgHeight)
+	}
+	d.bg.Clear()
+	overlayBg := style.OverlayBackground
+	overlayBg.A = 153 // 60% opacity
+	d.bg.Fill(overlayBg)
+
+	opts := &ebiten.DrawImageOptions{}
+	opts.GeoM.Translate(float64(margin), float64(margin))
+	screen.DrawImage(d.bg.SubImage(image.Rect(0, 0, bgWidth, bgHeight)).(*ebiten.Image), opts)
+
+	for i, line := range lines {
+		textOpts := &text.DrawOptions{}
+		textOpts.GeoM.Translate(float64(margin+padding), float64(margin+padding)+lineHeight*float64(i))
+		textOpts.ColorScale.ScaleWithColor(style.Text)
+		text.Draw(screen, line, face, textOpts)
+	}
+}
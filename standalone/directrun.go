@@ -35,10 +35,11 @@ type directRunner struct {
 func RunDirect(factory coreif.CoreFactory, romPath, regionStr string, options map[string]string, bios map[string][]byte) error {
 	systemInfo := factory.SystemInfo()
 
-	romData, _, err := romloader.Load(romPath, systemInfo.Extensions)
+	res, err := romloader.LoadWith(romPath, systemInfo.Extensions, loadOptions(factory))
 	if err != nil {
 		return fmt.Errorf("failed to load ROM: %w", err)
 	}
+	romData := res.Data
 
 	region, err := parseRegion(regionStr, factory, romData)
 	if err != nil {
@@ -50,6 +51,10 @@ func RunDirect(factory coreif.CoreFactory, romPath, regionStr string, options ma
 		return fmt.Errorf("failed to create emulator: %w", err)
 	}
 
+	if mr, ok := emulator.(coreif.MessageReporter); ok {
+		mr.SetMessageHandler(logCoreMessage)
+	}
+
 	for key, value := range options {
 		emulator.SetOption(key, value)
 	}
@@ -73,7 +78,7 @@ func RunDirect(factory coreif.CoreFactory, romPath, regionStr string, options ma
 	ebiten.SetWindowSize(windowW, windowH)
 	ebiten.SetWindowSizeLimits(minW, minH, -1, -1)
 
-	audioPlayer, err := NewAudioPlayer(1.0)
+	audioPlayer, err := NewAudioPlayer(1.0, defaultAudioLatencyMs)
 	if err != nil {
 		log.Printf("Warning: audio initialization failed: %v", err)
 	}
@@ -133,12 +138,7 @@ func (dr *directRunner) emulationLoop() {
 		sleepTime := frameTime - elapsed
 
 		if dr.audioPlayer != nil {
-			bufferLevel := dr.audioPlayer.GetBufferLevel()
-			if bufferLevel < adtMinBuffer {
-				sleepTime = time.Duration(float64(sleepTime) * 0.9)
-			} else if bufferLevel > adtMaxBuffer {
-				sleepTime = time.Duration(float64(sleepTime) * 1.1)
-			}
+			sleepTime = dr.audioPlayer.AdjustSleep(sleepTime)
 		}
 
 		if sleepTime > time.Millisecond {
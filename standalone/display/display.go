@@ -1,18 +1,87 @@
 package display
 
 import (
+	"image"
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/user-none/eblitui/coreif"
 )
 
+// Crop holds per-side overscan cropping in native pixels.
+type Crop struct {
+	Top    int
+	Bottom int
+	Left   int
+	Right  int
+}
+
+// Rect returns the visible region of a w x h framebuffer after cropping.
+// Negative values are treated as zero and cropping is clamped so at least
+// one pixel row and column remain visible.
+func (c Crop) Rect(w, h int) image.Rectangle {
+	left, right := clampCrop(c.Left, c.Right, w)
+	top, bottom := clampCrop(c.Top, c.Bottom, h)
+	return image.Rect(left, top, w-right, h-bottom)
+}
+
+// clampCrop limits a pair of opposing crop values to leave at least one pixel.
+func clampCrop(a, b, size int) (int, int) {
+	a = max(a, 0)
+	b = max(b, 0)
+	if a+b >= size {
+		a = min(a, size-1)
+		b = size - 1 - a
+	}
+	return a, b
+}
+
+// IsInteger reports whether the mode scales by whole-number multiples.
+// Callers use this to snap centering offsets to whole pixels.
+func IsInteger(mode string) bool {
+	switch mode {
+	case "integer", "integer-1:1", "pixel-perfect":
+		return true
+	}
+	return false
+}
+
 // Size computes the display dimensions for the given aspect ratio mode,
 // fitting within screenW x screenH while preserving the chosen ratio.
 // sourceW/sourceH are the native pixel dimensions and par is the pixel aspect
-// ratio (used only for "dar" mode).
+// ratio (used by "dar", "integer" and "pixel-perfect" modes).
+//
+// Integer modes never scale below 1x, so a window smaller than the native
+// resolution shows a centered, clipped image.
 func Size(mode string, screenW, screenH, sourceW, sourceH int, par float64) (float64, float64) {
 	switch mode {
 	case "stretch":
 		return float64(screenW), float64(screenH)
+	case "integer":
+		// Integer vertical multiple, width aspect-corrected from the DAR
+		dar := coreif.DisplayAspectRatio(sourceW, sourceH, par)
+		n := screenH / sourceH
+		for n > 1 && float64(sourceH*n)*dar > float64(screenW) {
+			n--
+		}
+		n = max(n, 1)
+		displayH := float64(sourceH * n)
+		return displayH * dar, displayH
+	case "integer-1:1":
+		// Same integer multiple on both axes (square pixels)
+		n := max(min(screenW/sourceW, screenH/sourceH), 1)
+		return float64(sourceW * n), float64(sourceH * n)
+	case "pixel-perfect":
+		// Largest vertical multiple whose nearest horizontal multiple
+		// (approximating the PAR) also fits, so every source pixel maps
+		// to a whole-pixel rectangle
+		ny := max(screenH/sourceH, 1)
+		nx := pixelPerfectX(ny, par)
+		for ny > 1 && sourceW*nx > screenW {
+			ny--
+			nx = pixelPerfectX(ny, par)
+		}
+		return float64(sourceW * nx), float64(sourceH * ny)
 	case "4:3":
 		ratio := 4.0 / 3.0
 		displayW := float64(screenW)
@@ -43,6 +112,12 @@ func Size(mode string, screenW, screenH, sourceW, sourceH int, par float64) (flo
 	}
 }
 
+// pixelPerfectX returns the horizontal integer multiple that best
+// approximates the pixel aspect ratio for a vertical multiple of ny.
+func pixelPerfectX(ny int, par float64) int {
+	return max(int(math.Round(float64(ny)*par)), 1)
+}
+
 // ScaleAndCenter computes scale factors and centering offsets to fit a
 // display-sized image (displayW x displayH) from a source (sourceW x sourceH)
 // into the screen (screenW x screenH).
@@ -56,6 +131,15 @@ func ScaleAndCenter(displayW, displayH, sourceW, sourceH float64, screenW, scree
 	return
 }
 
+// SnapOffsets rounds centering offsets down to whole pixels for integer
+// modes so scaled source pixels land exactly on screen pixels.
+func SnapOffsets(mode string, offsetX, offsetY float64) (float64, float64) {
+	if !IsInteger(mode) {
+		return offsetX, offsetY
+	}
+	return math.Floor(offsetX), math.Floor(offsetY)
+}
+
 // DPIScale returns the device scale factor for the current monitor.
 // Returns 1.0 if the monitor is not available (e.g. in test environments).
 func DPIScale() float64 {
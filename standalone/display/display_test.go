@@ -157,3 +157,104 @@ func TestDPIScale(t *testing.T) {
 		t.Errorf("DPIScale: got %v, want >= 1.0", s)
 	}
 }
+
+func TestSizeInteger(t *testing.T) {
+	// 800x600 screen, 256x224 PAR 1.0 -> 600/224 = 2x vertical
+	// width = 448 * (256/224) = 512
+	w, h := Size("integer", 800, 600, 256, 224, 1.0)
+	if !almostEqual(w, 512, tolerance) || !almostEqual(h, 448, tolerance) {
+		t.Errorf("integer: got (%v, %v), want (512, 448)", w, h)
+	}
+}
+
+func TestSizeIntegerWidthConstrained(t *testing.T) {
+	// 600x1000 screen, 256x224 PAR 2.0 -> 4x vertical needs 2048 width
+	// Reduce until 224*n*DAR fits: n=1 -> 512 fits
+	w, h := Size("integer", 600, 1000, 256, 224, 2.0)
+	if !almostEqual(w, 512, tolerance) || !almostEqual(h, 224, tolerance) {
+		t.Errorf("integer width constrained: got (%v, %v), want (512, 224)", w, h)
+	}
+}
+
+func TestSizeIntegerMinimum(t *testing.T) {
+	// Screen smaller than source still renders at 1x
+	w, h := Size("integer", 100, 100, 256, 224, 1.0)
+	if !almostEqual(w, 256, tolerance) || !almostEqual(h, 224, tolerance) {
+		t.Errorf("integer minimum: got (%v, %v), want (256, 224)", w, h)
+	}
+}
+
+func TestSizeInteger11(t *testing.T) {
+	// 800x600 screen, 256x224 -> min(800/256, 600/224) = 2
+	w, h := Size("integer-1:1", 800, 600, 256, 224, 1.1458)
+	if w != 512 || h != 448 {
+		t.Errorf("integer-1:1: got (%v, %v), want (512, 448)", w, h)
+	}
+}
+
+func TestSizePixelPerfect(t *testing.T) {
+	// 1920x1080 screen, 256x224 PAR 8/7
+	// ny = 1080/224 = 4, nx = round(4 * 8/7) = 5 -> 1280x896
+	w, h := Size("pixel-perfect", 1920, 1080, 256, 224, 8.0/7.0)
+	if w != 1280 || h != 896 {
+		t.Errorf("pixel-perfect: got (%v, %v), want (1280, 896)", w, h)
+	}
+}
+
+func TestSizePixelPerfectWidthConstrained(t *testing.T) {
+	// 1000x1080 screen: ny=4 -> nx=5 -> 1280 too wide
+	// ny=3 -> nx=round(3.43)=3 -> 768 fits
+	w, h := Size("pixel-perfect", 1000, 1080, 256, 224, 8.0/7.0)
+	if w != 768 || h != 672 {
+		t.Errorf("pixel-perfect width constrained: got (%v, %v), want (768, 672)", w, h)
+	}
+}
+
+func TestIsInteger(t *testing.T) {
+	for _, mode := range []string{"integer", "integer-1:1", "pixel-perfect"} {
+		if !IsInteger(mode) {
+			t.Errorf("IsInteger(%q) = false, want true", mode)
+		}
+	}
+	for _, mode := range []string{"", "dar", "1:1", "4:3", "stretch"} {
+		if IsInteger(mode) {
+			t.Errorf("IsInteger(%q) = true, want false", mode)
+		}
+	}
+}
+
+func TestSnapOffsets(t *testing.T) {
+	x, y := SnapOffsets("integer", 10.5, 3.7)
+	if x != 10 || y != 3 {
+		t.Errorf("integer snap: got (%v, %v), want (10, 3)", x, y)
+	}
+	x, y = SnapOffsets("dar", 10.5, 3.7)
+	if x != 10.5 || y != 3.7 {
+		t.Errorf("dar snap: got (%v, %v), want (10.5, 3.7)", x, y)
+	}
+}
+
+func TestCropRect(t *testing.T) {
+	r := Crop{Top: 8, Bottom: 8, Left: 4, Right: 2}.Rect(256, 224)
+	if r.Min.X != 4 || r.Min.Y != 8 || r.Max.X != 254 || r.Max.Y != 216 {
+		t.Errorf("crop rect: got %v, want (4,8)-(254,216)", r)
+	}
+}
+
+func TestCropRectZero(t *testing.T) {
+	r := Crop{}.Rect(256, 224)
+	if r.Dx() != 256 || r.Dy() != 224 {
+		t.Errorf("zero crop: got %v, want full 256x224", r)
+	}
+}
+
+func TestCropRectClamped(t *testing.T) {
+	// Over-cropping and negative values leave at least one pixel
+	r := Crop{Top: 200, Bottom: 200, Left: -5, Right: 300}.Rect(256, 224)
+	if r.Dx() < 1 || r.Dy() < 1 {
+		t.Errorf("clamped crop: got %v, want at least 1x1", r)
+	}
+	if r.Min.X != 0 {
+		t.Errorf("negative left: got Min.X %d, want 0", r.Min.X)
+	}
+}
@@ -3,6 +3,7 @@ package standalone
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"log"
 	"strconv"
 	"strings"
@@ -13,19 +14,14 @@ import (
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/user-none/eblitui/coreif"
 	"github.com/user-none/eblitui/romloader"
+	"github.com/user-none/eblitui/rumble"
 	"github.com/user-none/eblitui/standalone/achievements"
+	"github.com/user-none/eblitui/standalone/display"
 	"github.com/user-none/eblitui/standalone/metadata"
 	"github.com/user-none/eblitui/standalone/storage"
 	"github.com/user-none/eblitui/standalone/style"
 )
 
-// ADT (audio-driven timing) buffer thresholds in bytes.
-// At 48kHz stereo 16-bit: 3200 bytes/frame at 60fps.
-const (
-	adtMinBuffer = 9600  // ~3 frames — speed up below this
-	adtMaxBuffer = 19200 // ~6 frames — slow down above this
-)
-
 // GameplayManager handles all gameplay-related state and logic.
 // This includes emulator control, input handling, save states,
 // play time tracking, and the pause menu.
@@ -45,6 +41,8 @@ type GameplayManager struct {
 	renderer     *FramebufferRenderer
 	audioPlayer  *AudioPlayer
 	currentGame  *storage.GameEntry
+	romData      []byte        // Kept to recreate the emulator on reset
+	region       coreif.Region // Region the emulator was created with
 
 	// ADT goroutine control
 	emuControl        *EmuControl
@@ -66,6 +64,9 @@ type GameplayManager struct {
 	// Achievement overlay
 	achievementOverlay *AchievementOverlay
 
+	// Audio diagnostics overlay
+	diagnosticsOverlay *DiagnosticsOverlay
+
 	// Play time tracking
 	playTime PlayTimeTracker
 
@@ -79,9 +80,9 @@ type GameplayManager struct {
 	achievementScreenshotMu      sync.Mutex
 
 	// Rumble
-	rumbleEngine    *RumbleEngine
+	rumbleEngine    *rumble.Engine
 	memoryInspector coreif.MemoryInspector
-	pendingRumble   []RumbleEvent
+	pendingRumble   []rumble.Event
 	pendingRumbleMu sync.Mutex
 
 	// Turbo (fast-forward)
@@ -145,6 +146,9 @@ func NewGameplayManager(
 		func() { // onResume
 			gm.Resume()
 		},
+		func() { // onReset
+			gm.Reset()
+		},
 		func() { // onLibrary
 			gm.Exit(true)
 			if gm.onExitToLibrary != nil {
@@ -162,6 +166,9 @@ func NewGameplayManager(
 	// Initialize achievement overlay
 	gm.achievementOverlay = NewAchievementOverlay(achievementManager)
 
+	// Initialize audio diagnostics overlay
+	gm.diagnosticsOverlay = NewDiagnosticsOverlay()
+
 	return gm
 }
 
@@ -185,6 +192,17 @@ func (gm *GameplayManager) IsPlaying() bool {
 	return gm.emulator != nil
 }
 
+// loadOptions returns the romloader options for the core's ROMs: its size
+// limit and, if the factory implements coreif.ROMNormalizer, its
+// normalization.
+func loadOptions(factory coreif.CoreFactory) romloader.Options {
+	opts := romloader.Options{MaxSize: factory.SystemInfo().MaxROMSize}
+	if n, ok := factory.(coreif.ROMNormalizer); ok {
+		opts.Normalize = n.NormalizeROM
+	}
+	return opts
+}
+
 // CurrentGameCRC returns the CRC of the currently loaded game, or empty string if none
 func (gm *GameplayManager) CurrentGameCRC() string {
 	if gm.currentGame != nil {
@@ -209,89 +227,38 @@ func (gm *GameplayManager) Launch(gameCRC string, resume bool) bool {
 	}
 
 	// Load ROM
-	romData, _, err := romloader.Load(game.File, gm.systemInfo.Extensions)
+	opts := loadOptions(gm.factory)
+	opts.Patches = game.Patches
+	res, err := romloader.LoadWith(game.File, gm.systemInfo.Extensions, opts)
+	if errors.Is(err, romloader.ErrInvalidPatch) || errors.Is(err, romloader.ErrPatchMismatch) {
+		gm.notification.ShowDefault("Failed to apply patch")
+		return false
+	}
 	if err != nil {
 		game.Missing = true
 		storage.SaveLibrary(gm.library)
 		gm.notification.ShowDefault("Failed to load ROM")
 		return false
 	}
+	romData := res.Data
+	if res.Normalized != "" {
+		log.Printf("Loaded %s: %s", res.Name, res.Normalized)
+	}
 
 	// Determine region
 	region := gm.regionFromLibraryEntry(game)
 
 	// Create emulator
-	emu, err := gm.factory.CreateEmulator(romData, region)
-	if err != nil {
-		gm.notification.ShowDefault("Failed to create emulator")
+	emu, ok := gm.newEmulator(romData, region)
+	if !ok {
 		return false
 	}
 	gm.emulator = emu
 	gm.currentGame = game
+	gm.romData = romData
+	gm.region = region
 	gm.saveStateManager.SetGame(gameCRC)
 
-	// Apply core options: use config value if set, otherwise declared default
-	for _, opt := range gm.systemInfo.CoreOptions {
-		if gm.config.CoreOptions != nil {
-			if v, ok := gm.config.CoreOptions[opt.Key]; ok {
-				emu.SetOption(opt.Key, v)
-				continue
-			}
-		}
-		emu.SetOption(opt.Key, opt.Default)
-	}
-
-	// Load BIOS files
-	for _, opt := range gm.systemInfo.BIOSOptions {
-		bc, hasCfg := gm.config.BIOS[opt.Key]
-		if !hasCfg || bc.Active == "" {
-			if opt.Required {
-				gm.notification.ShowDefault("BIOS required: " + opt.Label)
-				return false
-			}
-			continue
-		}
-		filePath := ""
-		if bc.Files != nil {
-			filePath = bc.Files[bc.Active]
-		}
-		if filePath == "" {
-			if opt.Required {
-				gm.notification.ShowDefault("BIOS file not set: " + opt.Label)
-				return false
-			}
-			continue
-		}
-		data, err := romloader.LoadBIOS(filePath)
-		if err != nil {
-			if opt.Required {
-				gm.notification.ShowDefault("Failed to read BIOS: " + opt.Label)
-				return false
-			}
-			continue
-		}
-		// Validate hash against the active variant
-		hashValid := true
-		for _, v := range opt.Variants {
-			if v.Label == bc.Active && v.SHA256 != "" {
-				h := sha256.Sum256(data)
-				hash := hex.EncodeToString(h[:])
-				if hash != v.SHA256 {
-					hashValid = false
-				}
-				break
-			}
-		}
-		if !hashValid {
-			if opt.Required {
-				gm.notification.ShowDefault("BIOS hash mismatch: " + opt.Label)
-				return false
-			}
-			continue
-		}
-		emu.SetBIOS(opt.Key, data)
-	}
-
 	emu.Start()
 
 	// Detect optional interfaces
@@ -301,6 +268,12 @@ func (gm *GameplayManager) Launch(gameCRC string, resume bool) bool {
 	// Create renderer and shared structures for ADT
 	gm.renderer = NewFramebufferRenderer(gm.systemInfo.ScreenWidth, gm.systemInfo.PixelAspectRatio)
 	gm.renderer.SetAspectRatioMode(gm.config.Video.AspectRatio)
+	gm.renderer.SetCrop(display.Crop{
+		Top:    game.Settings.CropTop,
+		Bottom: game.Settings.CropBottom,
+		Left:   game.Settings.CropLeft,
+		Right:  game.Settings.CropRight,
+	})
 	gm.sharedInput = &SharedInput{}
 	gm.sharedFramebuffer = NewSharedFramebuffer(gm.systemInfo.ScreenWidth, gm.systemInfo.MaxScreenHeight)
 	gm.emuControl = NewEmuControl()
@@ -313,7 +286,7 @@ func (gm *GameplayManager) Launch(gameCRC string, resume bool) bool {
 	if gm.config.Audio.Muted {
 		volume = 0
 	}
-	player, err := NewAudioPlayer(volume)
+	player, err := NewAudioPlayer(volume, storage.AudioLatencyMs(gm.config.Audio))
 	if err != nil {
 		log.Printf("Failed to init audio: %v", err)
 	} else {
@@ -387,9 +360,9 @@ func (gm *GameplayManager) Launch(gameCRC string, resume bool) bool {
 		if mi, ok := gm.emulator.(coreif.MemoryInspector); ok {
 			rumblePath, err := storage.GetGameRumblePath(game.CRC32)
 			if err == nil {
-				entries, err := ParseRumbleFile(rumblePath)
+				entries, err := rumble.ParseFile(rumblePath)
 				if err == nil && len(entries) > 0 {
-					gm.rumbleEngine = NewRumbleEngine(entries, gm.systemInfo.BigEndianMemory)
+					gm.rumbleEngine = rumble.NewEngine(entries, gm.systemInfo.BigEndianMemory)
 					gm.memoryInspector = mi
 				}
 			}
@@ -402,6 +375,87 @@ func (gm *GameplayManager) Launch(gameCRC string, resume bool) bool {
 	return true
 }
 
+// newEmulator creates an emulator for romData with the configured core
+// options and BIOS applied, ready to start. Failures are shown as a
+// notification.
+func (gm *GameplayManager) newEmulator(romData []byte, region coreif.Region) (coreif.Emulator, bool) {
+	emu, err := gm.factory.CreateEmulator(romData, region)
+	if err != nil {
+		gm.notification.ShowDefault("Failed to create emulator")
+		return nil, false
+	}
+	if mr, ok := emu.(coreif.MessageReporter); ok {
+		mr.SetMessageHandler(gm.notification.ShowCoreMessage)
+	}
+
+	// Apply core options: use config value if set, otherwise declared default
+	for _, opt := range gm.systemInfo.CoreOptions {
+		if gm.config.CoreOptions != nil {
+			if v, ok := gm.config.CoreOptions[opt.Key]; ok {
+				emu.SetOption(opt.Key, v)
+				continue
+			}
+		}
+		emu.SetOption(opt.Key, opt.Default)
+	}
+
+	// Load BIOS files
+	for _, opt := range gm.systemInfo.BIOSOptions {
+		bc, hasCfg := gm.config.BIOS[opt.Key]
+		if !hasCfg || bc.Active == "" {
+			if opt.Required {
+				gm.notification.ShowDefault("BIOS required: " + opt.Label)
+				emu.Close()
+				return nil, false
+			}
+			continue
+		}
+		filePath := ""
+		if bc.Files != nil {
+			filePath = bc.Files[bc.Active]
+		}
+		if filePath == "" {
+			if opt.Required {
+				gm.notification.ShowDefault("BIOS file not set: " + opt.Label)
+				emu.Close()
+				return nil, false
+			}
+			continue
+		}
+		data, err := romloader.LoadBIOS(filePath)
+		if err != nil {
+			if opt.Required {
+				gm.notification.ShowDefault("Failed to read BIOS: " + opt.Label)
+				emu.Close()
+				return nil, false
+			}
+			continue
+		}
+		// Validate hash against the active variant
+		hashValid := true
+		for _, v := range opt.Variants {
+			if v.Label == bc.Active && v.SHA256 != "" {
+				h := sha256.Sum256(data)
+				hash := hex.EncodeToString(h[:])
+				if hash != v.SHA256 {
+					hashValid = false
+				}
+				break
+			}
+		}
+		if !hashValid {
+			if opt.Required {
+				gm.notification.ShowDefault("BIOS hash mismatch: " + opt.Label)
+				emu.Close()
+				return nil, false
+			}
+			continue
+		}
+		emu.SetBIOS(opt.Key, data)
+	}
+	return emu, true
+}
+
 // runEmulatorFrame advances the emulator by one frame and processes achievements.
 // Called from the emulation goroutine.
 func (gm *GameplayManager) runEmulatorFrame() {
@@ -502,12 +556,7 @@ func (gm *GameplayManager) emulationLoop() {
 		sleepTime := frameTime - elapsed
 
 		if gm.audioPlayer != nil {
-			bufferLevel := gm.audioPlayer.GetBufferLevel()
-			if bufferLevel < adtMinBuffer {
-				sleepTime = time.Duration(float64(sleepTime) * 0.9)
-			} else if bufferLevel > adtMaxBuffer {
-				sleepTime = time.Duration(float64(sleepTime) * 1.1)
-			}
+			sleepTime = gm.audioPlayer.AdjustSleep(sleepTime)
 		}
 
 		if sleepTime > time.Millisecond {
@@ -691,6 +740,14 @@ func (gm *GameplayManager) DrawAchievementOverlay(screen *ebiten.Image) {
 	gm.achievementOverlay.Draw(screen)
 }
 
+// DrawDiagnostics draws the audio diagnostics overlay when enabled
+func (gm *GameplayManager) DrawDiagnostics(screen *ebiten.Image) {
+	if !gm.config.Audio.ShowDiagnostics || gm.audioPlayer == nil {
+		return
+	}
+	gm.diagnosticsOverlay.Draw(screen, gm.audioPlayer.Stats())
+}
+
 // IsPaused returns whether the pause menu is visible
 func (gm *GameplayManager) IsPaused() bool {
 	return gm.pauseMenu.IsVisible()
@@ -704,6 +761,67 @@ func (gm *GameplayManager) Resume() {
 	gm.playTime.tracking = true
 }
 
+// Reset soft resets the emulator and resumes gameplay. Cores without
+// coreif.Resetter are recreated with save RAM kept, like a power cycle.
+// The emulation goroutine is already paused by the pause menu.
+func (gm *GameplayManager) Reset() {
+	if r, ok := gm.emulator.(coreif.Resetter); ok {
+		r.Reset(false)
+	} else if !gm.recreateEmulator() {
+		gm.Resume()
+		return
+	}
+
+	if gm.rewindBuffer != nil {
+		gm.rewindBuffer.Reset()
+	}
+	if gm.rumbleEngine != nil {
+		gm.rumbleEngine.Reset()
+	}
+	if gm.achievementManager != nil {
+		gm.achievementManager.Reset()
+	}
+	if gm.audioPlayer != nil {
+		gm.audioPlayer.ClearQueue()
+	}
+	gm.Resume()
+}
+
+// recreateEmulator replaces the emulator with a new one for the same ROM,
+// carrying save RAM over. The old emulator is closed. Returns false, with
+// the old emulator still running, if the new one can't be created.
+func (gm *GameplayManager) recreateEmulator() bool {
+	emu, ok := gm.newEmulator(gm.romData, gm.region)
+	if !ok {
+		return false
+	}
+
+	var sram []byte
+	if gm.batterySaver != nil && gm.batterySaver.HasSRAM() {
+		sram = gm.batterySaver.GetSRAM()
+	}
+	gm.emulator.Close()
+
+	emu.Start()
+	gm.emulator = emu
+	gm.saveStater, _ = emu.(coreif.SaveStater)
+	gm.batterySaver, _ = emu.(coreif.BatterySaver)
+	if gm.batterySaver != nil && sram != nil {
+		gm.batterySaver.SetSRAM(sram)
+	}
+
+	// Point memory readers at the new emulator
+	if mi, ok := emu.(coreif.MemoryInspector); ok {
+		if gm.achievementManager != nil {
+			gm.achievementManager.SetEmulator(mi)
+		}
+		if gm.rumbleEngine != nil {
+			gm.memoryInspector = mi
+		}
+	}
+	return true
+}
+
 // Exit cleans up when exiting gameplay
 func (gm *GameplayManager) Exit(saveResume bool) {
 	if gm.emulator == nil {
@@ -777,6 +895,7 @@ func (gm *GameplayManager) Exit(saveResume bool) {
 	gm.batterySaver = nil
 	gm.renderer = nil
 	gm.currentGame = nil
+	gm.romData = nil
 	gm.rumbleEngine = nil
 	gm.memoryInspector = nil
 	gm.pendingRumble = nil
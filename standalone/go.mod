@@ -7,9 +7,10 @@ require (
 	github.com/ebitenui/ebitenui v0.7.2
 	github.com/hajimehoshi/ebiten/v2 v2.9.8
 	github.com/sqweek/dialog v0.0.0-20240226140203-065105509627
-	github.com/user-none/eblitui/coreif v0.4.0
-	github.com/user-none/eblitui/rdb v0.1.0
-	github.com/user-none/eblitui/romloader v0.2.0
+	github.com/user-none/eblitui/coreif v0.5.0
+	github.com/user-none/eblitui/rdb v0.2.0
+	github.com/user-none/eblitui/romloader v0.3.0
+	github.com/user-none/eblitui/rumble v0.1.0
 	github.com/user-none/go-rcheevos v0.0.0
 	golang.design/x/clipboard v0.7.1
 	golang.org/x/image v0.35.0
@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/user-none/eblitui/coreif"
 	"github.com/user-none/eblitui/rdb"
@@ -19,17 +20,18 @@ const (
 
 // metadataVariant holds the loaded RDB and config for a single variant.
 type metadataVariant struct {
-	name          string   // Display name
-	rdbName       string   // e.g. "SNK - Neo Geo Pocket Color"
-	thumbnailRepo string   // e.g. "SNK_-_Neo_Geo_Pocket_Color"
-	consoleID     int      // RetroAchievements console ID override; 0 = use default
-	rdb           *rdb.RDB // Loaded RDB, nil if not loaded
+	name          string       // Display name
+	rdbName       string       // e.g. "SNK - Neo Geo Pocket Color"
+	thumbnailRepo string       // e.g. "SNK_-_Neo_Geo_Pocket_Color"
+	consoleID     int          // RetroAchievements console ID override; 0 = use default
+	rdb           *rdb.LazyRDB // Loaded RDB, nil if not loaded
 }
 
 // MetadataManager handles RDB and artwork downloads for one or more
 // metadata variants (RDB + thumbnail repo pairs).
 type MetadataManager struct {
 	variants []metadataVariant
+	dats     []*rdb.RDB // User-supplied DATs from the metadata directory
 }
 
 // NewMetadataManager creates a new metadata manager from the given variants.
@@ -88,6 +90,11 @@ func (m *MetadataManager) downloadVariantRDB(idx int) error {
 		return fmt.Errorf("failed to download RDB: %w", err)
 	}
 
+	// Reject truncated or corrupt downloads before replacing a good file
+	if _, err := rdb.NewLazyRDB(data); err != nil {
+		return fmt.Errorf("invalid RDB download: %w", err)
+	}
+
 	// Write to temp file
 	if err := os.WriteFile(tempPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write RDB temp file: %w", err)
@@ -102,7 +109,9 @@ func (m *MetadataManager) downloadVariantRDB(idx int) error {
 	return nil
 }
 
-// LoadRDB loads all variant RDB files into memory.
+// LoadRDB loads all variant RDB files, along with any clrmamepro or Logiqx
+// DAT files the user placed in the metadata directory. RDBs are indexed
+// and decoded per lookup rather than held in memory as decoded games.
 // Returns nil without error if a file doesn't exist.
 func (m *MetadataManager) LoadRDB() error {
 	for i := range m.variants {
@@ -112,23 +121,58 @@ func (m *MetadataManager) LoadRDB() error {
 			return err
 		}
 
-		loadedRDB, err := rdb.LoadRDB(rdbPath)
+		// The replaced RDB is closed to release its file data. Closing
+		// waits for lookups in progress; later ones find nothing.
+		old := v.rdb
+		loadedRDB, err := rdb.OpenRDB(rdbPath)
 		if err != nil {
 			if !os.IsNotExist(err) {
 				// Delete corrupted file silently
 				os.Remove(rdbPath)
 			}
-			v.rdb = nil
-			continue
 		}
 
 		v.rdb = loadedRDB
+		if old != nil {
+			old.Close()
+		}
+	}
+	return m.loadDATs()
+}
+
+// loadDATs loads the *.dat and *.xml files in the metadata directory.
+// Unlike downloaded RDBs, DATs that fail to parse are skipped rather than
+// deleted since they can't be downloaded again.
+func (m *MetadataManager) loadDATs() error {
+	metadataDir, err := storage.GetMetadataDir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(metadataDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	m.dats = nil
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".dat" && ext != ".xml") {
+			continue
+		}
+		dat, err := rdb.LoadDAT(filepath.Join(metadataDir, e.Name()))
+		if err != nil {
+			continue
+		}
+		m.dats = append(m.dats, dat)
 	}
 	return nil
 }
 
-// IsRDBLoaded returns true if any variant RDB is loaded.
+// IsRDBLoaded returns true if any variant RDB or user DAT is loaded.
 func (m *MetadataManager) IsRDBLoaded() bool {
+	if len(m.dats) > 0 {
+		return true
+	}
 	for _, v := range m.variants {
 		if v.rdb != nil {
 			return true
@@ -137,8 +181,9 @@ func (m *MetadataManager) IsRDBLoaded() bool {
 	return false
 }
 
-// LookupByCRC32 looks up a game by CRC32 across all loaded RDBs.
-// Returns the game and the variant index where it was found.
+// LookupByCRC32 looks up a game by CRC32 across all loaded RDBs, then
+// user DATs. Returns the game and the variant index where it was found;
+// DAT matches belong to no variant and return -1 with the game.
 // Returns nil, -1 if not found or no RDB is loaded.
 func (m *MetadataManager) LookupByCRC32(crc32 uint32) (*rdb.Game, int) {
 	for i, v := range m.variants {
@@ -149,10 +194,42 @@ func (m *MetadataManager) LookupByCRC32(crc32 uint32) (*rdb.Game, int) {
 			return game, i
 		}
 	}
+	for _, dat := range m.dats {
+		if game := dat.FindByCRC32(crc32); game != nil {
+			return game, -1
+		}
+	}
 	return nil, -1
 }
 
-// GetMD5ByCRC32 searches all loaded RDBs for an MD5 hash matching the CRC32.
+// LookupByROMName looks up a game by ROM filename across all loaded RDBs,
+// then user DATs, for ROMs whose CRC32 doesn't match. When size is
+// non-zero the entry must have the same size. As with LookupByCRC32, DAT
+// matches return -1 with the game. Returns nil, -1 if not found.
+func (m *MetadataManager) LookupByROMName(name string, size int64) (*rdb.Game, int) {
+	match := func(game *rdb.Game) *rdb.Game {
+		if game == nil || (size > 0 && game.Size != 0 && game.Size != uint64(size)) {
+			return nil
+		}
+		return game
+	}
+	for i, v := range m.variants {
+		if v.rdb == nil {
+			continue
+		}
+		if game := match(v.rdb.FindByROMName(name)); game != nil {
+			return game, i
+		}
+	}
+	for _, dat := range m.dats {
+		if game := match(dat.FindByROMName(name)); game != nil {
+			return game, -1
+		}
+	}
+	return nil, -1
+}
+
+// GetMD5ByCRC32 searches all loaded RDBs and user DATs for an MD5 hash matching the CRC32.
 func (m *MetadataManager) GetMD5ByCRC32(crc32 uint32) string {
 	for _, v := range m.variants {
 		if v.rdb == nil {
@@ -162,6 +239,11 @@ func (m *MetadataManager) GetMD5ByCRC32(crc32 uint32) string {
 			return md5
 		}
 	}
+	for _, dat := range m.dats {
+		if md5 := dat.GetMD5ByCRC32(crc32); md5 != "" {
+			return md5
+		}
+	}
 	return ""
 }
 
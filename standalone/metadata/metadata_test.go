@@ -1,9 +1,14 @@
 package metadata
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/user-none/eblitui/coreif"
+	"github.com/user-none/eblitui/rdb"
+	"github.com/user-none/eblitui/standalone/storage"
 )
 
 func TestResolveConsoleID(t *testing.T) {
@@ -36,3 +41,110 @@ func TestResolveConsoleID(t *testing.T) {
 		})
 	}
 }
+
+// testMetadataDir points storage at a temporary metadata directory
+func testMetadataDir(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("metadata directory location uses XDG_DATA_HOME")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	storage.Init("eblitui-test")
+	metadataDir, err := storage.GetMetadataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(metadataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return metadataDir
+}
+
+func TestLoadRDB(t *testing.T) {
+	metadataDir := testMetadataDir(t)
+	err := rdb.WriteFile(filepath.Join(metadataDir, "SNK - Neo Geo Pocket Color.rdb"), []rdb.Game{
+		{Name: "Game (World)", ROMName: "Game (World).ngc", Size: 2097152, CRC32: 0x12345678, MD5: "00112233445566778899aabbccddeeff"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt := filepath.Join(metadataDir, "SNK - Neo Geo Pocket.rdb")
+	if err := os.WriteFile(corrupt, []byte("RARCHDB\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMetadataManager([]coreif.MetadataVariant{
+		{Name: "NGP", RDBName: "SNK - Neo Geo Pocket"},
+		{Name: "NGPC", RDBName: "SNK - Neo Geo Pocket Color"},
+	})
+	if err := m.LoadRDB(); err != nil {
+		t.Fatalf("LoadRDB: %v", err)
+	}
+
+	if game, idx := m.LookupByCRC32(0x12345678); game == nil || game.Name != "Game (World)" || idx != 1 {
+		t.Errorf("LookupByCRC32 = %+v, %d", game, idx)
+	}
+	if game, idx := m.LookupByROMName("game (world).NGC", 2097152); game == nil || idx != 1 {
+		t.Errorf("LookupByROMName = %+v, %d", game, idx)
+	}
+	if md5 := m.GetMD5ByCRC32(0x12345678); md5 != "00112233445566778899aabbccddeeff" {
+		t.Errorf("GetMD5ByCRC32 = %q", md5)
+	}
+
+	// Truncated RDBs are deleted so they're downloaded again
+	if _, err := os.Stat(corrupt); !os.IsNotExist(err) {
+		t.Errorf("corrupt RDB not removed: %v", err)
+	}
+}
+
+func TestLoadUserDATs(t *testing.T) {
+	metadataDir := testMetadataDir(t)
+
+	files := map[string]string{
+		"Homebrew.dat": `game (
+	name "Homebrew (World)"
+	rom ( name "Homebrew (World).sms" size 32768 crc 12345678 md5 00112233445566778899AABBCCDDEEFF )
+)`,
+		"Prototypes.xml": `<?xml version="1.0"?>
+<datafile>
+	<game name="Prototype (USA) (Proto)">
+		<rom name="Prototype (USA) (Proto).sms" size="65536" crc="87654321"/>
+	</game>
+</datafile>`,
+		"Broken.dat": `game ( name "unterminated`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(metadataDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewMetadataManager([]coreif.MetadataVariant{{Name: "SMS", RDBName: "Sega - Master System - Mark III"}})
+	if err := m.LoadRDB(); err != nil {
+		t.Fatalf("LoadRDB: %v", err)
+	}
+	if !m.IsRDBLoaded() {
+		t.Error("IsRDBLoaded = false with DATs loaded")
+	}
+
+	if game, idx := m.LookupByCRC32(0x12345678); game == nil || game.Name != "Homebrew (World)" || idx != -1 {
+		t.Errorf("LookupByCRC32 clrmamepro = %+v, %d", game, idx)
+	}
+	if game, _ := m.LookupByCRC32(0x87654321); game == nil || game.Name != "Prototype (USA) (Proto)" {
+		t.Errorf("LookupByCRC32 Logiqx = %+v", game)
+	}
+	if game, _ := m.LookupByROMName("Prototype (USA) (Proto).sms", 65536); game == nil {
+		t.Error("LookupByROMName found nothing")
+	}
+	if game, _ := m.LookupByROMName("Prototype (USA) (Proto).sms", 100); game != nil {
+		t.Errorf("LookupByROMName with wrong size = %+v", game)
+	}
+	if md5 := m.GetMD5ByCRC32(0x12345678); md5 != "00112233445566778899aabbccddeeff" {
+		t.Errorf("GetMD5ByCRC32 = %q", md5)
+	}
+
+	// Broken DATs are skipped, not deleted
+	if _, err := os.Stat(filepath.Join(metadataDir, "Broken.dat")); err != nil {
+		t.Errorf("broken DAT removed: %v", err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/ebitengine/oto/v3"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/user-none/eblitui/coreif"
 	"github.com/user-none/eblitui/standalone/style"
 )
 
@@ -103,6 +104,35 @@ func (n *Notification) ShowShort(message string) {
 	n.Show(message, 1*time.Second)
 }
 
+// ShowCoreMessage logs a message from the core and shows it on screen if
+// the core asks to notify the user. Safe to call from the emulation
+// goroutine.
+func (n *Notification) ShowCoreMessage(m coreif.Message) {
+	logCoreMessage(m)
+	if !m.Notify {
+		return
+	}
+	duration := 3 * time.Second
+	if m.Duration > 0 {
+		duration = time.Duration(m.Duration) * time.Millisecond
+	}
+	n.Show(m.Text, duration)
+}
+
+// logCoreMessage writes a message from the core to the log
+func logCoreMessage(m coreif.Message) {
+	switch m.Level {
+	case coreif.MessageDebug:
+		log.Printf("Core debug: %s", m.Text)
+	case coreif.MessageWarn:
+		log.Printf("Core warning: %s", m.Text)
+	case coreif.MessageError:
+		log.Printf("Core error: %s", m.Text)
+	default:
+		log.Printf("Core: %s", m.Text)
+	}
+}
+
 // ShowAchievementWithBadge displays a prominent achievement notification with a badge image
 func (n *Notification) ShowAchievementWithBadge(title, description string, badge *ebiten.Image) {
 	n.mu.Lock()
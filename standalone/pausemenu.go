@@ -14,6 +14,7 @@ type PauseMenuOption int
 
 const (
 	PauseMenuResume PauseMenuOption = iota
+	PauseMenuReset
 	PauseMenuLibrary
 	PauseMenuExit
 	PauseMenuOptionCount
@@ -24,6 +25,7 @@ type PauseMenu struct {
 	visible       bool
 	selectedIndex int
 	onResume      func()
+	onReset       func()
 	onLibrary     func()
 	onExit        func()
 
@@ -48,11 +50,12 @@ type PauseMenu struct {
 }
 
 // NewPauseMenu creates a new pause menu
-func NewPauseMenu(onResume, onLibrary, onExit func()) *PauseMenu {
+func NewPauseMenu(onResume, onReset, onLibrary, onExit func()) *PauseMenu {
 	return &PauseMenu{
 		visible:       false,
 		selectedIndex: 0,
 		onResume:      onResume,
+		onReset:       onReset,
 		onLibrary:     onLibrary,
 		onExit:        onExit,
 		buttonRects:   make([]image.Rectangle, PauseMenuOptionCount),
@@ -176,6 +179,11 @@ func (m *PauseMenu) handleSelect() {
 		if m.onResume != nil {
 			m.onResume()
 		}
+	case PauseMenuReset:
+		m.Hide()
+		if m.onReset != nil {
+			m.onReset()
+		}
 	case PauseMenuLibrary:
 		m.Hide()
 		if m.onLibrary != nil {
@@ -312,7 +320,7 @@ func (m *PauseMenu) Draw(screen *ebiten.Image) {
 	screen.DrawImage(m.cache.panelBg, &m.drawOpts)
 
 	// Draw menu options
-	options := []string{"Resume", "Library", "Exit"}
+	options := []string{"Resume", "Reset", "Library", "Exit"}
 	buttonSpacing := m.cache.buttonH / 4
 	padding := m.cache.buttonH / 2
 	startY := panelY + padding
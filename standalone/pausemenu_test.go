@@ -3,7 +3,7 @@ package standalone
 import "testing"
 
 func TestNewPauseMenu(t *testing.T) {
-	m := NewPauseMenu(nil, nil, nil)
+	m := NewPauseMenu(nil, nil, nil, nil)
 
 	if m.IsVisible() {
 		t.Error("should not be visible initially")
@@ -14,7 +14,7 @@ func TestNewPauseMenu(t *testing.T) {
 }
 
 func TestPauseMenuShow(t *testing.T) {
-	m := NewPauseMenu(nil, nil, nil)
+	m := NewPauseMenu(nil, nil, nil, nil)
 
 	m.Show()
 	if !m.IsVisible() {
@@ -26,7 +26,7 @@ func TestPauseMenuShow(t *testing.T) {
 }
 
 func TestPauseMenuHide(t *testing.T) {
-	m := NewPauseMenu(nil, nil, nil)
+	m := NewPauseMenu(nil, nil, nil, nil)
 
 	m.Show()
 	m.Hide()
@@ -36,7 +36,7 @@ func TestPauseMenuHide(t *testing.T) {
 }
 
 func TestPauseMenuShowResetsSelection(t *testing.T) {
-	m := NewPauseMenu(nil, nil, nil)
+	m := NewPauseMenu(nil, nil, nil, nil)
 
 	m.selectedIndex = 2
 	m.Show()
@@ -47,7 +47,7 @@ func TestPauseMenuShowResetsSelection(t *testing.T) {
 
 func TestHandleSelectResume(t *testing.T) {
 	resumed := false
-	m := NewPauseMenu(func() { resumed = true }, nil, nil)
+	m := NewPauseMenu(func() { resumed = true }, nil, nil, nil)
 
 	m.Show()
 	m.selectedIndex = int(PauseMenuResume)
@@ -61,9 +61,25 @@ func TestHandleSelectResume(t *testing.T) {
 	}
 }
 
+func TestHandleSelectReset(t *testing.T) {
+	resetCalled := false
+	m := NewPauseMenu(nil, func() { resetCalled = true }, nil, nil)
+
+	m.Show()
+	m.selectedIndex = int(PauseMenuReset)
+	m.handleSelect()
+
+	if !resetCalled {
+		t.Error("onReset should have been called")
+	}
+	if m.IsVisible() {
+		t.Error("menu should be hidden after Reset")
+	}
+}
+
 func TestHandleSelectLibrary(t *testing.T) {
 	libraryCalled := false
-	m := NewPauseMenu(nil, func() { libraryCalled = true }, nil)
+	m := NewPauseMenu(nil, nil, func() { libraryCalled = true }, nil)
 
 	m.Show()
 	m.selectedIndex = int(PauseMenuLibrary)
@@ -79,7 +95,7 @@ func TestHandleSelectLibrary(t *testing.T) {
 
 func TestHandleSelectExit(t *testing.T) {
 	exitCalled := false
-	m := NewPauseMenu(nil, nil, func() { exitCalled = true })
+	m := NewPauseMenu(nil, nil, nil, func() { exitCalled = true })
 
 	m.Show()
 	m.selectedIndex = int(PauseMenuExit)
@@ -94,13 +110,17 @@ func TestHandleSelectExit(t *testing.T) {
 }
 
 func TestHandleSelectNilCallbacks(t *testing.T) {
-	m := NewPauseMenu(nil, nil, nil)
+	m := NewPauseMenu(nil, nil, nil, nil)
 	m.Show()
 
 	// None of these should panic
 	m.selectedIndex = int(PauseMenuResume)
 	m.handleSelect()
 
+	m.Show()
+	m.selectedIndex = int(PauseMenuReset)
+	m.handleSelect()
+
 	m.Show()
 	m.selectedIndex = int(PauseMenuLibrary)
 	m.handleSelect()
@@ -111,7 +131,7 @@ func TestHandleSelectNilCallbacks(t *testing.T) {
 }
 
 func TestPauseMenuOptionCount(t *testing.T) {
-	if PauseMenuOptionCount != 3 {
-		t.Errorf("PauseMenuOptionCount should be 3, got %d", PauseMenuOptionCount)
+	if PauseMenuOptionCount != 4 {
+		t.Errorf("PauseMenuOptionCount should be 4, got %d", PauseMenuOptionCount)
 	}
 }
@@ -13,15 +13,21 @@ type FramebufferRenderer struct {
 	screenWidth     int
 	par             float64
 	aspectRatioMode string
+	crop            display.Crop
 	offscreen       *ebiten.Image
 	drawOpts        ebiten.DrawImageOptions
 }
 
-// SetAspectRatioMode sets the aspect ratio scaling mode ("dar", "4:3", "1:1", "stretch").
+// SetAspectRatioMode sets the aspect ratio scaling mode (see storage.ValidAspectRatios).
 func (r *FramebufferRenderer) SetAspectRatioMode(mode string) {
 	r.aspectRatioMode = mode
 }
 
+// SetCrop sets the overscan crop applied to the framebuffer before scaling.
+func (r *FramebufferRenderer) SetCrop(crop display.Crop) {
+	r.crop = crop
+}
+
 // NewFramebufferRenderer creates a renderer for the given native screen width
 // and pixel aspect ratio.
 func NewFramebufferRenderer(screenWidth int, par float64) *FramebufferRenderer {
@@ -43,29 +49,25 @@ func (r *FramebufferRenderer) DrawFramebuffer(screen *ebiten.Image, pixels []byt
 		return
 	}
 
-	pixelWidth := stride / 4
-	if r.offscreen == nil || r.offscreen.Bounds().Dx() != pixelWidth || r.offscreen.Bounds().Dy() != activeHeight {
-		r.offscreen = ebiten.NewImage(pixelWidth, activeHeight)
-	}
-
-	r.offscreen.WritePixels(pixels[:requiredLen])
+	visible := r.upload(pixels, stride, activeHeight)
 
 	screenW, screenH := screen.Bounds().Dx(), screen.Bounds().Dy()
-	nativeW := float64(pixelWidth)
-	nativeH := float64(activeHeight)
+	visibleW := visible.Bounds().Dx()
+	visibleH := visible.Bounds().Dy()
 
-	displayW, displayH := display.Size(r.aspectRatioMode, screenW, screenH, pixelWidth, activeHeight, r.par)
-	scaleX, scaleY, offsetX, offsetY := display.ScaleAndCenter(displayW, displayH, nativeW, nativeH, screenW, screenH)
+	displayW, displayH := display.Size(r.aspectRatioMode, screenW, screenH, visibleW, visibleH, r.par)
+	scaleX, scaleY, offsetX, offsetY := display.ScaleAndCenter(displayW, displayH, float64(visibleW), float64(visibleH), screenW, screenH)
+	offsetX, offsetY = display.SnapOffsets(r.aspectRatioMode, offsetX, offsetY)
 
 	r.drawOpts = ebiten.DrawImageOptions{}
 	r.drawOpts.GeoM.Scale(scaleX, scaleY)
 	r.drawOpts.GeoM.Translate(offsetX, offsetY)
 	r.drawOpts.Filter = ebiten.FilterNearest
-	screen.DrawImage(r.offscreen, &r.drawOpts)
+	screen.DrawImage(visible, &r.drawOpts)
 }
 
 // GetFramebufferImage returns pixel data as an ebiten.Image at native
-// resolution. Used for shader processing.
+// resolution with the overscan crop applied. Used for shader processing.
 func (r *FramebufferRenderer) GetFramebufferImage(pixels []byte, stride, activeHeight int) *ebiten.Image {
 	if activeHeight == 0 || stride == 0 {
 		return nil
@@ -76,12 +78,21 @@ func (r *FramebufferRenderer) GetFramebufferImage(pixels []byte, stride, activeH
 		return nil
 	}
 
+	return r.upload(pixels, stride, activeHeight)
+}
+
+// upload writes pixel data to the offscreen buffer and returns the
+// visible region after cropping. Callers must validate the lengths.
+func (r *FramebufferRenderer) upload(pixels []byte, stride, activeHeight int) *ebiten.Image {
 	pixelWidth := stride / 4
 	if r.offscreen == nil || r.offscreen.Bounds().Dx() != pixelWidth || r.offscreen.Bounds().Dy() != activeHeight {
 		r.offscreen = ebiten.NewImage(pixelWidth, activeHeight)
 	}
 
-	r.offscreen.WritePixels(pixels[:requiredLen])
+	r.offscreen.WritePixels(pixels[:stride*activeHeight])
 
-	return r.offscreen
+	if r.crop == (display.Crop{}) {
+		return r.offscreen
+	}
+	return r.offscreen.SubImage(r.crop.Rect(pixelWidth, activeHeight)).(*ebiten.Image)
 }
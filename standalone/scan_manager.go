@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"github.com/user-none/eblitui/romloader"
 	"github.com/user-none/eblitui/standalone/metadata"
 	"github.com/user-none/eblitui/standalone/scanner"
 	"github.com/user-none/eblitui/standalone/screens"
@@ -21,6 +22,7 @@ type ScanManager struct {
 	library          *storage.Library
 	scanScreen       *screens.ScanProgressScreen
 	extensions       []string                  // Supported ROM file extensions
+	loadOptions      romloader.Options         // Size limit and normalization for loading ROMs
 	metadata         *metadata.MetadataManager // Metadata for RDB/thumbnail lookups
 	defaultConsoleID int
 
@@ -34,6 +36,7 @@ func NewScanManager(
 	library *storage.Library,
 	scanScreen *screens.ScanProgressScreen,
 	extensions []string,
+	loadOptions romloader.Options,
 	md *metadata.MetadataManager,
 	defaultConsoleID int,
 	onProgress func(),
@@ -43,6 +46,7 @@ func NewScanManager(
 		library:          library,
 		scanScreen:       scanScreen,
 		extensions:       extensions,
+		loadOptions:      loadOptions,
 		metadata:         md,
 		defaultConsoleID: defaultConsoleID,
 		onProgress:       onProgress,
@@ -74,6 +78,7 @@ func (sm *ScanManager) Start(rescanAll bool) {
 		sm.library.Games,
 		rescanAll,
 		sm.extensions,
+		sm.loadOptions,
 		sm.metadata,
 		sm.defaultConsoleID,
 	)
@@ -2,7 +2,6 @@ package scanner
 
 import (
 	"fmt"
-	"hash/crc32"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -66,7 +65,9 @@ type Scanner struct {
 	excludedPaths map[string]bool
 	existingGames map[string]*storage.GameEntry // Full existing entries to preserve user data
 	rescanAll     bool
-	extensions    []string // Supported ROM file extensions
+	extensions    []string              // Supported ROM file extensions
+	loadOptions   romloader.Options     // Size limit and normalization for loading ROMs
+	patchIndex    *romloader.PatchIndex // Patches next to and inside ROMs, read once per scan
 
 	// Metadata
 	metadata         *metadata.MetadataManager
@@ -90,7 +91,7 @@ type Scanner struct {
 type artworkJob struct {
 	gameCRC    string
 	gameName   string // No-Intro name from RDB
-	variantIdx int    // Index into MetadataVariants for correct repo
+	variantIdx int    // Index into MetadataVariants for correct repo, or -1 to try all
 }
 
 // resolvedJob represents a download that has been matched against a listing
@@ -101,7 +102,7 @@ type resolvedJob struct {
 }
 
 // NewScanner creates a new scanner instance
-func NewScanner(dirs []storage.ScanDirectory, excluded []string, existing map[string]*storage.GameEntry, rescanAll bool, extensions []string, md *metadata.MetadataManager, defaultConsoleID int) *Scanner {
+func NewScanner(dirs []storage.ScanDirectory, excluded []string, existing map[string]*storage.GameEntry, rescanAll bool, extensions []string, loadOptions romloader.Options, md *metadata.MetadataManager, defaultConsoleID int) *Scanner {
 	excludedMap := make(map[string]bool)
 	for _, p := range excluded {
 		excludedMap[p] = true
@@ -113,6 +114,8 @@ func NewScanner(dirs []storage.ScanDirectory, excluded []string, existing map[st
 		existingGames:    existing, // Keep full map to preserve user data
 		rescanAll:        rescanAll,
 		extensions:       extensions,
+		loadOptions:      loadOptions,
+		patchIndex:       romloader.NewPatchIndex(),
 		metadata:         md,
 		defaultConsoleID: defaultConsoleID,
 		progress:         make(chan ScanProgress, 10),
@@ -287,17 +290,72 @@ func (s *Scanner) scanDirectory(dir storage.ScanDirectory) ([]string, error) {
 	return files, nil
 }
 
-// processROM loads and processes a single ROM file
+// processROM processes a ROM file. Every ROM in an archive is added as
+// its own game; archives holding more than one are referenced by
+// "archive#entry" paths.
 func (s *Scanner) processROM(path string) {
-	// Load ROM using romloader (handles archives)
-	romData, filename, err := romloader.Load(path, s.extensions)
+	entries, err := romloader.ListWith(path, s.extensions, s.loadOptions)
 	if err != nil {
 		// Skip unsupported formats silently
 		return
 	}
 
-	// Calculate CRC32
-	crcValue := crc32.ChecksumIEEE(romData)
+	for _, e := range entries {
+		if s.isCancelled() {
+			return
+		}
+		file := path
+		if len(entries) > 1 {
+			file = romloader.EntryPath(path, e.Name)
+		}
+		s.processEntry(file, e)
+	}
+}
+
+// processEntry adds one ROM to the library, followed by a patched variant
+// for each patch found next to it. The CRC32 comes from the archive header
+// when available; otherwise the ROM is loaded to compute it.
+func (s *Scanner) processEntry(file string, e romloader.Entry) {
+	filename := filepath.Base(e.Name)
+	crcValue := e.CRC32
+
+	// Header CRC32s are of the file as stored, so normalized ROMs are
+	// always hashed. Without normalization this is one streaming pass
+	// that doesn't keep the ROM in memory.
+	size := e.Size
+	if !e.HasCRC32 || s.loadOptions.Normalize != nil {
+		opts := s.loadOptions
+		opts.Hashes = romloader.HashCRC32
+		opts.Discard = true
+		res, err := romloader.LoadWith(file, s.extensions, opts)
+		if err != nil {
+			return
+		}
+		crcValue = res.CRC32
+		size = res.Size
+	}
+	s.addGame(file, nil, crcValue, filename, size)
+
+	patches, err := s.patchIndex.Find(file, s.extensions)
+	if err != nil {
+		return
+	}
+	for _, p := range patches {
+		// Patches for a different ROM fail their checks and are skipped
+		opts := s.loadOptions
+		opts.Hashes = romloader.HashCRC32
+		opts.Discard = true
+		opts.Patches = []string{p}
+		res, err := romloader.LoadWith(file, s.extensions, opts)
+		if err != nil {
+			continue
+		}
+		s.addGame(file, []string{p}, res.CRC32, filename, res.Size)
+	}
+}
+
+// addGame adds a ROM, with patches applied when set, to the scanned games
+func (s *Scanner) addGame(file string, patches []string, crcValue uint32, filename string, size int64) {
 	crcHex := fmt.Sprintf("%08x", crcValue)
 
 	// Check if game already exists in library
@@ -322,7 +380,8 @@ func (s *Scanner) processROM(path string) {
 			Settings:        existingEntry.Settings,
 
 			// Update file path (may have moved)
-			File:    path,
+			File:    file,
+			Patches: patches,
 			Missing: false,
 
 			// Will be updated with metadata below or from existing
@@ -341,7 +400,8 @@ func (s *Scanner) processROM(path string) {
 		// Create new entry - Name/DisplayName left empty so RDB lookup can fill them
 		entry = &storage.GameEntry{
 			CRC32:   crcHex,
-			File:    path,
+			File:    file,
+			Patches: patches,
 			Added:   time.Now().Unix(),
 			Missing: false,
 		}
@@ -349,6 +409,10 @@ func (s *Scanner) processROM(path string) {
 
 	// Look up in RDB for metadata - only fill in empty fields
 	game, variantIdx := s.metadata.LookupByCRC32(crcValue)
+	if game == nil && len(patches) == 0 {
+		// Bad or modified dumps can still be named as in the RDB
+		game, variantIdx = s.metadata.LookupByROMName(filename, size)
+	}
 	if game != nil {
 		if entry.Name == "" {
 			entry.Name = game.Name
@@ -386,7 +450,9 @@ func (s *Scanner) processROM(path string) {
 			}
 		}
 
-		if entry.System == "" && s.metadata.VariantCount() > 1 {
+		// User DAT matches have no variant, so they keep the default
+		// system and console ID and search every thumbnail repo
+		if entry.System == "" && variantIdx >= 0 && s.metadata.VariantCount() > 1 {
 			entry.System = s.metadata.VariantName(variantIdx)
 		}
 
@@ -405,9 +471,10 @@ func (s *Scanner) processROM(path string) {
 			s.mu.Unlock()
 		}
 
-		// Queue rumble file download only if rumble file doesn't exist
+		// Queue rumble file download only if rumble file doesn't exist.
+		// Rumble files are kept per variant RDB.
 		rumblePath, _ := storage.GetGameRumblePath(crcHex)
-		if _, err := os.Stat(rumblePath); os.IsNotExist(err) {
+		if _, err := os.Stat(rumblePath); os.IsNotExist(err) && variantIdx >= 0 {
 			s.mu.Lock()
 			s.rumbleQueue = append(s.rumbleQueue, artworkJob{
 				gameCRC:    crcHex,
@@ -445,6 +512,9 @@ func (s *Scanner) processROM(path string) {
 	}
 	if entry.DisplayName == "" {
 		entry.DisplayName = s.cleanDisplayName(filename)
+		if len(patches) > 0 {
+			entry.DisplayName += " [" + storage.PatchLabel(file, patches[0]) + "]"
+		}
 	}
 
 	s.mu.Lock()
@@ -764,7 +834,10 @@ func (s *Scanner) sendProgress(p ScanProgress) {
 }
 
 // archiveExtensions are always supported for scanning regardless of system
-var archiveExtensions = []string{".zip", ".7z", ".gz", ".tar.gz", ".rar"}
+var archiveExtensions = []string{
+	".zip", ".7z", ".gz", ".tar.gz", ".rar", ".zst", ".tzst", ".xz", ".txz",
+	".bz2", ".tbz2", ".tbz", ".lz4", ".chd",
+}
 
 // isSupportedExtension checks if a file extension is supported
 func (s *Scanner) isSupportedExtension(ext string) bool {
@@ -1,10 +1,16 @@
 package scanner
 
 import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/user-none/eblitui/coreif"
+	"github.com/user-none/eblitui/romloader"
 	"github.com/user-none/eblitui/standalone/metadata"
 	"github.com/user-none/eblitui/standalone/storage"
 )
@@ -141,7 +147,7 @@ func TestNewScanner(t *testing.T) {
 	}
 
 	md := metadata.NewMetadataManager(nil)
-	s := NewScanner(dirs, excluded, existing, false, []string{".sms"}, md, 0)
+	s := NewScanner(dirs, excluded, existing, false, []string{".sms"}, romloader.Options{}, md, 0)
 
 	if len(s.directories) != 2 {
 		t.Errorf("expected 2 directories, got %d", len(s.directories))
@@ -259,7 +265,7 @@ func TestScanDirectoryWithExclusions(t *testing.T) {
 
 func TestScannerCancellation(t *testing.T) {
 	md := metadata.NewMetadataManager(nil)
-	s := NewScanner(nil, nil, nil, false, nil, md, 0)
+	s := NewScanner(nil, nil, nil, false, nil, romloader.Options{}, md, 0)
 
 	if s.isCancelled() {
 		t.Error("new scanner should not be cancelled")
@@ -276,7 +282,7 @@ func TestScannerCancellation(t *testing.T) {
 
 func TestScannerGamesCount(t *testing.T) {
 	md := metadata.NewMetadataManager(nil)
-	s := NewScanner(nil, nil, nil, false, nil, md, 0)
+	s := NewScanner(nil, nil, nil, false, nil, romloader.Options{}, md, 0)
 
 	if s.gamesCount() != 0 {
 		t.Errorf("expected 0 games, got %d", s.gamesCount())
@@ -290,8 +296,190 @@ func TestScannerGamesCount(t *testing.T) {
 
 func TestNewScannerDefaultConsoleID(t *testing.T) {
 	md := metadata.NewMetadataManager(nil)
-	s := NewScanner(nil, nil, nil, false, nil, md, 42)
+	s := NewScanner(nil, nil, nil, false, nil, romloader.Options{}, md, 42)
 	if s.defaultConsoleID != 42 {
 		t.Errorf("expected defaultConsoleID 42, got %d", s.defaultConsoleID)
 	}
 }
+
+func TestProcessROMMultiEntryArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "collection.zip")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	roms := map[string][]byte{
+		"Game One (USA).sms": []byte("first rom"),
+		"Game Two (USA).sms": []byte("second rom"),
+		"readme.txt":         []byte("not a rom"),
+	}
+	for _, name := range []string{"Game One (USA).sms", "Game Two (USA).sms", "readme.txt"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(roms[name])
+	}
+	zw.Close()
+	f.Close()
+
+	md := metadata.NewMetadataManager(nil)
+	s := NewScanner(nil, nil, nil, false, []string{".sms"}, romloader.Options{}, md, 0)
+	s.processROM(path)
+
+	if len(s.games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(s.games))
+	}
+	for _, name := range []string{"Game One (USA).sms", "Game Two (USA).sms"} {
+		crc := fmt.Sprintf("%08x", crc32.ChecksumIEEE(roms[name]))
+		game := s.games[crc]
+		if game == nil {
+			t.Errorf("%s not added", name)
+			continue
+		}
+		if want := path + "#" + name; game.File != want {
+			t.Errorf("File = %q, want %q", game.File, want)
+		}
+	}
+}
+
+func TestProcessROMSingleEntryArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "game.zip")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("Game (USA).sms")
+	w.Write([]byte("only rom"))
+	zw.Close()
+	f.Close()
+
+	md := metadata.NewMetadataManager(nil)
+	s := NewScanner(nil, nil, nil, false, []string{".sms"}, romloader.Options{}, md, 0)
+	s.processROM(path)
+
+	crc := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("only rom")))
+	game := s.games[crc]
+	if game == nil {
+		t.Fatal("game not added")
+	}
+	// Single ROM archives keep the plain archive path
+	if game.File != path {
+		t.Errorf("File = %q, want %q", game.File, path)
+	}
+}
+
+func TestProcessROMPatchedVariant(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "Game (Japan).sms")
+	os.WriteFile(path, []byte("original rom"), 0644)
+
+	// IPS patch replacing "original" with "patched!"
+	patch := []byte("PATCH\x00\x00\x00\x00\x08patched!EOF")
+	os.WriteFile(filepath.Join(tmpDir, "Game (Japan).ips"), patch, 0644)
+
+	md := metadata.NewMetadataManager(nil)
+	s := NewScanner(nil, nil, nil, false, []string{".sms"}, romloader.Options{}, md, 0)
+	s.processROM(path)
+
+	if len(s.games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(s.games))
+	}
+	crc := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("patched! rom")))
+	game := s.games[crc]
+	if game == nil {
+		t.Fatal("patched game not added")
+	}
+	if game.File != path || len(game.Patches) != 1 {
+		t.Errorf("File = %q, Patches = %v", game.File, game.Patches)
+	}
+	if game.DisplayName != "Game [Patched]" {
+		t.Errorf("DisplayName = %q, want %q", game.DisplayName, "Game [Patched]")
+	}
+}
+
+func TestProcessROMNormalized(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "game.zip")
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("Game (USA).sms")
+	w.Write([]byte("HDRrom data"))
+	zw.Close()
+	f.Close()
+
+	// The archive header CRC32 is of the headered file; the game must be
+	// keyed by the normalized ROM
+	opts := romloader.Options{
+		Normalize: func(ext string, data []byte) ([]byte, string) {
+			return data[3:], "removed header"
+		},
+	}
+	md := metadata.NewMetadataManager(nil)
+	s := NewScanner(nil, nil, nil, false, []string{".sms"}, opts, md, 0)
+	s.processROM(path)
+
+	crc := fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte("rom data")))
+	if s.games[crc] == nil {
+		t.Errorf("game not keyed by normalized CRC32; games = %v", s.games)
+	}
+}
+
+func TestProcessROMUserDATMatch(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("metadata directory location uses XDG_DATA_HOME")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	storage.Init("eblitui-test")
+	metadataDir, err := storage.GetMetadataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(metadataDir, 0755)
+
+	rom := []byte("homebrew rom")
+	crc := crc32.ChecksumIEEE(rom)
+	dat := fmt.Sprintf(`game ( name "Homebrew (World)" rom ( name "Homebrew (World).sms" crc %08x ) )`, crc)
+	os.WriteFile(filepath.Join(metadataDir, "Homebrew.dat"), []byte(dat), 0644)
+
+	md := metadata.NewMetadataManager([]coreif.MetadataVariant{
+		{Name: "SMS", RDBName: "Sega - Master System - Mark III", ConsoleID: 11},
+		{Name: "GG", RDBName: "Sega - Game Gear", ConsoleID: 15},
+	})
+	if err := md.LoadRDB(); err != nil {
+		t.Fatalf("LoadRDB: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "homebrew.sms")
+	os.WriteFile(path, rom, 0644)
+	s := NewScanner(nil, nil, nil, false, []string{".sms"}, romloader.Options{}, md, 7)
+	s.processROM(path)
+
+	game := s.games[fmt.Sprintf("%08x", crc)]
+	if game == nil {
+		t.Fatal("game not added")
+	}
+	if game.Name != "Homebrew (World)" {
+		t.Errorf("Name = %q, want the DAT name", game.Name)
+	}
+	// A DAT match belongs to no variant
+	if game.System != "" || game.ConsoleID != 7 {
+		t.Errorf("System = %q, ConsoleID = %d, want no variant", game.System, game.ConsoleID)
+	}
+	if len(s.rumbleQueue) != 0 {
+		t.Errorf("rumble queued for a DAT match: %+v", s.rumbleQueue)
+	}
+	if len(s.artworkQueue) != 1 || s.artworkQueue[0].variantIdx != -1 {
+		t.Errorf("artwork queue = %+v, want one job for every variant", s.artworkQueue)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/ebitenui/ebitenui/image"
 	"github.com/ebitenui/ebitenui/widget"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/sqweek/dialog"
 	"github.com/user-none/eblitui/romloader"
 	"github.com/user-none/eblitui/standalone/achievements"
 	"github.com/user-none/eblitui/standalone/storage"
@@ -111,7 +112,9 @@ func (s *DetailScreen) loadAchievementProgress() {
 
 	// Fallback: compute hash from ROM if not in RDB
 	if md5Hash == "" {
-		romData, _, err := romloader.Load(s.game.File, s.callback.GetExtensions())
+		opts := s.callback.GetLoadOptions()
+		opts.Patches = s.game.Patches
+		res, err := romloader.LoadWith(s.game.File, s.callback.GetExtensions(), opts)
 		if err != nil {
 			s.achMu.Lock()
 			s.achLoading = false
@@ -124,7 +127,7 @@ func (s *DetailScreen) loadAchievementProgress() {
 		if s.game.ConsoleID != 0 {
 			gameConsoleID = uint32(s.game.ConsoleID)
 		}
-		md5Hash = s.achievementManager.ComputeGameHash(romData, gameConsoleID)
+		md5Hash = s.achievementManager.ComputeGameHash(res.Data, gameConsoleID)
 	}
 
 	// Look up progress using MD5
@@ -244,6 +247,14 @@ func (s *DetailScreen) Build() *widget.Container {
 	})
 	buttonContainer.AddChild(favButton)
 
+	if !s.game.Missing {
+		patchButton := style.TextButton("Apply Patch", style.ButtonPaddingMedium, func(args *widget.ButtonClickedEventArgs) {
+			s.onApplyPatch()
+		})
+		s.RegisterFocusButton("patch", patchButton)
+		buttonContainer.AddChild(patchButton)
+	}
+
 	toolbarRight.AddChild(buttonContainer)
 
 	// Main content container (horizontal: box art | metadata)
@@ -406,6 +417,21 @@ func (s *DetailScreen) Build() *widget.Container {
 	metadataContainer.AddChild(s.buildMetadataRow("Last Played", style.FormatLastPlayed(s.game.LastPlayed), valueWidth))
 	metadataContainer.AddChild(s.buildMetadataRow("Added", style.FormatDate(s.game.Added), valueWidth))
 
+	// Display crop section (per-game overscan)
+	if !s.game.Missing {
+		metadataContainer.AddChild(s.buildSectionHeader("Display Crop"))
+		metadataContainer.AddChild(s.buildCropRow("Top", "crop-top", &s.game.Settings.CropTop))
+		metadataContainer.AddChild(s.buildCropRow("Bottom", "crop-bottom", &s.game.Settings.CropBottom))
+		metadataContainer.AddChild(s.buildCropRow("Left", "crop-left", &s.game.Settings.CropLeft))
+		metadataContainer.AddChild(s.buildCropRow("Right", "crop-right", &s.game.Settings.CropRight))
+	}
+
+	// Shader preset section (only when presets exist)
+	if !s.game.Missing && len(s.config.Shaders.Presets) > 0 {
+		metadataContainer.AddChild(s.buildSectionHeader("Shaders"))
+		metadataContainer.AddChild(s.buildShaderPresetRow())
+	}
+
 	// Achievements section (only if logged in)
 	if s.achievementManager != nil && s.achievementManager.IsLoggedIn() {
 		metadataContainer.AddChild(s.buildSectionHeader("Achievements"))
@@ -456,6 +482,42 @@ func (s *DetailScreen) Build() *widget.Container {
 	return rootContainer
 }
 
+// onApplyPatch opens a file dialog for an IPS, BPS or UPS patch and adds
+// the patched game to the library as its own entry.
+func (s *DetailScreen) onApplyPatch() {
+	game := s.game
+	// Run dialog in goroutine to avoid blocking Ebiten's main thread
+	go func() {
+		path, err := dialog.File().
+			Title("Select Patch").
+			Filter("ROM Patches", "ips", "bps", "ups").
+			Load()
+		if err != nil {
+			return // User cancelled or error
+		}
+
+		opts := s.callback.GetLoadOptions()
+		opts.Hashes = romloader.HashCRC32
+		opts.Discard = true
+		opts.Patches = append(append([]string(nil), game.Patches...), path)
+		res, err := romloader.LoadWith(game.File, s.callback.GetExtensions(), opts)
+		if err != nil {
+			s.callback.ShowNotification("Patch does not apply to this game")
+			return
+		}
+
+		crc := fmt.Sprintf("%08x", res.CRC32)
+		if s.library.GetGame(crc) != nil {
+			s.callback.ShowNotification("Patched game is already in the library")
+			return
+		}
+		entry := s.library.AddPatchedGame(game, path, crc)
+		storage.SaveLibrary(s.library)
+		s.callback.ShowNotification("Added " + entry.DisplayName)
+		s.callback.RequestRebuild()
+	}()
+}
+
 // loadBoxArtScaled loads and scales the box art image for the current game
 func (s *DetailScreen) loadBoxArtScaled(maxWidth, maxHeight int) *ebiten.Image {
 	if s.game == nil {
@@ -582,6 +644,153 @@ func (s *DetailScreen) buildMetadataRow(label, value string, valuePixelWidth int
 	return row
 }
 
+// buildCropRow creates a crop row with label, pixel value and -/+ buttons.
+// value points into the game's settings and is saved on every change.
+func (s *DetailScreen) buildCropRow(label, key string, value *int) *widget.Container {
+	row := widget.NewContainer(
+		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(style.Surface)),
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(2),
+			widget.GridLayoutOpts.Stretch([]bool{true, false}, []bool{true}),
+			widget.GridLayoutOpts.Spacing(style.DefaultSpacing, 0),
+			widget.GridLayoutOpts.Padding(widget.NewInsetsSimple(style.SmallSpacing)),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	labelText := widget.NewText(
+		widget.TextOpts.Text(label, style.FontFace(), style.TextSecondary),
+		widget.TextOpts.Position(widget.TextPositionStart, widget.TextPositionCenter),
+	)
+	row.AddChild(labelText)
+
+	controls := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(style.SmallSpacing),
+		)),
+	)
+
+	valueText := widget.NewText(
+		widget.TextOpts.Text(fmt.Sprintf("%d px", *value), style.FontFace(), style.Text),
+		widget.TextOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			}),
+			widget.WidgetOpts.MinSize(style.Px(50), 0),
+		),
+	)
+
+	update := func(delta int) {
+		*value = max(0, min(*value+delta, storage.MaxCrop))
+		storage.SaveLibrary(s.library)
+		valueText.Label = fmt.Sprintf("%d px", *value)
+	}
+
+	decBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("-", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			update(-1)
+		}),
+	)
+	s.RegisterFocusButton(key+"-dec", decBtn)
+
+	incBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("+", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			update(1)
+		}),
+	)
+	s.RegisterFocusButton(key+"-inc", incBtn)
+
+	controls.AddChild(decBtn)
+	controls.AddChild(valueText)
+	controls.AddChild(incBtn)
+	row.AddChild(controls)
+
+	return row
+}
+
+// buildShaderPresetRow creates a cycle button row selecting the game's
+// shader preset. "Global" uses the Game shaders from Video settings.
+func (s *DetailScreen) buildShaderPresetRow() *widget.Container {
+	presets := s.config.Shaders.Presets
+	current := s.game.Settings.ShaderPreset
+	if s.config.Shaders.FindPreset(current) == nil {
+		current = ""
+	}
+
+	// Cycle order: Global, then each preset in saved order
+	displayName := "Global"
+	next := presets[0].Name
+	for i, p := range presets {
+		if p.Name == current {
+			displayName = p.Name
+			next = ""
+			if i+1 < len(presets) {
+				next = presets[i+1].Name
+			}
+			break
+		}
+	}
+
+	row := widget.NewContainer(
+		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(style.Surface)),
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(2),
+			widget.GridLayoutOpts.Stretch([]bool{true, false}, []bool{true}),
+			widget.GridLayoutOpts.Spacing(style.DefaultSpacing, 0),
+			widget.GridLayoutOpts.Padding(widget.NewInsetsSimple(style.SmallSpacing)),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	row.AddChild(widget.NewText(
+		widget.TextOpts.Text("Preset", style.FontFace(), style.TextSecondary),
+		widget.TextOpts.Position(widget.TextPositionStart, widget.TextPositionCenter),
+	))
+
+	cycleBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text(displayName, style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+			widget.WidgetOpts.MinSize(style.Px(60), 0),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			s.game.Settings.ShaderPreset = next
+			storage.SaveLibrary(s.library)
+			s.SetPendingFocus("shader-preset")
+			s.callback.RequestRebuild()
+		}),
+	)
+	s.RegisterFocusButton("shader-preset", cycleBtn)
+	row.AddChild(cycleBtn)
+
+	return row
+}
+
 // buildAchievementSection creates the achievements section content
 func (s *DetailScreen) buildAchievementSection(valuePixelWidth int) *widget.Container {
 	container := widget.NewContainer(
@@ -246,6 +246,8 @@ func (s *SettingsScreen) setupVideoNav() {
 	s.SetNavTransition("video-core-opts", types.DirLeft, "sidebar", types.NavIndexFirst)
 	s.SetNavTransition("video-preprocess", types.DirLeft, "sidebar", types.NavIndexFirst)
 	s.SetNavTransition("video-shaders", types.DirLeft, "sidebar", types.NavIndexFirst)
+	s.SetNavTransition("video-preset-save", types.DirLeft, "sidebar", types.NavIndexFirst)
+	s.SetNavTransition("video-presets", types.DirLeft, "sidebar", types.NavIndexFirst)
 }
 
 func (s *SettingsScreen) setupAudioNav() {
@@ -325,6 +327,8 @@ func (s *SettingsScreen) EnsureFocusedVisible(focused widget.Focuser) {
 func (s *SettingsScreen) Update() {
 	if s.selectedSection >= 0 && s.selectedSection < len(s.sections) {
 		switch s.sections[s.selectedSection].focusKey {
+		case "section-video":
+			s.video.Update()
 		case "section-input":
 			s.input.Update()
 		case "section-achievements":
@@ -15,6 +15,8 @@ const (
 	volumeMin  = 0.0
 	volumeMax  = 2.0
 	volumeStep = 0.1
+
+	latencyStepMs = 10
 )
 
 // AudioSection manages audio settings
@@ -23,8 +25,9 @@ type AudioSection struct {
 	config     *storage.Config
 	systemInfo coreif.SystemInfo
 
-	// Live-updated text widget (avoid rebuild on +/- to preserve focus)
-	volumeValueText *widget.Text
+	// Live-updated text widgets (avoid rebuild on +/- to preserve focus)
+	volumeValueText  *widget.Text
+	latencyValueText *widget.Text
 }
 
 // NewAudioSection creates a new audio section
@@ -86,6 +89,18 @@ func (a *AudioSection) Build(focus types.FocusManager) *widget.Container {
 	// Fast-forward mute toggle
 	section.AddChild(a.buildFastForwardMuteRow(focus))
 
+	// Latency mode and custom value
+	section.AddChild(a.buildLatencyRow(focus))
+	if a.config.Audio.Latency == "custom" {
+		section.AddChild(a.buildCustomLatencyRow(focus))
+	}
+	section.AddChild(widget.NewText(
+		widget.TextOpts.Text("Latency changes take effect after restarting the app.", style.FontFace(), style.TextSecondary),
+	))
+
+	// Diagnostics overlay toggle
+	section.AddChild(a.buildDiagnosticsRow(focus))
+
 	a.setupNavigation(focus)
 
 	scrollContainer, vSlider, scrollWrapper := style.ScrollableContainer(style.ScrollableOpts{
@@ -117,6 +132,12 @@ func (a *AudioSection) setupNavigation(focus types.FocusManager) {
 	focus.RegisterNavZone("audio-mute", types.NavZoneHorizontal, []string{"audio-mute"}, 0)
 	focus.RegisterNavZone("audio-volume", types.NavZoneGrid, []string{"audio-vol-dec", "audio-vol-inc"}, 2)
 	focus.RegisterNavZone("audio-ff-mute", types.NavZoneHorizontal, []string{"audio-ff-mute"}, 0)
+	focus.RegisterNavZone("audio-latency", types.NavZoneHorizontal, []string{"audio-latency"}, 0)
+	custom := a.config.Audio.Latency == "custom"
+	if custom {
+		focus.RegisterNavZone("audio-latency-ms", types.NavZoneGrid, []string{"audio-lat-dec", "audio-lat-inc"}, 2)
+	}
+	focus.RegisterNavZone("audio-diagnostics", types.NavZoneHorizontal, []string{"audio-diagnostics"}, 0)
 
 	if len(coreOptKeys) > 0 {
 		focus.SetNavTransition("audio-core-opts", types.DirDown, "audio-mute", types.NavIndexFirst)
@@ -126,6 +147,17 @@ func (a *AudioSection) setupNavigation(focus types.FocusManager) {
 	focus.SetNavTransition("audio-volume", types.DirUp, "audio-mute", types.NavIndexFirst)
 	focus.SetNavTransition("audio-volume", types.DirDown, "audio-ff-mute", types.NavIndexFirst)
 	focus.SetNavTransition("audio-ff-mute", types.DirUp, "audio-volume", types.NavIndexFirst)
+	focus.SetNavTransition("audio-ff-mute", types.DirDown, "audio-latency", types.NavIndexFirst)
+	focus.SetNavTransition("audio-latency", types.DirUp, "audio-ff-mute", types.NavIndexFirst)
+	if custom {
+		focus.SetNavTransition("audio-latency", types.DirDown, "audio-latency-ms", types.NavIndexFirst)
+		focus.SetNavTransition("audio-latency-ms", types.DirUp, "audio-latency", types.NavIndexFirst)
+		focus.SetNavTransition("audio-latency-ms", types.DirDown, "audio-diagnostics", types.NavIndexFirst)
+		focus.SetNavTransition("audio-diagnostics", types.DirUp, "audio-latency-ms", types.NavIndexFirst)
+	} else {
+		focus.SetNavTransition("audio-latency", types.DirDown, "audio-diagnostics", types.NavIndexFirst)
+		focus.SetNavTransition("audio-diagnostics", types.DirUp, "audio-latency", types.NavIndexFirst)
+	}
 }
 
 // buildVolumeRow creates the volume control row with [-] value [+] buttons
@@ -290,3 +322,171 @@ func (a *AudioSection) buildFastForwardMuteRow(focus types.FocusManager) *widget
 
 	return row
 }
+
+// buildLatencyRow creates a cycle button row for the audio latency mode
+func (a *AudioSection) buildLatencyRow(focus types.FocusManager) *widget.Container {
+	current := a.config.Audio.Latency
+	displayName := storage.AudioLatencyDisplayName(current)
+	if current != "custom" {
+		displayName = fmt.Sprintf("%s (%d ms)", displayName, storage.AudioLatencyMs(a.config.Audio))
+	}
+
+	nextIdx := 0
+	for i, l := range storage.ValidAudioLatencies {
+		if l == current {
+			nextIdx = (i + 1) % len(storage.ValidAudioLatencies)
+			break
+		}
+	}
+
+	row := style.SettingsRow(2)
+
+	label := widget.NewText(
+		widget.TextOpts.Text("Latency", style.FontFace(), style.Text),
+		widget.TextOpts.Position(widget.TextPositionStart, widget.TextPositionCenter),
+	)
+	row.AddChild(label)
+
+	cycleBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text(displayName, style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+			widget.WidgetOpts.MinSize(style.Px(60), 0),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			a.config.Audio.Latency = storage.ValidAudioLatencies[nextIdx]
+			storage.SaveConfig(a.config)
+			focus.SetPendingFocus("audio-latency")
+			a.callback.RequestRebuild()
+		}),
+	)
+	focus.RegisterFocusButton("audio-latency", cycleBtn)
+	row.AddChild(cycleBtn)
+
+	return row
+}
+
+// buildCustomLatencyRow creates the custom latency row with [-] value [+] buttons
+func (a *AudioSection) buildCustomLatencyRow(focus types.FocusManager) *widget.Container {
+	row := style.SettingsRow(2)
+
+	labelText := widget.NewText(
+		widget.TextOpts.Text("Custom Latency", style.FontFace(), style.Text),
+		widget.TextOpts.Position(widget.TextPositionStart, widget.TextPositionCenter),
+	)
+	row.AddChild(labelText)
+
+	controls := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionHorizontal),
+			widget.RowLayoutOpts.Spacing(style.SmallSpacing),
+		)),
+	)
+
+	a.latencyValueText = widget.NewText(
+		widget.TextOpts.Text(a.latencyLabel(), style.FontFace(), style.Text),
+		widget.TextOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			}),
+			widget.WidgetOpts.MinSize(style.Px(70), 0),
+		),
+	)
+
+	decBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("-", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			a.config.Audio.LatencyMs -= latencyStepMs
+			if a.config.Audio.LatencyMs < storage.AudioLatencyMinMs {
+				a.config.Audio.LatencyMs = storage.AudioLatencyMinMs
+			}
+			storage.SaveConfig(a.config)
+			a.updateLatencyLabel()
+		}),
+	)
+	focus.RegisterFocusButton("audio-lat-dec", decBtn)
+	controls.AddChild(decBtn)
+
+	controls.AddChild(a.latencyValueText)
+
+	incBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("+", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{
+				Position: widget.RowLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			a.config.Audio.LatencyMs += latencyStepMs
+			if a.config.Audio.LatencyMs > storage.AudioLatencyMaxMs {
+				a.config.Audio.LatencyMs = storage.AudioLatencyMaxMs
+			}
+			storage.SaveConfig(a.config)
+			a.updateLatencyLabel()
+		}),
+	)
+	focus.RegisterFocusButton("audio-lat-inc", incBtn)
+	controls.AddChild(incBtn)
+
+	row.AddChild(controls)
+
+	return row
+}
+
+// latencyLabel returns the custom latency as a string
+func (a *AudioSection) latencyLabel() string {
+	return fmt.Sprintf("%d ms", a.config.Audio.LatencyMs)
+}
+
+// updateLatencyLabel updates the custom latency label in-place
+func (a *AudioSection) updateLatencyLabel() {
+	if a.latencyValueText != nil {
+		a.latencyValueText.Label = a.latencyLabel()
+	}
+}
+
+// buildDiagnosticsRow creates the audio diagnostics overlay toggle row
+func (a *AudioSection) buildDiagnosticsRow(focus types.FocusManager) *widget.Container {
+	row := style.SettingsRow(2)
+
+	label := widget.NewText(
+		widget.TextOpts.Text("Show Audio Diagnostics", style.FontFace(), style.Text),
+		widget.TextOpts.Position(widget.TextPositionStart, widget.TextPositionCenter),
+	)
+	row.AddChild(label)
+
+	toggleBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ActiveButtonImage(a.config.Audio.ShowDiagnostics)),
+		widget.ButtonOpts.Text(boolToOnOff(a.config.Audio.ShowDiagnostics), style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+			widget.WidgetOpts.MinSize(style.Px(50), 0),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			a.config.Audio.ShowDiagnostics = !a.config.Audio.ShowDiagnostics
+			storage.SaveConfig(a.config)
+			focus.SetPendingFocus("audio-diagnostics")
+			a.callback.RequestRebuild()
+		}),
+	)
+	focus.RegisterFocusButton("audio-diagnostics", toggleBtn)
+	row.AddChild(toggleBtn)
+
+	return row
+}
@@ -1,6 +1,8 @@
 package settings
 
 import (
+	"strconv"
+
 	"github.com/ebitenui/ebitenui/widget"
 	"github.com/user-none/eblitui/coreif"
 	"github.com/user-none/eblitui/standalone/shader"
@@ -14,6 +16,10 @@ type VideoSection struct {
 	callback   types.ScreenCallback
 	config     *storage.Config
 	systemInfo coreif.SystemInfo
+
+	// Preset name entry
+	textInputs      *style.TextInputGroup
+	presetNameInput *widget.TextInput
 }
 
 // NewVideoSection creates a new video section
@@ -22,9 +28,15 @@ func NewVideoSection(callback types.ScreenCallback, config *storage.Config, syst
 		callback:   callback,
 		config:     config,
 		systemInfo: systemInfo,
+		textInputs: style.NewTextInputGroup(),
 	}
 }
 
+// Update handles per-frame updates (clipboard shortcuts for the preset name)
+func (v *VideoSection) Update() {
+	v.textInputs.Update()
+}
+
 // SystemInfo returns the system info for navigation setup
 func (v *VideoSection) SystemInfo() coreif.SystemInfo {
 	return v.systemInfo
@@ -80,12 +92,29 @@ func (v *VideoSection) setupNavigation(focus types.FocusManager) {
 		focus.RegisterNavZone("video-preprocess", types.NavZoneGrid, preprocessKeys, 1)
 	}
 
-	// Regular shaders: UI+Game, 2-column grid
+	// Regular shaders: UI+Game, 2-column grid. A shader limited to one
+	// context repeats its only button in both columns. Param rows follow
+	// their shader as -/+ pairs.
 	shaderKeys := make([]string, 0)
 	for _, info := range shader.AvailableShaders {
-		if !info.Preprocess {
-			shaderKeys = append(shaderKeys, "shader-ui-"+info.ID)
-			shaderKeys = append(shaderKeys, "shader-game-"+info.ID)
+		if info.Preprocess {
+			continue
+		}
+		uiKey := "shader-ui-" + info.ID
+		gameKey := "shader-game-" + info.ID
+		switch info.Context {
+		case shader.ContextUI:
+			gameKey = uiKey
+		case shader.ContextGame:
+			uiKey = gameKey
+		}
+		shaderKeys = append(shaderKeys, uiKey, gameKey)
+
+		if v.showShaderParams(info) {
+			for _, p := range info.Params {
+				key := paramKey(info.ID, p.Uniform)
+				shaderKeys = append(shaderKeys, key+"-dec", key+"-inc")
+			}
 		}
 	}
 	if len(shaderKeys) > 0 {
@@ -111,6 +140,28 @@ func (v *VideoSection) setupNavigation(focus types.FocusManager) {
 			focus.SetNavTransition("video-shaders", types.DirUp, "video-aspect", types.NavIndexFirst)
 		}
 	}
+
+	// Presets: save button, then a Load/Delete grid
+	focus.RegisterNavZone("video-preset-save", types.NavZoneHorizontal, []string{"preset-save"}, 0)
+	lastShaderZone := "video-aspect"
+	if len(shaderKeys) > 0 {
+		lastShaderZone = "video-shaders"
+	} else if len(preprocessKeys) > 0 {
+		lastShaderZone = "video-preprocess"
+	}
+	focus.SetNavTransition(lastShaderZone, types.DirDown, "video-preset-save", types.NavIndexFirst)
+	focus.SetNavTransition("video-preset-save", types.DirUp, lastShaderZone, types.NavIndexLast)
+
+	presetKeys := make([]string, 0, len(v.config.Shaders.Presets)*2)
+	for i := range v.config.Shaders.Presets {
+		key := strconv.Itoa(i)
+		presetKeys = append(presetKeys, "preset-load-"+key, "preset-delete-"+key)
+	}
+	if len(presetKeys) > 0 {
+		focus.RegisterNavZone("video-presets", types.NavZoneGrid, presetKeys, 2)
+		focus.SetNavTransition("video-preset-save", types.DirDown, "video-presets", types.NavIndexFirst)
+		focus.SetNavTransition("video-presets", types.DirUp, "video-preset-save", types.NavIndexFirst)
+	}
 }
 
 // buildShadersList creates the scrollable shaders list with core options and header
@@ -153,8 +204,22 @@ func (v *VideoSection) buildShadersList(focus types.FocusManager) widget.Preferr
 
 	for _, shaderInfo := range shader.AvailableShaders {
 		listContent.AddChild(v.buildShaderRow(shaderInfo, focus))
+		if v.showShaderParams(shaderInfo) {
+			listContent.AddChild(v.buildShaderParams(shaderInfo, focus))
+		}
 	}
 
+	// User shaders that failed to load
+	if errs := v.buildUserShaderErrors(); errs != nil {
+		listContent.AddChild(errs)
+	}
+
+	// Spacer before presets
+	listContent.AddChild(widget.NewText(
+		widget.TextOpts.Text("", style.FontFace(), style.TextSecondary),
+	))
+	listContent.AddChild(v.buildPresetsSection(focus))
+
 	// Wrap in scrollable container
 	scrollContainer, vSlider, scrollWrapper := style.ScrollableContainer(style.ScrollableOpts{
 		Content:     listContent,
@@ -315,7 +380,18 @@ func (v *VideoSection) buildShaderRow(info shader.ShaderInfo, focus types.FocusM
 		row.AddChild(placeholder)
 	}
 
-	// Game toggle button
+	// Game toggle button (hidden for UI-only user shaders)
+	if info.Context&shader.ContextGame == 0 {
+		row.AddChild(widget.NewContainer(
+			widget.ContainerOpts.WidgetOpts(
+				widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+					VerticalPosition: widget.GridLayoutPositionCenter,
+				}),
+			),
+		))
+		return row
+	}
+
 	gameBtn := widget.NewButton(
 		widget.ButtonOpts.Image(style.ActiveButtonImage(gameEnabled)),
 		widget.ButtonOpts.Text("Game", style.FontFace(), style.ButtonTextColor()),
@@ -0,0 +1,198 @@
+package settings
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/ebitenui/ebitenui/widget"
+	"github.com/user-none/eblitui/standalone/shader"
+	"github.com/user-none/eblitui/standalone/storage"
+	"github.com/user-none/eblitui/standalone/style"
+	"github.com/user-none/eblitui/standalone/types"
+)
+
+// defaultParamSteps is the slider resolution for params without a step
+const defaultParamSteps = 100
+
+// paramKey returns the focus key prefix for a shader parameter's buttons
+func paramKey(id, uniform string) string {
+	return "shader-param-" + id + "-" + uniform
+}
+
+// showShaderParams reports whether a shader's parameter rows are shown.
+// Params are only listed for shaders that are enabled somewhere so the
+// list stays short.
+func (v *VideoSection) showShaderParams(info shader.ShaderInfo) bool {
+	if info.Preprocess || len(info.Params) == 0 {
+		return false
+	}
+	return v.isShaderEnabledForUI(info.ID) || v.isShaderEnabledForGame(info.ID)
+}
+
+// buildShaderParams creates one slider row per parameter of a shader.
+// Changes apply immediately so the effect can be previewed live.
+func (v *VideoSection) buildShaderParams(info shader.ShaderInfo, focus types.FocusManager) *widget.Container {
+	container := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Spacing(style.TinySpacing),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	for _, p := range info.Params {
+		container.AddChild(v.buildShaderParamRow(info.ID, p, focus))
+	}
+
+	return container
+}
+
+// buildShaderParamRow creates a row: [label] [-] [slider] [value] [+]
+func (v *VideoSection) buildShaderParamRow(id string, p shader.ShaderParam, focus types.FocusManager) *widget.Container {
+	row := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(5),
+			widget.GridLayoutOpts.Stretch([]bool{true, false, false, false, false}, []bool{true}),
+			widget.GridLayoutOpts.Spacing(style.DefaultSpacing, 0),
+			widget.GridLayoutOpts.Padding(&widget.Insets{Left: style.DefaultPadding}),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	row.AddChild(widget.NewText(
+		widget.TextOpts.Text(p.Label, style.FontFace(), style.TextSecondary),
+		widget.TextOpts.Position(widget.TextPositionStart, widget.TextPositionCenter),
+	))
+
+	steps := paramSteps(p)
+	current := paramToStep(p, shader.ParamValue(id, p, v.config.Shaders.Params))
+	key := paramKey(id, p.Uniform)
+
+	valueText := widget.NewText(
+		widget.TextOpts.Text(formatParam(p, paramFromStep(p, current)), style.FontFace(), style.Text),
+		widget.TextOpts.Position(widget.TextPositionCenter, widget.TextPositionCenter),
+		widget.TextOpts.WidgetOpts(
+			widget.WidgetOpts.MinSize(int(style.MeasureWidth("0.000")), 0),
+		),
+	)
+
+	var slider *widget.Slider
+	decBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("-", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			if slider.Current > 0 {
+				slider.Current--
+			}
+		}),
+	)
+	focus.RegisterFocusButton(key+"-dec", decBtn)
+	row.AddChild(decBtn)
+
+	// Slider changes (drag or -/+) are reported on the next render
+	slider = widget.NewSlider(
+		widget.SliderOpts.TabOrder(-1), // Navigated through the -/+ buttons
+		widget.SliderOpts.Direction(widget.DirectionHorizontal),
+		widget.SliderOpts.MinMax(0, steps),
+		widget.SliderOpts.InitialCurrent(current),
+		widget.SliderOpts.Images(style.SliderTrackImage(), style.SliderButtonImage()),
+		widget.SliderOpts.FixedHandleSize(style.Px(12)),
+		widget.SliderOpts.PageSizeFunc(func() int {
+			return 1
+		}),
+		widget.SliderOpts.WidgetOpts(
+			widget.WidgetOpts.MinSize(style.Px(120), style.Px(16)),
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+		),
+		widget.SliderOpts.ChangedHandler(func(args *widget.SliderChangedEventArgs) {
+			value := paramFromStep(p, args.Current)
+			valueText.Label = formatParam(p, value)
+			if shader.ParamValue(id, p, v.config.Shaders.Params) == value {
+				return
+			}
+			v.config.Shaders.SetParam(id, p.Uniform, value)
+			storage.SaveConfig(v.config)
+		}),
+	)
+	row.AddChild(slider)
+	row.AddChild(valueText)
+
+	incBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("+", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			if slider.Current < steps {
+				slider.Current++
+			}
+		}),
+	)
+	focus.RegisterFocusButton(key+"-inc", incBtn)
+	row.AddChild(incBtn)
+
+	return row
+}
+
+// paramSteps returns the number of slider steps across a param's range
+func paramSteps(p shader.ShaderParam) int {
+	if p.Step <= 0 {
+		return defaultParamSteps
+	}
+	steps := int(math.Round((p.Max - p.Min) / p.Step))
+	if steps < 1 {
+		return 1
+	}
+	return steps
+}
+
+// paramToStep converts a param value to the nearest slider step
+func paramToStep(p shader.ShaderParam, value float64) int {
+	steps := paramSteps(p)
+	step := int(math.Round((value - p.Min) / (p.Max - p.Min) * float64(steps)))
+	return max(0, min(step, steps))
+}
+
+// paramFromStep converts a slider step back to a param value. Rounded to
+// the step's precision so repeated -/+ presses don't accumulate error.
+func paramFromStep(p shader.ShaderParam, step int) float64 {
+	value := p.Min + (p.Max-p.Min)*float64(step)/float64(paramSteps(p))
+	scale := math.Pow(10, float64(paramDecimals(p)))
+	return math.Round(value*scale) / scale
+}
+
+// paramDecimals returns how many decimal places a param's step needs
+func paramDecimals(p shader.ShaderParam) int {
+	step := p.Step
+	if step <= 0 {
+		step = (p.Max - p.Min) / defaultParamSteps
+	}
+	for d := 0; d < 4; d++ {
+		scaled := step * math.Pow(10, float64(d))
+		if math.Abs(scaled-math.Round(scaled)) < 1e-9 {
+			return d
+		}
+	}
+	return 4
+}
+
+// formatParam formats a param value using its step's precision
+func formatParam(p shader.ShaderParam, value float64) string {
+	return strconv.FormatFloat(value, 'f', paramDecimals(p), 64)
+}
@@ -0,0 +1,246 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ebitenui/ebitenui/widget"
+	"github.com/user-none/eblitui/standalone/shader"
+	"github.com/user-none/eblitui/standalone/storage"
+	"github.com/user-none/eblitui/standalone/style"
+	"github.com/user-none/eblitui/standalone/types"
+)
+
+// buildUserShaderErrors lists user shaders that failed to load or compile.
+// Returns nil when there are no errors.
+func (v *VideoSection) buildUserShaderErrors() *widget.Container {
+	loadErrs := shader.UserShaderErrors()
+	if len(loadErrs) == 0 {
+		return nil
+	}
+
+	container := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Spacing(style.TinySpacing),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	container.AddChild(widget.NewText(
+		widget.TextOpts.Text("User Shader Errors", style.FontFace(), style.Accent),
+	))
+
+	maxW := v.maxShaderLabelWidth()
+	face := *style.FontFace()
+	for _, e := range loadErrs {
+		// Kage compiler errors can span lines; show the first in the row
+		// and the full message in the tooltip
+		msg := e.Error()
+		firstLine, _, _ := strings.Cut(msg, "\n")
+		display, _ := style.TruncateToWidth(firstLine, face, maxW)
+		container.AddChild(widget.NewText(
+			widget.TextOpts.Text(display, style.FontFace(), style.TextSecondary),
+			widget.TextOpts.WidgetOpts(
+				widget.WidgetOpts.ToolTip(
+					widget.NewToolTip(
+						widget.ToolTipOpts.Content(style.TooltipContent(msg)),
+					),
+				),
+			),
+		))
+	}
+
+	return container
+}
+
+// buildPresetsSection creates the shader presets header, the save row,
+// and one row per saved preset.
+func (v *VideoSection) buildPresetsSection(focus types.FocusManager) *widget.Container {
+	container := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewRowLayout(
+			widget.RowLayoutOpts.Direction(widget.DirectionVertical),
+			widget.RowLayoutOpts.Spacing(style.SmallSpacing),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	container.AddChild(widget.NewText(
+		widget.TextOpts.Text("Shader Presets", style.FontFace(), style.Accent),
+	))
+	container.AddChild(widget.NewText(
+		widget.TextOpts.Text("Save the Game shaders as a preset to select it per game.", style.FontFace(), style.TextSecondary),
+	))
+
+	// Save row: [name input (stretch)] [Save]
+	saveRow := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(2),
+			widget.GridLayoutOpts.Stretch([]bool{true, false}, []bool{true}),
+			widget.GridLayoutOpts.Spacing(style.DefaultSpacing, 0),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	v.presetNameInput = style.StyledTextInput("Preset name", false, style.Px(200))
+	v.textInputs.Add(v.presetNameInput)
+	saveRow.AddChild(v.presetNameInput)
+
+	saveBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("Save", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			v.savePreset(v.presetNameInput.GetText())
+			storage.SaveConfig(v.config)
+			focus.SetPendingFocus("preset-save")
+			v.callback.RequestRebuild()
+		}),
+	)
+	focus.RegisterFocusButton("preset-save", saveBtn)
+	saveRow.AddChild(saveBtn)
+	container.AddChild(saveRow)
+
+	for i, preset := range v.config.Shaders.Presets {
+		container.AddChild(v.buildPresetRow(i, preset, focus))
+	}
+
+	return container
+}
+
+// buildPresetRow creates a row for a saved preset with Load and Delete buttons
+func (v *VideoSection) buildPresetRow(index int, preset storage.ShaderPreset, focus types.FocusManager) *widget.Container {
+	row := widget.NewContainer(
+		widget.ContainerOpts.Layout(widget.NewGridLayout(
+			widget.GridLayoutOpts.Columns(3),
+			widget.GridLayoutOpts.Stretch([]bool{true, false, false}, []bool{true}),
+			widget.GridLayoutOpts.Spacing(style.DefaultSpacing, 0),
+		)),
+		widget.ContainerOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.RowLayoutData{Stretch: true}),
+		),
+	)
+
+	maxW := v.maxShaderLabelWidth()
+	face := *style.FontFace()
+	displayName, _ := style.TruncateToWidth(preset.Name, face, maxW)
+	row.AddChild(style.LabeledText(displayName, presetSummary(preset)))
+
+	key := strconv.Itoa(index)
+
+	loadBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("Load", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			v.config.Shaders.LoadPreset(preset)
+			storage.SaveConfig(v.config)
+			focus.SetPendingFocus("preset-load-" + key)
+			v.callback.RequestRebuild()
+		}),
+	)
+	focus.RegisterFocusButton("preset-load-"+key, loadBtn)
+	row.AddChild(loadBtn)
+
+	deleteBtn := widget.NewButton(
+		widget.ButtonOpts.Image(style.ButtonImage()),
+		widget.ButtonOpts.Text("Delete", style.FontFace(), style.ButtonTextColor()),
+		widget.ButtonOpts.TextPadding(widget.NewInsetsSimple(style.ButtonPaddingSmall)),
+		widget.ButtonOpts.WidgetOpts(
+			widget.WidgetOpts.LayoutData(widget.GridLayoutData{
+				VerticalPosition: widget.GridLayoutPositionCenter,
+			}),
+		),
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			presets := v.config.Shaders.Presets
+			v.config.Shaders.Presets = append(presets[:index], presets[index+1:]...)
+			storage.SaveConfig(v.config)
+			focus.SetPendingFocus("preset-save")
+			v.callback.RequestRebuild()
+		}),
+	)
+	focus.RegisterFocusButton("preset-delete-"+key, deleteBtn)
+	row.AddChild(deleteBtn)
+
+	return row
+}
+
+// presetSummary describes a preset's shader count for its row subtitle
+func presetSummary(preset storage.ShaderPreset) string {
+	if len(preset.Shaders) == 1 {
+		return "1 effect"
+	}
+	return fmt.Sprintf("%d effects", len(preset.Shaders))
+}
+
+// savePreset stores the current Game shaders and their parameter values
+// under name, replacing any preset with the same name. An empty name
+// picks the next free "Preset N".
+func (v *VideoSection) savePreset(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = nextPresetName(v.config.Shaders.Presets)
+	}
+
+	preset := storage.ShaderPreset{
+		Name:    name,
+		Shaders: append([]string{}, v.config.Shaders.GameShaders...),
+		Params:  snapshotShaderParams(v.config.Shaders.GameShaders, v.config.Shaders.Params),
+	}
+
+	if existing := v.config.Shaders.FindPreset(name); existing != nil {
+		*existing = preset
+		return
+	}
+	v.config.Shaders.Presets = append(v.config.Shaders.Presets, preset)
+}
+
+// nextPresetName returns the first "Preset N" name not already in use
+func nextPresetName(presets []storage.ShaderPreset) string {
+	cfg := storage.ShaderConfig{Presets: presets}
+	for n := 1; ; n++ {
+		name := fmt.Sprintf("Preset %d", n)
+		if cfg.FindPreset(name) == nil {
+			return name
+		}
+	}
+}
+
+// snapshotShaderParams captures the parameter values in effect for the
+// given shaders, falling back to defaults for values never changed.
+// Returns nil if none of them have parameters.
+func snapshotShaderParams(ids []string, current map[string]map[string]float64) map[string]map[string]float64 {
+	var params map[string]map[string]float64
+	for _, id := range ids {
+		declared := shader.GetShaderParams(id)
+		if len(declared) == 0 {
+			continue
+		}
+		if params == nil {
+			params = make(map[string]map[string]float64)
+		}
+		values := make(map[string]float64, len(declared))
+		for _, p := range declared {
+			values[p.Uniform] = shader.ParamValue(id, p, current)
+		}
+		params[id] = values
+	}
+	return params
+}
@@ -0,0 +1,265 @@
+package shader
+
+import (
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// chainShader is one shader in a pass chain with a compiled shader per
+// pass. compiled is nil when only planning.
+type chainShader struct {
+	id       string
+	passes   []PassInfo
+	compiled []*ebiten.Shader
+}
+
+// passGraph runs a chain of shaders through their passes. It owns the
+// intermediate buffers, history frames, and feedback buffers, all of
+// which persist across frames and are reused while the chain and input
+// size stay the same.
+type passGraph struct {
+	// Cached plan and what it was built for
+	plan     passPlan
+	planIDs  []string
+	planSize image.Point
+	planDest bool
+
+	// Intermediate buffers, one per plan slot
+	slots []*ebiten.Image
+
+	// Previous frame's output for each feedback slot
+	previous map[int]*ebiten.Image
+
+	// Copies of previous input frames, most recent first
+	history []*ebiten.Image
+}
+
+// run draws src through shaders into dst and returns the image holding
+// the result. If dst is nil the result is left in an intermediate buffer,
+// which stays valid until the next run.
+func (g *passGraph) run(dst, src *ebiten.Image, shaders []chainShader, uniforms map[string]interface{}, params map[string]map[string]float64) *ebiten.Image {
+	size := src.Bounds().Size()
+	if !g.planMatches(shaders, size, dst != nil) {
+		g.replan(shaders, size, dst != nil)
+	}
+
+	var result *ebiten.Image
+	for _, step := range g.plan.Steps {
+		out := g.image(step.Output, src, dst)
+		if step.Output >= 0 {
+			out.Clear()
+		}
+		in := g.image(step.Input, src, dst)
+
+		switch step.Kind {
+		case stepResample:
+			drawResampled(out, in, step.Size, step.Filter)
+		case stepPass:
+			g.drawPass(out, in, src, shaders[step.Shader], step, uniforms, params)
+		}
+		result = out
+	}
+
+	g.advance(src)
+	return result
+}
+
+// planMatches reports whether the cached plan was built for this chain
+func (g *passGraph) planMatches(shaders []chainShader, size image.Point, toDest bool) bool {
+	if g.planIDs == nil || g.planSize != size || g.planDest != toDest || len(g.planIDs) != len(shaders) {
+		return false
+	}
+	for i, cs := range shaders {
+		if g.planIDs[i] != cs.id {
+			return false
+		}
+	}
+	return true
+}
+
+// replan rebuilds the plan and sizes the buffers to match it. Buffers that
+// already have the right size are kept.
+func (g *passGraph) replan(shaders []chainShader, size image.Point, toDest bool) {
+	g.plan = planChain(shaders, size, toDest)
+	g.planIDs = make([]string, len(shaders))
+	for i, cs := range shaders {
+		g.planIDs[i] = cs.id
+	}
+	g.planSize = size
+	g.planDest = toDest
+
+	for i := len(g.plan.Slots); i < len(g.slots); i++ {
+		g.slots[i].Deallocate()
+	}
+	if len(g.slots) > len(g.plan.Slots) {
+		g.slots = g.slots[:len(g.plan.Slots)]
+	}
+	for i, slotSize := range g.plan.Slots {
+		if i < len(g.slots) {
+			if g.slots[i].Bounds().Size() == slotSize {
+				continue
+			}
+			g.slots[i].Deallocate()
+			g.slots[i] = ebiten.NewImage(slotSize.X, slotSize.Y)
+		} else {
+			g.slots = append(g.slots, ebiten.NewImage(slotSize.X, slotSize.Y))
+		}
+	}
+
+	// Feedback from a different chain is meaningless; start fresh
+	for _, img := range g.previous {
+		img.Deallocate()
+	}
+	g.previous = make(map[int]*ebiten.Image, len(g.plan.Feedback))
+	for slot := range g.plan.Feedback {
+		slotSize := g.plan.Slots[slot]
+		g.previous[slot] = ebiten.NewImage(slotSize.X, slotSize.Y)
+	}
+
+	g.trimHistory()
+}
+
+// image resolves a plan slot to its image
+func (g *passGraph) image(slot int, src, dst *ebiten.Image) *ebiten.Image {
+	switch slot {
+	case slotSource:
+		return src
+	case slotDest:
+		return dst
+	}
+	return g.slots[slot]
+}
+
+// drawPass runs one shader pass from in to out, binding the pass's extra
+// images after the input
+func (g *passGraph) drawPass(out, in, src *ebiten.Image, cs chainShader, step planStep, uniforms map[string]interface{}, params map[string]map[string]float64) {
+	pass := cs.passes[step.Pass]
+
+	var images [4]*ebiten.Image
+	images[0] = in
+	n := 1
+	for _, slot := range step.Inputs {
+		images[n] = g.image(slot, src, nil)
+		n++
+	}
+	for i := 0; i < pass.History; i++ {
+		images[n] = g.historyFrame(i, src)
+		n++
+	}
+	if pass.Feedback {
+		images[n] = g.previous[step.Output]
+	}
+
+	uniforms = shaderUniforms(cs.id, uniforms, params)
+
+	// DrawRectShader needs every image at the output size; anything else
+	// goes through DrawTrianglesShader, which pixel-unit shaders allow
+	if sameSize(images[:], step.Size) {
+		op := &ebiten.DrawRectShaderOptions{}
+		op.Images = images
+		op.Uniforms = uniforms
+		out.DrawRectShader(step.Size.X, step.Size.Y, cs.compiled[step.Pass], op)
+		return
+	}
+
+	b := in.Bounds()
+	w, h := float32(step.Size.X), float32(step.Size.Y)
+	sx0, sy0, sx1, sy1 := float32(b.Min.X), float32(b.Min.Y), float32(b.Max.X), float32(b.Max.Y)
+	vertices := []ebiten.Vertex{
+		{DstX: 0, DstY: 0, SrcX: sx0, SrcY: sy0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+		{DstX: w, DstY: 0, SrcX: sx1, SrcY: sy0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+		{DstX: 0, DstY: h, SrcX: sx0, SrcY: sy1, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+		{DstX: w, DstY: h, SrcX: sx1, SrcY: sy1, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+	}
+	indices := []uint16{0, 1, 2, 1, 3, 2}
+
+	op := &ebiten.DrawTrianglesShaderOptions{}
+	op.Images = images
+	op.Uniforms = uniforms
+	out.DrawTrianglesShader(vertices, indices, cs.compiled[step.Pass], op)
+}
+
+// historyFrame returns the input from i+1 frames ago. Until enough frames
+// have been seen the oldest available one, or the current input, is used.
+func (g *passGraph) historyFrame(i int, src *ebiten.Image) *ebiten.Image {
+	if len(g.history) == 0 {
+		return src
+	}
+	return g.history[min(i, len(g.history)-1)]
+}
+
+// advance records src in the history and swaps the feedback buffers so
+// this frame's output becomes next frame's previous output
+func (g *passGraph) advance(src *ebiten.Image) {
+	for slot, prev := range g.previous {
+		g.previous[slot], g.slots[slot] = g.slots[slot], prev
+	}
+
+	if g.plan.History == 0 {
+		return
+	}
+
+	// Reuse the oldest frame's buffer once the history is full
+	var frame *ebiten.Image
+	if len(g.history) == g.plan.History {
+		frame = g.history[len(g.history)-1]
+		g.history = g.history[:len(g.history)-1]
+		frame.Clear()
+	} else {
+		frame = ebiten.NewImage(g.planSize.X, g.planSize.Y)
+	}
+	frame.DrawImage(src, nil)
+	g.history = append([]*ebiten.Image{frame}, g.history...)
+}
+
+// trimHistory drops history frames the current plan doesn't need or that
+// no longer match the input size
+func (g *passGraph) trimHistory() {
+	keep := g.history[:0]
+	for _, frame := range g.history {
+		if len(keep) < g.plan.History && frame.Bounds().Size() == g.planSize {
+			keep = append(keep, frame)
+			continue
+		}
+		frame.Deallocate()
+	}
+	g.history = keep
+}
+
+// reset releases all buffers. The next run starts with no history or
+// feedback.
+func (g *passGraph) reset() {
+	for _, img := range g.slots {
+		img.Deallocate()
+	}
+	for _, img := range g.previous {
+		img.Deallocate()
+	}
+	for _, img := range g.history {
+		img.Deallocate()
+	}
+	*g = passGraph{}
+}
+
+// drawResampled draws src scaled to size at the origin of dst using filter
+func drawResampled(dst, src *ebiten.Image, size image.Point, filter PassFilter) {
+	sb := src.Bounds()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(size.X)/float64(sb.Dx()), float64(size.Y)/float64(sb.Dy()))
+	op.Filter = ebiten.FilterNearest
+	if filter == FilterLinear {
+		op.Filter = ebiten.FilterLinear
+	}
+	dst.DrawImage(src, op)
+}
+
+// sameSize reports whether every non-nil image has the given size
+func sameSize(images []*ebiten.Image, size image.Point) bool {
+	for _, img := range images {
+		if img != nil && img.Bounds().Size() != size {
+			return false
+		}
+	}
+	return true
+}
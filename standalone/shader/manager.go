@@ -63,6 +63,9 @@ var hsoftShaderSrc []byte
 //go:embed shaders/rainbow.kage
 var rainbowShaderSrc []byte
 
+//go:embed shaders/ghosting.kage
+var ghostingShaderSrc []byte
+
 // shaderSources maps shader IDs to their Kage source code
 var shaderSources = map[string][]byte{
 	"crt":         crtShaderSrc,
@@ -83,19 +86,18 @@ var shaderSources = map[string][]byte{
 	"vblur":       vblurShaderSrc,
 	"hsoft":       hsoftShaderSrc,
 	"rainbow":     rainbowShaderSrc,
+	"ghosting":    ghostingShaderSrc,
 }
 
 // Manager handles shader compilation, caching, and application
 type Manager struct {
-	// Compiled shader cache
+	// Compiled shader cache, keyed by passKey
 	shaders map[string]*ebiten.Shader
 
-	// Intermediate buffers for shader chaining (ping-pong)
-	bufferA *ebiten.Image
-	bufferB *ebiten.Image
-
-	// Ghosting buffer for phosphor persistence (persistent across frames)
-	ghostingBuffer *ebiten.Image
+	// Pass graphs for the shader chain and for ghosting. Each owns its
+	// intermediate, history, and feedback buffers.
+	chain      passGraph
+	preprocess passGraph
 
 	// xBR scaler for pixel art scaling
 	xbrScaler *XBRScaler
@@ -104,8 +106,8 @@ type Manager struct {
 	frame int
 
 	// Cached shader pipeline (rebuilt only when config changes)
-	cachedShaderIDs     []string
-	cachedSortedShaders []*ebiten.Shader
+	cachedShaderIDs []string
+	cachedChain     []chainShader
 }
 
 // NewManager creates a new shader manager with the given pixel aspect ratio
@@ -124,18 +126,8 @@ func (m *Manager) SetAspectRatioMode(mode string) {
 
 // ResetBuffers clears all effect buffers. Call when switching games.
 func (m *Manager) ResetBuffers() {
-	if m.ghostingBuffer != nil {
-		m.ghostingBuffer.Deallocate()
-		m.ghostingBuffer = nil
-	}
-	if m.bufferA != nil {
-		m.bufferA.Deallocate()
-		m.bufferA = nil
-	}
-	if m.bufferB != nil {
-		m.bufferB.Deallocate()
-		m.bufferB = nil
-	}
+	m.chain.reset()
+	m.preprocess.reset()
 }
 
 // IncrementFrame advances the frame counter for animated shaders
@@ -148,10 +140,10 @@ func (m *Manager) Frame() int {
 	return m.frame
 }
 
-// LoadShader compiles and caches a shader by ID
+// LoadShader compiles and caches every pass of a shader by ID
 func (m *Manager) LoadShader(id string) error {
 	// Already loaded?
-	if _, ok := m.shaders[id]; ok {
+	if _, ok := m.shaders[passKey(id, 0)]; ok {
 		return nil
 	}
 
@@ -161,67 +153,67 @@ func (m *Manager) LoadShader(id string) error {
 		return fmt.Errorf("unknown shader: %s", id)
 	}
 
-	// Compile
-	shader, err := ebiten.NewShader(src)
-	if err != nil {
-		return fmt.Errorf("failed to compile shader %s: %w", id, err)
+	// Compile each pass, keeping none unless all succeed
+	passes := GetShaderPasses(id)
+	compiled := make([]*ebiten.Shader, 0, len(passes))
+	for i, pass := range passes {
+		passSrc := src
+		if pass.Source != nil {
+			passSrc = pass.Source
+		}
+		shader, err := ebiten.NewShader(passSrc)
+		if err != nil {
+			for _, s := range compiled {
+				s.Deallocate()
+			}
+			if len(passes) > 1 {
+				return fmt.Errorf("failed to compile shader %s pass %d: %w", id, i, err)
+			}
+			return fmt.Errorf("failed to compile shader %s: %w", id, err)
+		}
+		compiled = append(compiled, shader)
 	}
 
-	m.shaders[id] = shader
+	for i, shader := range compiled {
+		m.shaders[passKey(id, i)] = shader
+	}
 	return nil
 }
 
-// PreloadShaders loads all shaders in the given list
-func (m *Manager) PreloadShaders(ids []string) {
-	for _, id := range ids {
-		if IsPreprocess(id) {
-			continue
-		}
-		if err := m.LoadShader(id); err != nil {
-			log.Printf("Warning: failed to load shader %s: %v", id, err)
-		}
+// passKey returns the shader cache key for one pass of a shader. The
+// first pass uses the shader ID.
+func passKey(id string, pass int) string {
+	if pass == 0 {
+		return id
 	}
+	return fmt.Sprintf("%s#%d", id, pass)
 }
 
-// ensureGhostingBuffer creates or resizes the ghosting buffer to match dimensions
-func (m *Manager) ensureGhostingBuffer(width, height int) {
-	if m.ghostingBuffer != nil {
-		bw, bh := m.ghostingBuffer.Bounds().Dx(), m.ghostingBuffer.Bounds().Dy()
-		if bw != width || bh != height {
-			m.ghostingBuffer.Deallocate()
-			m.ghostingBuffer = nil
+// chainEntry returns a shader's passes with their compiled shaders, or
+// false if the shader isn't loaded
+func (m *Manager) chainEntry(id string) (chainShader, bool) {
+	passes := GetShaderPasses(id)
+	cs := chainShader{id: id, passes: passes, compiled: make([]*ebiten.Shader, len(passes))}
+	for i := range passes {
+		s, ok := m.shaders[passKey(id, i)]
+		if !ok {
+			return chainShader{}, false
 		}
+		cs.compiled[i] = s
 	}
-	if m.ghostingBuffer == nil {
-		m.ghostingBuffer = ebiten.NewImage(width, height)
-	}
+	return cs, true
 }
 
-// ensureBuffers creates or resizes the ping-pong buffers to match dimensions
-func (m *Manager) ensureBuffers(width, height int) {
-	// Check if bufferA needs (re)creation
-	if m.bufferA != nil {
-		bw, bh := m.bufferA.Bounds().Dx(), m.bufferA.Bounds().Dy()
-		if bw != width || bh != height {
-			m.bufferA.Deallocate()
-			m.bufferA = nil
+// PreloadShaders loads all shaders in the given list
+func (m *Manager) PreloadShaders(ids []string) {
+	for _, id := range ids {
+		if IsPreprocess(id) {
+			continue
 		}
-	}
-	if m.bufferA == nil {
-		m.bufferA = ebiten.NewImage(width, height)
-	}
-
-	// Check if bufferB needs (re)creation
-	if m.bufferB != nil {
-		bw, bh := m.bufferB.Bounds().Dx(), m.bufferB.Bounds().Dy()
-		if bw != width || bh != height {
-			m.bufferB.Deallocate()
-			m.bufferB = nil
+		if err := m.LoadShader(id); err != nil {
+			log.Printf("Warning: failed to load shader %s: %v", id, err)
 		}
 	}
-	if m.bufferB == nil {
-		m.bufferB = ebiten.NewImage(width, height)
-	}
 }
 
 // shaderListMatches checks if the given shader IDs match the cached list
@@ -245,7 +237,7 @@ func (m *Manager) rebuildShaderCache(shaderIDs []string) {
 		if IsPreprocess(id) {
 			continue
 		}
-		if _, ok := m.shaders[id]; !ok {
+		if _, ok := m.shaders[passKey(id, 0)]; !ok {
 			if err := m.LoadShader(id); err != nil {
 				log.Printf("Warning: shader %s not available: %v", id, err)
 			}
@@ -272,47 +264,53 @@ func (m *Manager) rebuildShaderCache(shaderIDs []string) {
 	})
 
 	// Keep only shaders that compiled successfully
-	m.cachedSortedShaders = make([]*ebiten.Shader, 0, len(filtered))
+	m.cachedChain = make([]chainShader, 0, len(filtered))
 	for _, id := range filtered {
-		if s, ok := m.shaders[id]; ok {
-			m.cachedSortedShaders = append(m.cachedSortedShaders, s)
+		if cs, ok := m.chainEntry(id); ok {
+			m.cachedChain = append(m.cachedChain, cs)
 		}
 	}
 }
 
-// applyGhosting applies the ghosting pre-processing step.
-// It updates the ghosting buffer and returns a ghosted image.
-func (m *Manager) applyGhosting(src *ebiten.Image) *ebiten.Image {
-	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
-	m.ensureGhostingBuffer(srcW, srcH)
-	m.ensureBuffers(srcW, srcH)
-
-	// Update ghosting buffer: buffer = buffer * 0.6 + src * 0.4
-	// Step 1: Copy ghostingBuffer at 60% to bufferA
-	m.bufferA.Clear()
-	decayOp := &ebiten.DrawImageOptions{}
-	decayOp.ColorScale.Scale(0.6, 0.6, 0.6, 1.0)
-	m.bufferA.DrawImage(m.ghostingBuffer, decayOp)
-
-	// Step 2: Add current at 40% to bufferA (additive blend)
-	addOp := &ebiten.DrawImageOptions{}
-	addOp.ColorScale.Scale(0.4, 0.4, 0.4, 1.0)
-	addOp.Blend = ebiten.Blend{
-		BlendFactorSourceRGB:        ebiten.BlendFactorOne,
-		BlendFactorSourceAlpha:      ebiten.BlendFactorOne,
-		BlendFactorDestinationRGB:   ebiten.BlendFactorOne,
-		BlendFactorDestinationAlpha: ebiten.BlendFactorOne,
-		BlendOperationRGB:           ebiten.BlendOperationAdd,
-		BlendOperationAlpha:         ebiten.BlendOperationAdd,
+// shaderUniforms returns the uniforms for one shader pass: the shared
+// frame uniforms plus the shader's tunable parameters. Parameters missing
+// from params use their declared defaults.
+func shaderUniforms(id string, base map[string]interface{}, params map[string]map[string]float64) map[string]interface{} {
+	declared := GetShaderParams(id)
+	if len(declared) == 0 {
+		return base
 	}
-	m.bufferA.DrawImage(src, addOp)
 
-	// Step 3: Copy bufferA to ghostingBuffer for next frame
-	m.ghostingBuffer.Clear()
-	m.ghostingBuffer.DrawImage(m.bufferA, nil)
+	uniforms := make(map[string]interface{}, len(base)+len(declared))
+	for k, v := range base {
+		uniforms[k] = v
+	}
+	for _, p := range declared {
+		uniforms[p.Uniform] = float32(ParamValue(id, p, params))
+	}
+	return uniforms
+}
 
-	// Return the blended result (bufferA already contains it)
-	return m.bufferA
+// ParamValue returns the value of a shader parameter from params, clamped
+// to the declared range, or the parameter's default if unset.
+func ParamValue(id string, p ShaderParam, params map[string]map[string]float64) float64 {
+	v, ok := params[id][p.Uniform]
+	if !ok {
+		return p.Default
+	}
+	return min(max(v, p.Min), p.Max)
+}
+
+// applyGhosting runs the ghosting shader, which blends each frame with
+// its own previous output through a feedback pass. Returns src unchanged
+// if the shader can't be loaded.
+func (m *Manager) applyGhosting(src *ebiten.Image) *ebiten.Image {
+	if err := m.LoadShader("ghosting"); err != nil {
+		log.Printf("Warning: shader ghosting not available: %v", err)
+		return src
+	}
+	cs, _ := m.chainEntry("ghosting")
+	return m.preprocess.run(nil, src, []chainShader{cs}, nil, nil)
 }
 
 // hasGhosting returns true if "ghosting" is in the shader list
@@ -361,12 +359,16 @@ func (m *Manager) ApplyPreprocessEffects(src *ebiten.Image, shaderIDs []string,
 
 // ApplyShaders draws src to dst with the specified shader chain applied.
 // If shaderIDs is empty, src is drawn directly to dst.
+// Shaders run in weight order, each through all of its passes; the
+// intermediate buffers are planned once per chain and reused every frame.
+// params holds parameter values keyed by shader ID then uniform name;
+// it may be nil to use every parameter's default.
 // sourceHeight is the native vertical resolution of the emulated system
 // (used by scanline shader to align with original pixel rows).
 // Note: Preprocessing effects (xBR, ghosting) should be applied via
 // ApplyPreprocessEffects before calling this function.
 // Returns true if shaders were applied, false if direct draw was used.
-func (m *Manager) ApplyShaders(dst, src *ebiten.Image, shaderIDs []string, sourceHeight int) bool {
+func (m *Manager) ApplyShaders(dst, src *ebiten.Image, shaderIDs []string, params map[string]map[string]float64, sourceHeight int) bool {
 	if src == nil {
 		return false
 	}
@@ -380,54 +382,17 @@ func (m *Manager) ApplyShaders(dst, src *ebiten.Image, shaderIDs []string, sourc
 		m.rebuildShaderCache(shaderIDs)
 	}
 
-	validShaders := m.cachedSortedShaders
-	if len(validShaders) == 0 {
+	if len(m.cachedChain) == 0 {
 		dst.DrawImage(src, nil)
 		return false
 	}
 
-	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
-
 	// Uniforms for shaders
 	uniforms := map[string]interface{}{
 		"Time":         float32(m.frame),
 		"SourceHeight": float32(sourceHeight),
 	}
 
-	// Single shader case - draw directly to destination
-	if len(validShaders) == 1 {
-		op := &ebiten.DrawRectShaderOptions{}
-		op.Images[0] = src
-		op.Uniforms = uniforms
-		dst.DrawRectShader(srcW, srcH, validShaders[0], op)
-		return true
-	}
-
-	// Multiple shaders - chain through ping-pong buffers
-	m.ensureBuffers(srcW, srcH)
-
-	// Track current input for each pass
-	currentInput := src
-	buffers := [2]*ebiten.Image{m.bufferA, m.bufferB}
-	bufferIndex := 1
-
-	for i, shader := range validShaders {
-		op := &ebiten.DrawRectShaderOptions{}
-		op.Images[0] = currentInput
-		op.Uniforms = uniforms
-
-		if i == len(validShaders)-1 {
-			// Last shader writes to destination
-			dst.DrawRectShader(srcW, srcH, shader, op)
-		} else {
-			// Intermediate shaders write to ping-pong buffer
-			outputBuffer := buffers[bufferIndex%2]
-			outputBuffer.Clear()
-			outputBuffer.DrawRectShader(srcW, srcH, shader, op)
-			currentInput = outputBuffer
-			bufferIndex++
-		}
-	}
-
+	m.chain.run(dst, src, m.cachedChain, uniforms, params)
 	return true
 }
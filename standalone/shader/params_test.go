@@ -0,0 +1,71 @@
+package shader
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestBuiltinShaderParams(t *testing.T) {
+	for _, info := range AvailableShaders {
+		src, hasSrc := shaderSources[info.ID]
+		for _, p := range info.Params {
+			if err := validateParam(p); err != nil {
+				t.Errorf("%s: %v", info.ID, err)
+			}
+			if p.Step <= 0 {
+				t.Errorf("%s.%s: built-in params need a positive step", info.ID, p.Uniform)
+			}
+			if !hasSrc {
+				t.Errorf("%s declares params but has no Kage source", info.ID)
+				continue
+			}
+			decl := regexp.MustCompile(`(?m)^var ` + p.Uniform + ` float$`)
+			if !decl.Match(src) {
+				t.Errorf("%s: uniform %q not declared in shader source", info.ID, p.Uniform)
+			}
+		}
+	}
+}
+
+func TestGetShaderParams(t *testing.T) {
+	params := GetShaderParams("crt")
+	if len(params) == 0 {
+		t.Fatal("crt should expose params")
+	}
+	if params[0].Uniform != "Curvature" {
+		t.Errorf("first crt param: got %q, want Curvature", params[0].Uniform)
+	}
+	if GetShaderParams("monochrome") != nil {
+		t.Error("monochrome should have no params")
+	}
+	if GetShaderParams("unknown") != nil {
+		t.Error("unknown shader should have no params")
+	}
+}
+
+func TestShaderUniforms(t *testing.T) {
+	base := map[string]interface{}{"Time": float32(1)}
+
+	// Shaders without params share the base map
+	if u := shaderUniforms("monochrome", base, nil); len(u) != 1 {
+		t.Errorf("monochrome uniforms: got %v", u)
+	}
+
+	params := map[string]map[string]float64{"scanlines": {"Intensity": 0.5}}
+	u := shaderUniforms("scanlines", base, params)
+	if u["Intensity"] != float32(0.5) {
+		t.Errorf("Intensity: got %v, want 0.5", u["Intensity"])
+	}
+	if u["Time"] != float32(1) {
+		t.Errorf("Time: got %v, want 1", u["Time"])
+	}
+	if _, ok := base["Intensity"]; ok {
+		t.Error("base uniforms must not be modified")
+	}
+
+	// Defaults fill in unset params
+	u = shaderUniforms("crt", base, params)
+	if u["Curvature"] != float32(0.1) {
+		t.Errorf("Curvature default: got %v, want 0.1", u["Curvature"])
+	}
+}
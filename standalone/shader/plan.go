@@ -0,0 +1,218 @@
+package shader
+
+import (
+	"image"
+	"math"
+)
+
+// Special slot values used by planStep in place of a buffer index
+const (
+	slotSource = -1 // The chain's input image
+	slotDest   = -2 // The destination image
+)
+
+// stepKind identifies what a planStep does
+type stepKind int
+
+const (
+	stepPass     stepKind = iota // Run a shader pass
+	stepResample                 // Draw Input scaled to the output size
+)
+
+// planStep is a single draw in a pass plan
+type planStep struct {
+	Kind   stepKind
+	Shader int        // Index into the chain (stepPass)
+	Pass   int        // Pass index within the shader (stepPass)
+	Filter PassFilter // Resampling filter (stepResample)
+	Size   image.Point
+	Input  int   // Slot drawn as image 0, or slotSource
+	Inputs []int // Slots bound for the pass's Inputs, in order
+	Output int   // Slot written, or slotDest
+}
+
+// passPlan is the ordered list of draws for a shader chain along with the
+// intermediate buffers they need. Buffers are shared between steps whose
+// lifetimes don't overlap, so a chain at one size needs only two.
+type passPlan struct {
+	Steps []planStep
+	Slots []image.Point // Size of each intermediate buffer
+
+	// Feedback marks slots written by a Feedback pass. They are never
+	// shared and keep a second buffer holding the previous frame.
+	Feedback map[int]bool
+
+	// History is the number of previous input frames any pass reads
+	History int
+}
+
+// planChain builds the pass plan for shaders, applied in order to an
+// input of the given size. Each shader's output is resampled back to the
+// input size so shaders can be freely combined. When toDest is true the
+// final draw targets slotDest, otherwise the result is left in the last
+// step's Output slot.
+//
+// Planning happens in two stages: steps are first laid out writing to
+// virtual buffers, one per output, then the virtual buffers are mapped
+// onto as few real slots as their lifetimes allow.
+func planChain(shaders []chainShader, size image.Point, toDest bool) passPlan {
+	var v virtualPlan
+	current := slotSource
+
+	for si, cs := range shaders {
+		last := si == len(shaders)-1 && toDest
+		shaderInput := current
+		passOut := make([]int, len(cs.passes))
+		passSize := size
+
+		for pi, pass := range cs.passes {
+			// Bring the previous output to this pass's size first
+			if next := scaleSize(passSize, pass.Scale); next != passSize {
+				passSize = next
+				out := v.newBuffer(passSize, false)
+				v.add(planStep{Kind: stepResample, Filter: pass.Filter, Size: passSize, Input: current, Output: out})
+				current = out
+			}
+
+			step := planStep{Kind: stepPass, Shader: si, Pass: pi, Size: passSize, Input: current}
+			for _, in := range pass.Inputs {
+				if in == PassOriginal {
+					step.Inputs = append(step.Inputs, shaderInput)
+				} else {
+					step.Inputs = append(step.Inputs, passOut[in])
+				}
+			}
+			if last && pi == len(cs.passes)-1 && passSize == size && !pass.Feedback {
+				step.Output = slotDest
+			} else {
+				step.Output = v.newBuffer(passSize, pass.Feedback)
+			}
+			v.add(step)
+
+			current = step.Output
+			passOut[pi] = step.Output
+			v.plan.History = max(v.plan.History, pass.History)
+		}
+
+		// Restore the chain size for the next shader, and copy a final
+		// feedback output that couldn't be drawn to the destination
+		if passSize != size || (last && current != slotDest) {
+			out := slotDest
+			if !last {
+				out = v.newBuffer(size, false)
+			}
+			filter := cs.passes[len(cs.passes)-1].Filter
+			v.add(planStep{Kind: stepResample, Filter: filter, Size: size, Input: current, Output: out})
+			current = out
+		}
+	}
+
+	return v.assignSlots()
+}
+
+// virtualPlan is a plan whose steps write to virtual buffers, each
+// written exactly once
+type virtualPlan struct {
+	plan     passPlan
+	sizes    []image.Point
+	feedback []bool
+}
+
+// newBuffer creates a virtual buffer and returns its index
+func (v *virtualPlan) newBuffer(size image.Point, feedback bool) int {
+	v.sizes = append(v.sizes, size)
+	v.feedback = append(v.feedback, feedback)
+	return len(v.sizes) - 1
+}
+
+// add appends a step to the plan
+func (v *virtualPlan) add(step planStep) {
+	v.plan.Steps = append(v.plan.Steps, step)
+}
+
+// assignSlots maps virtual buffers onto real slots. A buffer's slot is
+// released after the last step that reads it and reused by the next
+// output of the same size. Outputs are assigned before the step's inputs
+// are released so a pass never reads and writes the same slot.
+func (v *virtualPlan) assignSlots() passPlan {
+	lastRead := make([]int, len(v.sizes))
+	for i := range lastRead {
+		lastRead[i] = -1
+	}
+	for i, step := range v.plan.Steps {
+		for _, in := range append([]int{step.Input}, step.Inputs...) {
+			if in >= 0 {
+				lastRead[in] = i
+			}
+		}
+	}
+
+	plan := v.plan
+	slotOf := make([]int, len(v.sizes))
+	var free []int
+	mapSlot := func(buf int) int {
+		if buf < 0 {
+			return buf
+		}
+		return slotOf[buf]
+	}
+
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		step.Input = mapSlot(step.Input)
+		if len(step.Inputs) > 0 {
+			inputs := make([]int, len(step.Inputs))
+			for j, in := range step.Inputs {
+				inputs[j] = mapSlot(in)
+			}
+			step.Inputs = inputs
+		}
+
+		if out := step.Output; out >= 0 {
+			slotOf[out] = plan.alloc(&free, v.sizes[out], v.feedback[out])
+			step.Output = slotOf[out]
+		}
+
+		// Release buffers whose last read was this step
+		for buf, last := range lastRead {
+			if last == i && !v.feedback[buf] {
+				free = append(free, slotOf[buf])
+			}
+		}
+	}
+
+	return plan
+}
+
+// alloc returns a slot of the given size, reusing one from free if
+// possible. Feedback slots are always new.
+func (p *passPlan) alloc(free *[]int, size image.Point, feedback bool) int {
+	if !feedback {
+		for i, slot := range *free {
+			if p.Slots[slot] == size {
+				*free = append((*free)[:i], (*free)[i+1:]...)
+				return slot
+			}
+		}
+	}
+	p.Slots = append(p.Slots, size)
+	slot := len(p.Slots) - 1
+	if feedback {
+		if p.Feedback == nil {
+			p.Feedback = make(map[int]bool)
+		}
+		p.Feedback[slot] = true
+	}
+	return slot
+}
+
+// scaleSize applies a pass scale factor, keeping at least one pixel
+func scaleSize(size image.Point, scale float64) image.Point {
+	if scale <= 0 || scale == 1 {
+		return size
+	}
+	return image.Pt(
+		max(1, int(math.Round(float64(size.X)*scale))),
+		max(1, int(math.Round(float64(size.Y)*scale))),
+	)
+}
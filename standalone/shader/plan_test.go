@@ -0,0 +1,268 @@
+package shader
+
+import (
+	"image"
+	"testing"
+)
+
+// singlePass returns chain entries for single-pass shaders
+func singlePass(ids ...string) []chainShader {
+	shaders := make([]chainShader, len(ids))
+	for i, id := range ids {
+		shaders[i] = chainShader{id: id, passes: []PassInfo{{}}}
+	}
+	return shaders
+}
+
+// checkNoAliasing fails if any step writes the slot it reads from
+func checkNoAliasing(t *testing.T, plan passPlan) {
+	t.Helper()
+	for i, step := range plan.Steps {
+		for _, in := range append([]int{step.Input}, step.Inputs...) {
+			if in >= 0 && in == step.Output {
+				t.Errorf("step %d reads and writes slot %d", i, in)
+			}
+		}
+	}
+}
+
+func TestPlanChainSingleShader(t *testing.T) {
+	plan := planChain(singlePass("crt"), image.Pt(320, 240), true)
+
+	if len(plan.Steps) != 1 {
+		t.Fatalf("steps: got %d, want 1", len(plan.Steps))
+	}
+	step := plan.Steps[0]
+	if step.Kind != stepPass || step.Input != slotSource || step.Output != slotDest {
+		t.Errorf("step: got %+v, want source -> dest pass", step)
+	}
+	if len(plan.Slots) != 0 {
+		t.Errorf("slots: got %d, want 0", len(plan.Slots))
+	}
+}
+
+func TestPlanChainPingPong(t *testing.T) {
+	size := image.Pt(320, 240)
+	plan := planChain(singlePass("a", "b", "c", "d", "e"), size, true)
+
+	if len(plan.Steps) != 5 {
+		t.Fatalf("steps: got %d, want 5", len(plan.Steps))
+	}
+	// A chain at one size alternates between two buffers
+	if len(plan.Slots) != 2 {
+		t.Fatalf("slots: got %d, want 2", len(plan.Slots))
+	}
+	for _, s := range plan.Slots {
+		if s != size {
+			t.Errorf("slot size: got %v, want %v", s, size)
+		}
+	}
+
+	wantIn := []int{slotSource, 0, 1, 0, 1}
+	wantOut := []int{0, 1, 0, 1, slotDest}
+	for i, step := range plan.Steps {
+		if step.Shader != i || step.Pass != 0 {
+			t.Errorf("step %d: got shader %d pass %d, want shader %d pass 0", i, step.Shader, step.Pass, i)
+		}
+		if step.Input != wantIn[i] || step.Output != wantOut[i] {
+			t.Errorf("step %d: got %d -> %d, want %d -> %d", i, step.Input, step.Output, wantIn[i], wantOut[i])
+		}
+	}
+	checkNoAliasing(t, plan)
+}
+
+func TestPlanChainPassOrder(t *testing.T) {
+	shaders := []chainShader{
+		{id: "multi", passes: []PassInfo{{}, {}, {}}},
+		{id: "single", passes: []PassInfo{{}}},
+	}
+	plan := planChain(shaders, image.Pt(100, 100), true)
+
+	want := []struct{ shader, pass int }{{0, 0}, {0, 1}, {0, 2}, {1, 0}}
+	if len(plan.Steps) != len(want) {
+		t.Fatalf("steps: got %d, want %d", len(plan.Steps), len(want))
+	}
+	for i, w := range want {
+		step := plan.Steps[i]
+		if step.Kind != stepPass || step.Shader != w.shader || step.Pass != w.pass {
+			t.Errorf("step %d: got shader %d pass %d, want shader %d pass %d", i, step.Shader, step.Pass, w.shader, w.pass)
+		}
+	}
+	// Each step reads the previous one's output
+	for i := 1; i < len(plan.Steps); i++ {
+		if plan.Steps[i].Input != plan.Steps[i-1].Output {
+			t.Errorf("step %d input %d, want previous output %d", i, plan.Steps[i].Input, plan.Steps[i-1].Output)
+		}
+	}
+	checkNoAliasing(t, plan)
+}
+
+func TestPlanChainScaledPasses(t *testing.T) {
+	full := image.Pt(320, 240)
+	half := image.Pt(160, 120)
+	shaders := []chainShader{{id: "glow", passes: []PassInfo{
+		{Scale: 0.5, Filter: FilterLinear},
+		{Scale: 2, Inputs: []int{PassOriginal, 0}},
+	}}}
+	plan := planChain(shaders, full, true)
+
+	// resample down, pass 0, resample up, pass 1
+	if len(plan.Steps) != 4 {
+		t.Fatalf("steps: got %d, want 4", len(plan.Steps))
+	}
+	down, pass0, up, pass1 := plan.Steps[0], plan.Steps[1], plan.Steps[2], plan.Steps[3]
+
+	if down.Kind != stepResample || down.Filter != FilterLinear || down.Size != half || down.Input != slotSource {
+		t.Errorf("downsample: got %+v", down)
+	}
+	if pass0.Kind != stepPass || pass0.Size != half || pass0.Input != down.Output {
+		t.Errorf("pass 0: got %+v", pass0)
+	}
+	if up.Kind != stepResample || up.Size != full || up.Input != pass0.Output {
+		t.Errorf("upsample: got %+v", up)
+	}
+	if pass1.Kind != stepPass || pass1.Input != up.Output || pass1.Output != slotDest {
+		t.Errorf("pass 1: got %+v", pass1)
+	}
+
+	// Pass 0's output must survive the upsample to be bound by pass 1
+	if len(pass1.Inputs) != 2 || pass1.Inputs[0] != slotSource || pass1.Inputs[1] != pass0.Output {
+		t.Errorf("pass 1 inputs: got %v, want [source %d]", pass1.Inputs, pass0.Output)
+	}
+	if up.Output == pass0.Output {
+		t.Error("upsample overwrote pass 0 output still needed by pass 1")
+	}
+
+	// The downsample buffer is free once pass 0 has read it, but nothing
+	// later needs a half-size buffer, so three slots are used
+	if len(plan.Slots) != 3 {
+		t.Errorf("slots: got %v, want 3", plan.Slots)
+	}
+	checkNoAliasing(t, plan)
+}
+
+func TestPlanChainReusesAcrossShaders(t *testing.T) {
+	full := image.Pt(320, 240)
+	shaders := []chainShader{
+		{id: "blur", passes: []PassInfo{{Scale: 0.5}, {}}},
+		{id: "blur2", passes: []PassInfo{{Scale: 0.5}, {}}},
+	}
+	plan := planChain(shaders, full, true)
+
+	// Both shaders run at half size and return to full size; the second
+	// reuses the first's buffers instead of allocating more
+	halfSlots, fullSlots := 0, 0
+	for _, s := range plan.Slots {
+		switch s {
+		case full:
+			fullSlots++
+		case image.Pt(160, 120):
+			halfSlots++
+		default:
+			t.Errorf("unexpected slot size %v", s)
+		}
+	}
+	if halfSlots != 2 || fullSlots != 1 {
+		t.Errorf("slots: got %d half and %d full, want 2 and 1", halfSlots, fullSlots)
+	}
+
+	last := plan.Steps[len(plan.Steps)-1]
+	if last.Kind != stepResample || last.Output != slotDest {
+		t.Errorf("last step: got %+v, want resample to dest", last)
+	}
+	checkNoAliasing(t, plan)
+}
+
+func TestPlanChainFeedback(t *testing.T) {
+	size := image.Pt(64, 64)
+	shaders := []chainShader{
+		{id: "ghost", passes: []PassInfo{{Feedback: true}}},
+		{id: "a", passes: []PassInfo{{}}},
+		{id: "b", passes: []PassInfo{{}}},
+	}
+	plan := planChain(shaders, size, true)
+
+	ghost := plan.Steps[0].Output
+	if ghost < 0 || !plan.Feedback[ghost] {
+		t.Fatalf("feedback pass output %d not a feedback slot", ghost)
+	}
+	for i, step := range plan.Steps[1:] {
+		if step.Output == ghost {
+			t.Errorf("step %d reuses the feedback slot", i+1)
+		}
+	}
+	checkNoAliasing(t, plan)
+}
+
+func TestPlanChainFeedbackWithoutDest(t *testing.T) {
+	shaders := []chainShader{{id: "ghosting", passes: []PassInfo{{Feedback: true}}}}
+	plan := planChain(shaders, image.Pt(64, 64), false)
+
+	// The result stays in the feedback slot for the caller to use
+	if len(plan.Steps) != 1 {
+		t.Fatalf("steps: got %d, want 1", len(plan.Steps))
+	}
+	if out := plan.Steps[0].Output; out != 0 || !plan.Feedback[out] {
+		t.Errorf("output: got %d, want feedback slot 0", out)
+	}
+}
+
+func TestPlanChainFeedbackToDest(t *testing.T) {
+	shaders := []chainShader{{id: "ghosting", passes: []PassInfo{{Feedback: true}}}}
+	plan := planChain(shaders, image.Pt(64, 64), true)
+
+	// The feedback output must be kept, so it's copied to the destination
+	if len(plan.Steps) != 2 {
+		t.Fatalf("steps: got %d, want 2", len(plan.Steps))
+	}
+	if step := plan.Steps[1]; step.Kind != stepResample || step.Input != plan.Steps[0].Output || step.Output != slotDest {
+		t.Errorf("copy step: got %+v", step)
+	}
+}
+
+func TestPlanChainHistory(t *testing.T) {
+	shaders := []chainShader{
+		{id: "a", passes: []PassInfo{{History: 1}}},
+		{id: "b", passes: []PassInfo{{}, {History: 3}}},
+	}
+	plan := planChain(shaders, image.Pt(64, 64), true)
+	if plan.History != 3 {
+		t.Errorf("History: got %d, want 3", plan.History)
+	}
+}
+
+func TestScaleSize(t *testing.T) {
+	tests := []struct {
+		scale float64
+		want  image.Point
+	}{
+		{0, image.Pt(320, 240)},
+		{1, image.Pt(320, 240)},
+		{0.5, image.Pt(160, 120)},
+		{2, image.Pt(640, 480)},
+		{0.001, image.Pt(1, 1)},
+	}
+	for _, tc := range tests {
+		if got := scaleSize(image.Pt(320, 240), tc.scale); got != tc.want {
+			t.Errorf("scaleSize(%v): got %v, want %v", tc.scale, got, tc.want)
+		}
+	}
+}
+
+func TestGetShaderPasses(t *testing.T) {
+	if passes := GetShaderPasses("crt"); len(passes) != 1 || passes[0].Source != nil {
+		t.Errorf("crt: got %+v, want one pass of its own source", passes)
+	}
+	if passes := GetShaderPasses("ghosting"); len(passes) != 1 || !passes[0].Feedback {
+		t.Errorf("ghosting: got %+v, want one feedback pass", passes)
+	}
+}
+
+func TestPassKey(t *testing.T) {
+	if got := passKey("crt", 0); got != "crt" {
+		t.Errorf("pass 0: got %q, want crt", got)
+	}
+	if got := passKey("user:royale", 2); got != "user:royale#2" {
+		t.Errorf("pass 2: got %q, want user:royale#2", got)
+	}
+}
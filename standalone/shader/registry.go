@@ -17,6 +17,67 @@ type ShaderInfo struct {
 	Weight      int           // Higher weight = applied earlier in chain
 	Preprocess  bool          // True for preprocessing effects (xBR, ghosting)
 	Context     EffectContext // Where this effect can be applied
+	Params      []ShaderParam // Tunable uniforms exposed by the shader
+	Passes      []PassInfo    // Render passes; nil for a single pass of the shader's source
+	User        bool          // True for shaders loaded from the user shaders directory
+}
+
+// ShaderParam describes a tunable float uniform of a shader
+type ShaderParam struct {
+	Uniform string  `json:"uniform"` // Kage uniform variable name (exported)
+	Label   string  `json:"label"`   // Display name for UI
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Default float64 `json:"default"`
+	Step    float64 `json:"step"`
+}
+
+// PassFilter selects how a pass resamples the previous pass's output
+// when the two sizes differ
+type PassFilter int
+
+const (
+	FilterNearest PassFilter = iota
+	FilterLinear
+)
+
+// PassOriginal refers to the shader's input in PassInfo.Inputs
+const PassOriginal = -1
+
+// MaxPassExtras is the number of images a pass can bind besides its
+// input: Inputs, History frames, and Feedback share the remaining
+// shader image slots.
+const MaxPassExtras = 3
+
+// MaxPassHistory is the most previous frames a pass can request
+const MaxPassHistory = 3
+
+// PassInfo describes one render pass of a multi-pass shader.
+//
+// A pass draws into a buffer sized from the previous pass's output by
+// Scale. Its image 0 is the previous pass's output (the shader's input
+// for the first pass), resampled with Filter if sizes differ. Extra images
+// are bound in order: Inputs, then History frames (most recent first),
+// then the pass's own output from the previous frame if Feedback is set.
+// Extra images keep their own sizes, so passes that bind them must use
+// pixel units (//kage:unit pixels) and map positions with imageSrcNOrigin
+// and imageSrcNSize.
+type PassInfo struct {
+	Source   []byte     // Kage source; nil uses the shader's own source
+	Scale    float64    // Output size relative to the previous pass; 0 means 1
+	Filter   PassFilter // Resampling of the previous pass's output
+	Inputs   []int      // Earlier pass outputs to bind, by index or PassOriginal
+	History  int        // Previous frames of the chain input to bind
+	Feedback bool       // Bind this pass's own output from the previous frame
+}
+
+// extras returns the number of extra images the pass binds
+func (p PassInfo) extras() int {
+	n := len(p.Inputs) + p.History
+	if p.Feedback {
+		n++
+	}
+	return n
 }
 
 // AvailableShaders lists all shaders that can be enabled
@@ -34,6 +95,7 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Ghost trails from slow CRT phosphor decay",
 		Preprocess:  true,
 		Context:     ContextGame,
+		Passes:      []PassInfo{{Feedback: true}},
 	},
 	{
 		ID:          "crt",
@@ -41,6 +103,11 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Curved screen with RGB separation and vignette",
 		Weight:      25,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Curvature", Label: "Curvature", Min: 0, Max: 0.3, Default: 0.1, Step: 0.01},
+			{Uniform: "Separation", Label: "RGB Separation", Min: 0, Max: 2, Default: 0.5, Step: 0.1},
+			{Uniform: "Vignette", Label: "Vignette", Min: 0, Max: 1, Default: 0.3, Step: 0.05},
+		},
 	},
 	{
 		ID:          "scanlines",
@@ -48,6 +115,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Horizontal scanline effect",
 		Weight:      400,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Intensity", Label: "Intensity", Min: 0, Max: 1, Default: 0.25, Step: 0.05},
+		},
 	},
 	{
 		ID:          "bloom",
@@ -55,6 +125,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Bright pixels glow into neighbors like CRT phosphors",
 		Weight:      550,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Strength", Label: "Strength", Min: 0, Max: 1.5, Default: 0.4, Step: 0.05},
+			{Uniform: "Threshold", Label: "Threshold", Min: 0, Max: 0.9, Default: 0.5, Step: 0.05},
+		},
 	},
 	{
 		ID:          "lcd",
@@ -62,6 +136,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Visible pixel grid with RGB subpixels like handhelds",
 		Weight:      300,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "GridDarkness", Label: "Grid Darkness", Min: 0, Max: 1, Default: 0.3, Step: 0.05},
+			{Uniform: "SubpixelIntensity", Label: "Subpixel Tint", Min: 0, Max: 0.5, Default: 0.15, Step: 0.05},
+		},
 	},
 	{
 		ID:          "colorbleed",
@@ -69,6 +147,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Horizontal color bleeding from composite video",
 		Weight:      800,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Intensity", Label: "Intensity", Min: 0, Max: 1, Default: 0.6, Step: 0.05},
+			{Uniform: "Distance", Label: "Bleed Distance", Min: 0.5, Max: 4, Default: 1.5, Step: 0.25},
+		},
 	},
 	{
 		ID:          "dotmatrix",
@@ -76,6 +158,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Circular pixels like CRT phosphor dots",
 		Weight:      350,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "GapBrightness", Label: "Gap Brightness", Min: 0, Max: 1, Default: 0.1, Step: 0.05},
+		},
 	},
 	{
 		ID:          "ntsc",
@@ -83,6 +168,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Color fringing at edges from NTSC encoding",
 		Weight:      850,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Intensity", Label: "Artifact Amount", Min: 0, Max: 1, Default: 0.3, Step: 0.05},
+			{Uniform: "Softness", Label: "Softness", Min: 0, Max: 1, Default: 0.15, Step: 0.05},
+		},
 	},
 	{
 		ID:          "rainbow",
@@ -90,6 +179,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Rainbow artifacts from dithering on composite video",
 		Weight:      845,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Intensity", Label: "Intensity", Min: 0, Max: 1, Default: 0.35, Step: 0.05},
+		},
 	},
 	{
 		ID:          "gamma",
@@ -97,6 +189,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Non-linear brightness curve of CRT displays",
 		Weight:      900,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Gamma", Label: "Gamma", Min: 1, Max: 3, Default: 2.2, Step: 0.05},
+			{Uniform: "Contrast", Label: "Contrast", Min: 0.5, Max: 1.5, Default: 1.1, Step: 0.05},
+		},
 	},
 	{
 		ID:          "halation",
@@ -104,6 +200,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Light bleeding behind CRT glass",
 		Weight:      500,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Strength", Label: "Strength", Min: 0, Max: 1, Default: 0.25, Step: 0.05},
+			{Uniform: "Radius", Label: "Radius", Min: 1, Max: 8, Default: 4, Step: 0.5},
+		},
 	},
 	{
 		ID:          "rfnoise",
@@ -111,6 +211,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Subtle static grain from RF connection",
 		Weight:      50,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Amount", Label: "Noise Amount", Min: 0, Max: 0.5, Default: 0.1, Step: 0.01},
+		},
 	},
 	{
 		ID:          "rollingband",
@@ -118,6 +221,10 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Scrolling dark band for bad reception look",
 		Weight:      80,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Darkness", Label: "Darkness", Min: 0, Max: 0.3, Default: 0.05, Step: 0.01},
+			{Uniform: "BandRadius", Label: "Band Size", Min: 5, Max: 80, Default: 20, Step: 5},
+		},
 	},
 	{
 		ID:          "vhs",
@@ -132,6 +239,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Alternating scanline fields for 480i look",
 		Weight:      380,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Intensity", Label: "Intensity", Min: 0, Max: 1, Default: 0.25, Step: 0.05},
+		},
 	},
 	{
 		ID:          "hsoft",
@@ -139,6 +249,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Bandwidth-limited horizontal blur like analog video",
 		Weight:      770,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Softness", Label: "Softness", Min: 0, Max: 1, Default: 0.5, Step: 0.05},
+		},
 	},
 	{
 		ID:          "vblur",
@@ -146,6 +259,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Electron beam softness causing scanline bleed",
 		Weight:      760,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Softness", Label: "Softness", Min: 0, Max: 1, Default: 0.5, Step: 0.05},
+		},
 	},
 	{
 		ID:          "monochrome",
@@ -160,6 +276,9 @@ var AvailableShaders = []ShaderInfo{
 		Description: "Warm brownish tint like old photographs",
 		Weight:      650,
 		Context:     ContextAll,
+		Params: []ShaderParam{
+			{Uniform: "Intensity", Label: "Intensity", Min: 0, Max: 1, Default: 1, Step: 0.05},
+		},
 	},
 }
 
@@ -172,17 +291,41 @@ var shaderPreprocess map[string]bool
 // shaderContexts provides O(1) context lookup by shader ID
 var shaderContexts map[string]EffectContext
 
+// shaderParams provides O(1) parameter lookup by shader ID
+var shaderParams map[string][]ShaderParam
+
+// shaderPasses provides O(1) pass lookup by shader ID
+var shaderPasses map[string][]PassInfo
+
 func init() {
 	shaderWeights = make(map[string]int)
 	shaderPreprocess = make(map[string]bool)
 	shaderContexts = make(map[string]EffectContext)
+	shaderParams = make(map[string][]ShaderParam)
+	shaderPasses = make(map[string][]PassInfo)
 	for _, s := range AvailableShaders {
-		shaderWeights[s.ID] = s.Weight
-		shaderPreprocess[s.ID] = s.Preprocess
-		shaderContexts[s.ID] = s.Context
+		indexShader(s)
 	}
 }
 
+// indexShader adds a shader's fields to the lookup maps
+func indexShader(s ShaderInfo) {
+	shaderWeights[s.ID] = s.Weight
+	shaderPreprocess[s.ID] = s.Preprocess
+	shaderContexts[s.ID] = s.Context
+	shaderParams[s.ID] = s.Params
+	shaderPasses[s.ID] = s.Passes
+}
+
+// unindexShader removes a shader from the lookup maps
+func unindexShader(id string) {
+	delete(shaderWeights, id)
+	delete(shaderPreprocess, id)
+	delete(shaderContexts, id)
+	delete(shaderParams, id)
+	delete(shaderPasses, id)
+}
+
 // GetShaderWeight returns the weight for a shader ID (0 if unknown)
 func GetShaderWeight(id string) int {
 	return shaderWeights[id]
@@ -197,3 +340,17 @@ func IsPreprocess(id string) bool {
 func GetShaderContext(id string) EffectContext {
 	return shaderContexts[id]
 }
+
+// GetShaderParams returns the tunable parameters for a shader ID (nil if none)
+func GetShaderParams(id string) []ShaderParam {
+	return shaderParams[id]
+}
+
+// GetShaderPasses returns the render passes for a shader ID. Shaders that
+// don't declare passes get a single pass of their own source.
+func GetShaderPasses(id string) []PassInfo {
+	if passes := shaderPasses[id]; len(passes) > 0 {
+		return passes
+	}
+	return []PassInfo{{}}
+}
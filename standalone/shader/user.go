@@ -0,0 +1,341 @@
+package shader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// UserShaderPrefix namespaces user shader IDs so they never collide
+// with the built-in shaders.
+const UserShaderPrefix = "user:"
+
+// userPassSuffix marks extra pass sources of multi-pass user shaders.
+// These files are only loaded through a sidecar's passes, never listed
+// as shaders of their own.
+const userPassSuffix = ".pass.kage"
+
+// maxUserPassScale limits how far a single pass can upscale
+const maxUserPassScale = 8
+
+// userShaderSidecar is the optional JSON file next to a user .kage file
+// (same base name, .json extension) describing how the shader is listed.
+type userShaderSidecar struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Weight      int           `json:"weight"`
+	Context     string        `json:"context"` // "all" (default), "game", or "ui"
+	Params      []ShaderParam `json:"params"`
+	Passes      []userPass    `json:"passes"`
+}
+
+// userPass is one entry of a sidecar's passes, mirroring PassInfo
+type userPass struct {
+	File     string  `json:"file"` // A .pass.kage file; empty uses the shader's own source
+	Scale    float64 `json:"scale"`
+	Filter   string  `json:"filter"` // "nearest" (default) or "linear"
+	Inputs   []int   `json:"inputs"`
+	History  int     `json:"history"`
+	Feedback bool    `json:"feedback"`
+}
+
+// userShader is a parsed user shader waiting to be compiled
+type userShader struct {
+	info      ShaderInfo
+	src       []byte
+	file      string
+	passFiles []string // Source file per pass; empty for the shader's own
+}
+
+// LoadError describes a user shader that could not be loaded
+type LoadError struct {
+	File string // Base name of the offending file
+	Err  error
+}
+
+func (e LoadError) Error() string {
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+// userShaderErrors holds the errors from the last LoadUserShaders call
+var userShaderErrors []LoadError
+
+// UserShaderErrors returns the errors from the last LoadUserShaders call
+// so they can be shown in the settings UI.
+func UserShaderErrors() []LoadError {
+	return userShaderErrors
+}
+
+// LoadUserShaders scans dir for .kage files, compiles them, and adds the
+// ones that compile to AvailableShaders. Previously loaded user shaders are
+// replaced. A missing directory is not an error. Returns the per-file
+// errors, which are also kept for UserShaderErrors.
+func LoadUserShaders(dir string) []LoadError {
+	removeUserShaders()
+
+	shaders, errs := scanUserShaders(dir)
+	for _, us := range shaders {
+		// Compile once to surface syntax errors now rather than
+		// failing silently in LoadShader during rendering
+		if err := compileUserShader(us); err != nil {
+			errs = append(errs, *err)
+			continue
+		}
+
+		AvailableShaders = append(AvailableShaders, us.info)
+		shaderSources[us.info.ID] = us.src
+		indexShader(us.info)
+	}
+
+	userShaderErrors = errs
+	return errs
+}
+
+// compileUserShader test-compiles a user shader's source and the sources
+// of its passes, returning the first failure
+func compileUserShader(us userShader) *LoadError {
+	compiled, err := ebiten.NewShader(us.src)
+	if err != nil {
+		return &LoadError{File: us.file, Err: err}
+	}
+	compiled.Deallocate()
+
+	for i, pass := range us.info.Passes {
+		if pass.Source == nil {
+			continue
+		}
+		compiled, err := ebiten.NewShader(pass.Source)
+		if err != nil {
+			return &LoadError{File: us.passFiles[i], Err: err}
+		}
+		compiled.Deallocate()
+	}
+	return nil
+}
+
+// removeUserShaders drops all user shaders from the registry
+func removeUserShaders() {
+	builtin := AvailableShaders[:0]
+	for _, s := range AvailableShaders {
+		if s.User {
+			delete(shaderSources, s.ID)
+			unindexShader(s.ID)
+			continue
+		}
+		builtin = append(builtin, s)
+	}
+	AvailableShaders = builtin
+}
+
+// scanUserShaders reads and parses every .kage file in dir, in name order
+func scanUserShaders(dir string) ([]userShader, []LoadError) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, []LoadError{{File: filepath.Base(dir), Err: err}}
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lower := strings.ToLower(e.Name())
+		if !e.IsDir() && strings.HasSuffix(lower, ".kage") && !strings.HasSuffix(lower, userPassSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var shaders []userShader
+	var errs []LoadError
+	for _, name := range names {
+		base := strings.TrimSuffix(name, filepath.Ext(name))
+
+		src, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, LoadError{File: name, Err: err})
+			continue
+		}
+
+		sidecarName := base + ".json"
+		sidecar, err := os.ReadFile(filepath.Join(dir, sidecarName))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, LoadError{File: sidecarName, Err: err})
+			continue
+		}
+
+		info, passFiles, err := parseUserShaderInfo(base, sidecar)
+		if err != nil {
+			errs = append(errs, LoadError{File: sidecarName, Err: err})
+			continue
+		}
+
+		if loadErr := loadUserPasses(dir, &info, src, passFiles); loadErr != nil {
+			errs = append(errs, *loadErr)
+			continue
+		}
+		shaders = append(shaders, userShader{info: info, src: src, file: name, passFiles: passFiles})
+	}
+	return shaders, errs
+}
+
+// loadUserPasses reads the pass sources named in passFiles into info's
+// passes. Passes binding extra images must use pixel units since those
+// images can differ in size from the output.
+func loadUserPasses(dir string, info *ShaderInfo, src []byte, passFiles []string) *LoadError {
+	for i, file := range passFiles {
+		passSrc := src
+		if file != "" {
+			data, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				return &LoadError{File: file, Err: err}
+			}
+			info.Passes[i].Source = data
+			passSrc = data
+		} else {
+			file = info.ID[len(UserShaderPrefix):] + ".kage"
+		}
+
+		if info.Passes[i].extras() > 0 && !bytes.Contains(passSrc, []byte("//kage:unit pixels")) {
+			return &LoadError{File: file, Err: fmt.Errorf("pass %d binds extra images and must use //kage:unit pixels", i)}
+		}
+	}
+	return nil
+}
+
+// parseUserShaderInfo builds the ShaderInfo for a user shader from its
+// sidecar JSON. An empty sidecar yields defaults named after the file.
+// Also returns the source file of each declared pass, empty where the
+// pass uses the shader's own source; pass sources are loaded separately.
+func parseUserShaderInfo(base string, sidecar []byte) (ShaderInfo, []string, error) {
+	var sc userShaderSidecar
+	if len(sidecar) > 0 {
+		if err := json.Unmarshal(sidecar, &sc); err != nil {
+			return ShaderInfo{}, nil, fmt.Errorf("invalid sidecar: %w", err)
+		}
+	}
+
+	info := ShaderInfo{
+		ID:          UserShaderPrefix + base,
+		Name:        sc.Name,
+		Description: sc.Description,
+		Weight:      sc.Weight,
+		User:        true,
+	}
+	if info.Name == "" {
+		info.Name = base
+	}
+	if info.Description == "" {
+		info.Description = "User shader"
+	}
+
+	switch sc.Context {
+	case "", "all":
+		info.Context = ContextAll
+	case "game":
+		info.Context = ContextGame
+	case "ui":
+		info.Context = ContextUI
+	default:
+		return ShaderInfo{}, nil, fmt.Errorf("invalid context %q (valid: all, game, ui)", sc.Context)
+	}
+
+	seen := make(map[string]bool, len(sc.Params))
+	for _, p := range sc.Params {
+		if err := validateParam(p); err != nil {
+			return ShaderInfo{}, nil, err
+		}
+		if seen[p.Uniform] {
+			return ShaderInfo{}, nil, fmt.Errorf("duplicate param %q", p.Uniform)
+		}
+		seen[p.Uniform] = true
+		if p.Label == "" {
+			p.Label = p.Uniform
+		}
+		info.Params = append(info.Params, p)
+	}
+
+	var passFiles []string
+	for i, up := range sc.Passes {
+		pass, err := parsePass(i, up)
+		if err != nil {
+			return ShaderInfo{}, nil, err
+		}
+		info.Passes = append(info.Passes, pass)
+		passFiles = append(passFiles, up.File)
+	}
+
+	return info, passFiles, nil
+}
+
+// parsePass converts and checks the sidecar entry for pass index
+func parsePass(index int, up userPass) (PassInfo, error) {
+	pass := PassInfo{
+		Scale:    up.Scale,
+		Inputs:   up.Inputs,
+		History:  up.History,
+		Feedback: up.Feedback,
+	}
+
+	if up.File != "" {
+		if filepath.Base(up.File) != up.File || !strings.HasSuffix(strings.ToLower(up.File), userPassSuffix) {
+			return PassInfo{}, fmt.Errorf("pass %d: file %q must be a %s file in the shaders directory", index, up.File, userPassSuffix)
+		}
+	}
+
+	if up.Scale < 0 || up.Scale > maxUserPassScale {
+		return PassInfo{}, fmt.Errorf("pass %d: scale %v outside range (0, %d]", index, up.Scale, maxUserPassScale)
+	}
+
+	switch up.Filter {
+	case "", "nearest":
+		pass.Filter = FilterNearest
+	case "linear":
+		pass.Filter = FilterLinear
+	default:
+		return PassInfo{}, fmt.Errorf("pass %d: invalid filter %q (valid: nearest, linear)", index, up.Filter)
+	}
+
+	for _, in := range up.Inputs {
+		if in < PassOriginal || in >= index {
+			return PassInfo{}, fmt.Errorf("pass %d: input %d must be an earlier pass or %d for the original", index, in, PassOriginal)
+		}
+	}
+	if up.History < 0 || up.History > MaxPassHistory {
+		return PassInfo{}, fmt.Errorf("pass %d: history %d outside range [0, %d]", index, up.History, MaxPassHistory)
+	}
+	if pass.extras() > MaxPassExtras {
+		return PassInfo{}, fmt.Errorf("pass %d: binds %d extra images, at most %d allowed", index, pass.extras(), MaxPassExtras)
+	}
+
+	return pass, nil
+}
+
+// validateParam checks that a parameter names an exported uniform and
+// has a sensible range.
+func validateParam(p ShaderParam) error {
+	if p.Uniform == "" {
+		return fmt.Errorf("param missing uniform name")
+	}
+	if r := []rune(p.Uniform); !unicode.IsUpper(r[0]) {
+		return fmt.Errorf("param %q: uniform must be exported (start with an upper-case letter)", p.Uniform)
+	}
+	if p.Min >= p.Max {
+		return fmt.Errorf("param %q: min %v must be less than max %v", p.Uniform, p.Min, p.Max)
+	}
+	if p.Default < p.Min || p.Default > p.Max {
+		return fmt.Errorf("param %q: default %v outside range [%v, %v]", p.Uniform, p.Default, p.Min, p.Max)
+	}
+	if p.Step < 0 {
+		return fmt.Errorf("param %q: step must not be negative", p.Uniform)
+	}
+	return nil
+}
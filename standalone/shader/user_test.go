@@ -0,0 +1,172 @@
+package shader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseUserShaderInfoDefaults(t *testing.T) {
+	info, _, err := parseUserShaderInfo("warm", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.ID != "user:warm" {
+		t.Errorf("ID: got %q, want %q", info.ID, "user:warm")
+	}
+	if info.Name != "warm" {
+		t.Errorf("Name: got %q, want %q", info.Name, "warm")
+	}
+	if info.Context != ContextAll {
+		t.Errorf("Context: got %d, want ContextAll", info.Context)
+	}
+	if !info.User {
+		t.Error("User: got false, want true")
+	}
+}
+
+func TestParseUserShaderInfoSidecar(t *testing.T) {
+	sidecar := []byte(`{
+		"name": "Warm Tint",
+		"description": "Shifts colors warmer",
+		"weight": 600,
+		"context": "game",
+		"params": [
+			{"uniform": "Strength", "min": 0, "max": 1, "default": 0.5, "step": 0.1}
+		]
+	}`)
+	info, _, err := parseUserShaderInfo("warm", sidecar)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Name != "Warm Tint" || info.Weight != 600 || info.Context != ContextGame {
+		t.Errorf("got %+v", info)
+	}
+	if len(info.Params) != 1 {
+		t.Fatalf("Params: got %d, want 1", len(info.Params))
+	}
+	if info.Params[0].Label != "Strength" {
+		t.Errorf("Label should default to uniform name, got %q", info.Params[0].Label)
+	}
+}
+
+func TestParseUserShaderInfoErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sidecar string
+	}{
+		{"bad json", `{`},
+		{"bad context", `{"context": "everywhere"}`},
+		{"missing uniform", `{"params": [{"min": 0, "max": 1}]}`},
+		{"unexported uniform", `{"params": [{"uniform": "strength", "min": 0, "max": 1}]}`},
+		{"empty range", `{"params": [{"uniform": "A", "min": 1, "max": 1, "default": 1}]}`},
+		{"default out of range", `{"params": [{"uniform": "A", "min": 0, "max": 1, "default": 2}]}`},
+		{"negative step", `{"params": [{"uniform": "A", "min": 0, "max": 1, "step": -1}]}`},
+		{"duplicate uniform", `{"params": [{"uniform": "A", "min": 0, "max": 1}, {"uniform": "A", "min": 0, "max": 1}]}`},
+		{"pass file not a pass", `{"passes": [{"file": "other.kage"}]}`},
+		{"pass file in subdir", `{"passes": [{"file": "sub/a.pass.kage"}]}`},
+		{"negative scale", `{"passes": [{"scale": -1}]}`},
+		{"huge scale", `{"passes": [{"scale": 16}]}`},
+		{"bad filter", `{"passes": [{"filter": "cubic"}]}`},
+		{"forward input", `{"passes": [{"inputs": [0]}]}`},
+		{"too much history", `{"passes": [{"history": 4}]}`},
+		{"too many extras", `{"passes": [{}, {"inputs": [-1, 0], "history": 1, "feedback": true}]}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := parseUserShaderInfo("x", []byte(tc.sidecar)); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseUserShaderInfoPasses(t *testing.T) {
+	sidecar := []byte(`{
+		"passes": [
+			{"file": "glow.pass.kage", "scale": 0.5, "filter": "linear"},
+			{"inputs": [-1, 0], "history": 1}
+		]
+	}`)
+	info, passFiles, err := parseUserShaderInfo("royale", sidecar)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(info.Passes) != 2 {
+		t.Fatalf("Passes: got %d, want 2", len(info.Passes))
+	}
+	if info.Passes[0].Scale != 0.5 || info.Passes[0].Filter != FilterLinear {
+		t.Errorf("pass 0: got %+v", info.Passes[0])
+	}
+	if p := info.Passes[1]; len(p.Inputs) != 2 || p.Inputs[0] != PassOriginal || p.History != 1 {
+		t.Errorf("pass 1: got %+v", p)
+	}
+	if len(passFiles) != 2 || passFiles[0] != "glow.pass.kage" || passFiles[1] != "" {
+		t.Errorf("passFiles: got %q", passFiles)
+	}
+}
+
+func TestScanUserShaders(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b.kage", "package main")
+	write("a.kage", "package main")
+	write("a.json", `{"name": "Alpha"}`)
+	write("broken.kage", "package main")
+	write("broken.json", `{"context": "nope"}`)
+	write("notes.txt", "ignored")
+	write("c.kage", "//kage:unit pixels\npackage main")
+	write("c.json", `{"passes": [{"file": "c-blur.pass.kage"}, {"inputs": [0]}]}`)
+	write("c-blur.pass.kage", "package main")
+	write("d.kage", "package main")
+	write("d.json", `{"passes": [{"feedback": true}]}`)
+
+	shaders, errs := scanUserShaders(dir)
+	if len(shaders) != 3 {
+		t.Fatalf("shaders: got %d, want 3", len(shaders))
+	}
+	if shaders[0].info.Name != "Alpha" || shaders[1].info.ID != "user:b" {
+		t.Errorf("unexpected order or names: %q, %q", shaders[0].info.Name, shaders[1].info.ID)
+	}
+	if c := shaders[2].info; string(c.Passes[0].Source) != "package main" || c.Passes[1].Source != nil {
+		t.Errorf("c passes: got %+v", c.Passes)
+	}
+
+	// broken.json fails to parse; d's feedback pass needs pixel units
+	if len(errs) != 2 || errs[0].File != "broken.json" || errs[1].File != "d.kage" {
+		t.Errorf("errors: got %v, want broken.json and d.kage", errs)
+	}
+}
+
+func TestScanUserShadersMissingDir(t *testing.T) {
+	shaders, errs := scanUserShaders(filepath.Join(t.TempDir(), "missing"))
+	if shaders != nil || errs != nil {
+		t.Errorf("missing dir: got %v, %v, want nil, nil", shaders, errs)
+	}
+}
+
+func TestParamValue(t *testing.T) {
+	p := ShaderParam{Uniform: "Strength", Min: 0, Max: 1, Default: 0.5}
+	params := map[string]map[string]float64{
+		"user:a": {"Strength": 0.25},
+		"user:b": {"Strength": 4},
+	}
+
+	if v := ParamValue("user:a", p, params); v != 0.25 {
+		t.Errorf("set value: got %v, want 0.25", v)
+	}
+	if v := ParamValue("user:b", p, params); v != 1 {
+		t.Errorf("clamped value: got %v, want 1", v)
+	}
+	if v := ParamValue("user:c", p, params); v != 0.5 {
+		t.Errorf("default value: got %v, want 0.5", v)
+	}
+	if v := ParamValue("user:a", p, nil); v != 0.5 {
+		t.Errorf("nil params: got %v, want 0.5", v)
+	}
+}
@@ -24,7 +24,7 @@ type XBRScaler struct {
 	screenBuffer  *ebiten.Image
 }
 
-// SetAspectRatioMode sets the aspect ratio scaling mode ("dar", "4:3", "1:1", "stretch").
+// SetAspectRatioMode sets the aspect ratio scaling mode (see storage.ValidAspectRatios).
 func (x *XBRScaler) SetAspectRatioMode(mode string) {
 	x.aspectRatioMode = mode
 }
@@ -72,7 +72,7 @@ func (x *XBRScaler) Apply(src *ebiten.Image, screenW, screenH int) *ebiten.Image
 	}
 
 	// Scale final xBR output to screen with centering
-	x.drawToScreenBuffer(currentInput, screenW, screenH)
+	x.drawToScreenBuffer(currentInput, srcW, srcH, screenW, screenH)
 
 	return x.screenBuffer
 }
@@ -200,6 +200,7 @@ func (x *XBRScaler) scaleToScreen(src *ebiten.Image, screenW, screenH int) *ebit
 
 	displayW, displayH := display.Size(x.aspectRatioMode, screenW, screenH, int(srcW), int(srcH), x.par)
 	scaleX, scaleY, offsetX, offsetY := display.ScaleAndCenter(displayW, displayH, srcW, srcH, screenW, screenH)
+	offsetX, offsetY = display.SnapOffsets(x.aspectRatioMode, offsetX, offsetY)
 
 	screenBuffer := ebiten.NewImage(screenW, screenH)
 	drawOp := &ebiten.DrawImageOptions{}
@@ -213,12 +214,15 @@ func (x *XBRScaler) scaleToScreen(src *ebiten.Image, screenW, screenH int) *ebit
 
 // drawToScreenBuffer scales src to the pooled screen buffer using the
 // dynamically computed display aspect ratio, centered in the screen area.
-func (x *XBRScaler) drawToScreenBuffer(src *ebiten.Image, screenW, screenH int) {
+// The display size is computed from the native dimensions so integer
+// modes pick multiples of the original resolution, not the xBR output.
+func (x *XBRScaler) drawToScreenBuffer(src *ebiten.Image, nativeW, nativeH, screenW, screenH int) {
 	srcW := float64(src.Bounds().Dx())
 	srcH := float64(src.Bounds().Dy())
 
-	displayW, displayH := display.Size(x.aspectRatioMode, screenW, screenH, int(srcW), int(srcH), x.par)
+	displayW, displayH := display.Size(x.aspectRatioMode, screenW, screenH, nativeW, nativeH, x.par)
 	scaleX, scaleY, offsetX, offsetY := display.ScaleAndCenter(displayW, displayH, srcW, srcH, screenW, screenH)
+	offsetX, offsetY = display.SnapOffsets(x.aspectRatioMode, offsetX, offsetY)
 
 	drawOp := &ebiten.DrawImageOptions{}
 	drawOp.GeoM.Scale(scaleX, scaleY)
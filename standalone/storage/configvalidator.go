@@ -35,6 +35,12 @@ func detectPresentKeys(jsonBytes []byte) map[string]bool {
 			if _, ok := audio["fastForwardMute"]; ok {
 				present["audio.fastForwardMute"] = true
 			}
+			if _, ok := audio["latency"]; ok {
+				present["audio.latency"] = true
+			}
+			if _, ok := audio["latencyMs"]; ok {
+				present["audio.latencyMs"] = true
+			}
 		}
 	}
 
@@ -115,6 +121,12 @@ func ApplyMissingDefaults(config *Config, presentKeys map[string]bool) {
 	if !presentKeys["audio.fastForwardMute"] {
 		config.Audio.FastForwardMute = defaults.Audio.FastForwardMute
 	}
+	if !presentKeys["audio.latency"] {
+		config.Audio.Latency = defaults.Audio.Latency
+	}
+	if !presentKeys["audio.latencyMs"] {
+		config.Audio.LatencyMs = defaults.Audio.LatencyMs
+	}
 	if !presentKeys["window.width"] {
 		config.Window.Width = defaults.Window.Width
 	}
@@ -187,6 +199,23 @@ func ValidateConfig(config *Config, validThemes []string) []string {
 		errors = append(errors, fmt.Sprintf("audio.volume: %.2f (valid: 0.0-2.0)", config.Audio.Volume))
 	}
 
+	// audio.latency
+	latencyValid := false
+	for _, l := range ValidAudioLatencies {
+		if config.Audio.Latency == l {
+			latencyValid = true
+			break
+		}
+	}
+	if !latencyValid {
+		errors = append(errors, fmt.Sprintf("audio.latency: %q (valid: %v)", config.Audio.Latency, ValidAudioLatencies))
+	}
+
+	// audio.latencyMs
+	if config.Audio.LatencyMs < AudioLatencyMinMs || config.Audio.LatencyMs > AudioLatencyMaxMs {
+		errors = append(errors, fmt.Sprintf("audio.latencyMs: %d (valid: %d-%d)", config.Audio.LatencyMs, AudioLatencyMinMs, AudioLatencyMaxMs))
+	}
+
 	// window.width
 	if config.Window.Width < 900 {
 		errors = append(errors, fmt.Sprintf("window.width: %d (valid: >= 900)", config.Window.Width))
@@ -217,6 +246,17 @@ func ValidateConfig(config *Config, validThemes []string) []string {
 		errors = append(errors, fmt.Sprintf("rewind.frameStep: %d (valid: 1-10)", config.Rewind.FrameStep))
 	}
 
+	// shaders.presets: names must be non-empty and unique
+	seenPresets := make(map[string]bool)
+	for i, p := range config.Shaders.Presets {
+		if p.Name == "" {
+			errors = append(errors, fmt.Sprintf("shaders.presets[%d]: empty name", i))
+		} else if seenPresets[p.Name] {
+			errors = append(errors, fmt.Sprintf("shaders.presets[%d]: duplicate name %q", i, p.Name))
+		}
+		seenPresets[p.Name] = true
+	}
+
 	return errors
 }
 
@@ -304,6 +344,23 @@ func CorrectConfig(config *Config, validThemes []string) *Config {
 		config.Audio.Volume = defaults.Audio.Volume
 	}
 
+	// audio.latency
+	latencyValid := false
+	for _, l := range ValidAudioLatencies {
+		if config.Audio.Latency == l {
+			latencyValid = true
+			break
+		}
+	}
+	if !latencyValid {
+		config.Audio.Latency = defaults.Audio.Latency
+	}
+
+	// audio.latencyMs
+	if config.Audio.LatencyMs < AudioLatencyMinMs || config.Audio.LatencyMs > AudioLatencyMaxMs {
+		config.Audio.LatencyMs = defaults.Audio.LatencyMs
+	}
+
 	// window.width
 	if config.Window.Width < 900 {
 		config.Window.Width = defaults.Window.Width
@@ -334,5 +391,17 @@ func CorrectConfig(config *Config, validThemes []string) *Config {
 		config.Rewind.FrameStep = defaults.Rewind.FrameStep
 	}
 
+	// shaders.presets: drop unnamed and duplicate presets (first one wins)
+	presets := config.Shaders.Presets[:0]
+	seenPresets := make(map[string]bool)
+	for _, p := range config.Shaders.Presets {
+		if p.Name == "" || seenPresets[p.Name] {
+			continue
+		}
+		seenPresets[p.Name] = true
+		presets = append(presets, p)
+	}
+	config.Shaders.Presets = presets
+
 	return config
 }
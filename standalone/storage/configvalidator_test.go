@@ -432,8 +432,8 @@ func TestValidateConfig(t *testing.T) {
 			Rewind:   RewindConfig{BufferSizeMB: 0, FrameStep: 0},
 		}
 		errs := ValidateConfig(config, validTestThemes)
-		if len(errs) != 11 {
-			t.Errorf("expected 11 errors, got %d: %v", len(errs), errs)
+		if len(errs) != 13 {
+			t.Errorf("expected 13 errors, got %d: %v", len(errs), errs)
 		}
 	})
 
@@ -915,3 +915,144 @@ func TestPresentButZeroVsMissing(t *testing.T) {
 		}
 	})
 }
+
+func TestAudioLatencyValidation(t *testing.T) {
+	t.Run("invalid latency mode", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Audio.Latency = "ultra"
+		errs := ValidateConfig(config, validTestThemes)
+		if len(errs) != 1 {
+			t.Fatalf("expected 1 error, got: %v", errs)
+		}
+		CorrectConfig(config, validTestThemes)
+		if config.Audio.Latency != "normal" {
+			t.Errorf("latency should be corrected to normal, got %q", config.Audio.Latency)
+		}
+	})
+
+	t.Run("latencyMs out of range", func(t *testing.T) {
+		config := DefaultConfig()
+		config.Audio.LatencyMs = AudioLatencyMaxMs + 1
+		errs := ValidateConfig(config, validTestThemes)
+		if len(errs) != 1 {
+			t.Fatalf("expected 1 error, got: %v", errs)
+		}
+		CorrectConfig(config, validTestThemes)
+		if config.Audio.LatencyMs != 50 {
+			t.Errorf("latencyMs should be corrected to 50, got %d", config.Audio.LatencyMs)
+		}
+	})
+
+	t.Run("missing keys get defaults", func(t *testing.T) {
+		jsonBytes := []byte(`{"audio": {"volume": 0.5}}`)
+		config := &Config{}
+		if err := json.Unmarshal(jsonBytes, config); err != nil {
+			t.Fatal(err)
+		}
+		ApplyMissingDefaults(config, detectPresentKeys(jsonBytes))
+		if config.Audio.Latency != "normal" || config.Audio.LatencyMs != 50 {
+			t.Errorf("expected normal/50, got %q/%d", config.Audio.Latency, config.Audio.LatencyMs)
+		}
+	})
+}
+
+func TestAudioLatencyMs(t *testing.T) {
+	tests := []struct {
+		audio AudioConfig
+		want  int
+	}{
+		{AudioConfig{Latency: "low"}, 32},
+		{AudioConfig{Latency: "normal"}, 50},
+		{AudioConfig{Latency: "safe"}, 100},
+		{AudioConfig{Latency: "custom", LatencyMs: 70}, 70},
+		{AudioConfig{Latency: "custom", LatencyMs: 1}, AudioLatencyMinMs},
+		{AudioConfig{Latency: "custom", LatencyMs: 9999}, AudioLatencyMaxMs},
+		{AudioConfig{Latency: "bogus"}, 50},
+	}
+	for _, tt := range tests {
+		if got := AudioLatencyMs(tt.audio); got != tt.want {
+			t.Errorf("AudioLatencyMs(%+v) = %d, want %d", tt.audio, got, tt.want)
+		}
+	}
+}
+
+func TestShaderPresetValidation(t *testing.T) {
+	config := DefaultConfig()
+	config.Shaders.Presets = []ShaderPreset{
+		{Name: "CRT", Shaders: []string{"crt"}},
+		{Name: "", Shaders: []string{"lcd"}},
+		{Name: "CRT", Shaders: []string{"scanlines"}},
+		{Name: "LCD", Shaders: []string{"lcd"}},
+	}
+
+	errs := ValidateConfig(config, validTestThemes)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got: %v", errs)
+	}
+
+	CorrectConfig(config, validTestThemes)
+	if len(config.Shaders.Presets) != 2 {
+		t.Fatalf("expected 2 presets after correction, got %d", len(config.Shaders.Presets))
+	}
+	if config.Shaders.Presets[0].Shaders[0] != "crt" || config.Shaders.Presets[1].Name != "LCD" {
+		t.Errorf("unexpected presets after correction: %+v", config.Shaders.Presets)
+	}
+	if errs := ValidateConfig(config, validTestThemes); len(errs) != 0 {
+		t.Errorf("expected no errors after correction, got: %v", errs)
+	}
+}
+
+func TestFindPreset(t *testing.T) {
+	cfg := ShaderConfig{Presets: []ShaderPreset{{Name: "CRT"}, {Name: "LCD"}}}
+	if p := cfg.FindPreset("LCD"); p == nil || p.Name != "LCD" {
+		t.Errorf("FindPreset(LCD) = %v", p)
+	}
+	if p := cfg.FindPreset("missing"); p != nil {
+		t.Errorf("FindPreset(missing) = %v, want nil", p)
+	}
+	if p := cfg.FindPreset(""); p != nil {
+		t.Errorf("FindPreset(\"\") = %v, want nil", p)
+	}
+}
+
+func TestShaderConfigLoadPreset(t *testing.T) {
+	var cfg ShaderConfig
+	cfg.SetParam("crt", "Curvature", 0.9)
+	cfg.SetParam("bloom", "Strength", 0.7)
+
+	cfg.LoadPreset(ShaderPreset{
+		Name:    "CRT",
+		Shaders: []string{"crt", "scanlines"},
+		Params: map[string]map[string]float64{
+			"crt":       {"Curvature": 0.2, "Vignette": 0.5},
+			"scanlines": {"Intensity": 0.4},
+		},
+	})
+
+	if len(cfg.GameShaders) != 2 || cfg.GameShaders[0] != "crt" || cfg.GameShaders[1] != "scanlines" {
+		t.Errorf("GameShaders = %v", cfg.GameShaders)
+	}
+	if cfg.Params["crt"]["Curvature"] != 0.2 || cfg.Params["crt"]["Vignette"] != 0.5 {
+		t.Errorf("crt params: got %v", cfg.Params["crt"])
+	}
+	if cfg.Params["scanlines"]["Intensity"] != 0.4 {
+		t.Errorf("scanlines params: got %v", cfg.Params["scanlines"])
+	}
+	if cfg.Params["bloom"]["Strength"] != 0.7 {
+		t.Errorf("bloom params changed: got %v", cfg.Params["bloom"])
+	}
+}
+
+func TestShaderConfigSetParam(t *testing.T) {
+	var cfg ShaderConfig
+	cfg.SetParam("crt", "Curvature", 0.2)
+	cfg.SetParam("crt", "Vignette", 0.5)
+	cfg.SetParam("scanlines", "Intensity", 0.4)
+
+	if cfg.Params["crt"]["Curvature"] != 0.2 || cfg.Params["crt"]["Vignette"] != 0.5 {
+		t.Errorf("crt params: got %v", cfg.Params["crt"])
+	}
+	if cfg.Params["scanlines"]["Intensity"] != 0.4 {
+		t.Errorf("scanlines params: got %v", cfg.Params["scanlines"])
+	}
+}
@@ -3,9 +3,13 @@ package storage
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/user-none/eblitui/rdb"
 )
 
 // LoadLibrary loads the library from library.json.
@@ -116,6 +120,58 @@ func (lib *Library) RemoveGame(gameCRC string) {
 	}
 }
 
+// AddPatchedGame adds a patched variant of base keyed by the patched
+// ROM's CRC32. The variant uses base's file and patches followed by patch,
+// and copies its metadata with the patch named in DisplayName. An existing
+// entry with the same CRC32 is returned unchanged.
+func (lib *Library) AddPatchedGame(base *GameEntry, patch, crc string) *GameEntry {
+	if existing := lib.GetGame(crc); existing != nil {
+		return existing
+	}
+
+	label := PatchLabel(base.File, patch)
+	entry := &GameEntry{
+		CRC32:       crc,
+		File:        base.File,
+		Patches:     append(append([]string(nil), base.Patches...), patch),
+		Name:        base.Name,
+		DisplayName: base.DisplayName + " [" + label + "]",
+		Region:      base.Region,
+		Developer:   base.Developer,
+		Publisher:   base.Publisher,
+		Genre:       base.Genre,
+		Franchise:   base.Franchise,
+		ESRBRating:  base.ESRBRating,
+		ReleaseDate: base.ReleaseDate,
+		System:      base.System,
+		ConsoleID:   base.ConsoleID,
+		Added:       time.Now().Unix(),
+	}
+	lib.AddGame(entry)
+	return entry
+}
+
+// PatchLabel names a patched variant of a ROM after its patch file, or
+// "Patched" when the patch shares the ROM's name (game.ips for game.sms).
+// Both may be "archive#entry" paths.
+func PatchLabel(rom, patch string) string {
+	label := patchBaseName(patch)
+	if strings.EqualFold(label, patchBaseName(rom)) {
+		return "Patched"
+	}
+	return label
+}
+
+// patchBaseName returns the file name of path without its extension,
+// using the entry name of "archive#entry" paths.
+func patchBaseName(path string) string {
+	name := filepath.Base(path)
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
 // GameCount returns the number of games in the library
 func (lib *Library) GameCount() int {
 	if lib.Games == nil {
@@ -260,8 +316,40 @@ func (lib *Library) UpdatePlayTime(gameCRC string, secondsPlayed int64) {
 	}
 }
 
+// rdbGame returns the RDB metadata stored in the entry for matching queries
+func (e *GameEntry) rdbGame() *rdb.Game {
+	g := &rdb.Game{
+		Name:       e.Name,
+		Developer:  e.Developer,
+		Publisher:  e.Publisher,
+		Genre:      e.Genre,
+		Franchise:  e.Franchise,
+		ESRBRating: e.ESRBRating,
+	}
+	if crc, err := strconv.ParseUint(e.CRC32, 16, 32); err == nil {
+		g.CRC32 = uint32(crc)
+	}
+
+	// ReleaseDate is "Month Year" or "Year"
+	if fields := strings.Fields(e.ReleaseDate); len(fields) > 0 {
+		if year, err := strconv.ParseUint(fields[len(fields)-1], 10, 32); err == nil {
+			g.ReleaseYear = uint(year)
+		}
+		if len(fields) == 2 {
+			for m := time.January; m <= time.December; m++ {
+				if fields[0] == m.String() {
+					g.ReleaseMonth = uint(m)
+				}
+			}
+		}
+	}
+	return g
+}
+
 // GetGamesSortedFiltered returns a sorted slice of game entries filtered by search text.
 // Search is case-insensitive and matches against DisplayName and Name fields.
+// Search text that is an RDB query, e.g. {"developer":"Sega","releaseyear":1991},
+// instead matches the metadata stored in each entry.
 // Empty searchText returns all games (same as GetGamesSorted).
 func (lib *Library) GetGamesSortedFiltered(sortBy string, favoritesOnly bool, searchText string) []*GameEntry {
 	if lib.Games == nil {
@@ -271,13 +359,23 @@ func (lib *Library) GetGamesSortedFiltered(sortBy string, favoritesOnly bool, se
 	// Normalize search text for case-insensitive matching
 	searchLower := strings.ToLower(searchText)
 
+	// Text that isn't a complete query (e.g. still being typed) is plain text
+	var query *rdb.Query
+	if strings.HasPrefix(strings.TrimSpace(searchText), "{") {
+		query, _ = rdb.ParseQuery(searchText)
+	}
+
 	games := make([]*GameEntry, 0, len(lib.Games))
 	for _, game := range lib.Games {
 		if favoritesOnly && !game.Favorite {
 			continue
 		}
 		// Apply search filter if search text is provided
-		if searchText != "" {
+		if query != nil {
+			if !query.Match(game.rdbGame()) {
+				continue
+			}
+		} else if searchText != "" {
 			displayLower := strings.ToLower(game.DisplayName)
 			nameLower := strings.ToLower(game.Name)
 			if !strings.Contains(displayLower, searchLower) && !strings.Contains(nameLower, searchLower) {
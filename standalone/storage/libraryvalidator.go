@@ -28,7 +28,21 @@ func SanitizeLibraryEntries(lib *Library) {
 		if game.Settings.SaveSlot < 0 || game.Settings.SaveSlot > 9 {
 			game.Settings.SaveSlot = 0
 		}
+
+		// crop: each side must be 0-MaxCrop
+		game.Settings.CropTop = sanitizeCrop(game.Settings.CropTop)
+		game.Settings.CropBottom = sanitizeCrop(game.Settings.CropBottom)
+		game.Settings.CropLeft = sanitizeCrop(game.Settings.CropLeft)
+		game.Settings.CropRight = sanitizeCrop(game.Settings.CropRight)
+	}
+}
+
+// sanitizeCrop resets out-of-range crop values to zero.
+func sanitizeCrop(v int) int {
+	if v < 0 || v > MaxCrop {
+		return 0
 	}
+	return v
 }
 
 // ValidateLibrary checks library-level fields against valid ranges and returns
@@ -198,6 +198,29 @@ func TestSanitizeLibraryEntries(t *testing.T) {
 		}
 	})
 
+	t.Run("out of range crop reset to 0", func(t *testing.T) {
+		lib := DefaultLibrary()
+		lib.AddGame(&GameEntry{CRC32: "1", Settings: GameSettings{
+			CropTop: -1, CropBottom: MaxCrop + 1, CropLeft: 8, CropRight: MaxCrop,
+		}})
+
+		SanitizeLibraryEntries(lib)
+
+		s := lib.Games["1"].Settings
+		if s.CropTop != 0 {
+			t.Errorf("cropTop: expected 0, got %d", s.CropTop)
+		}
+		if s.CropBottom != 0 {
+			t.Errorf("cropBottom: expected 0, got %d", s.CropBottom)
+		}
+		if s.CropLeft != 8 {
+			t.Errorf("cropLeft: expected 8, got %d", s.CropLeft)
+		}
+		if s.CropRight != MaxCrop {
+			t.Errorf("cropRight: expected %d, got %d", MaxCrop, s.CropRight)
+		}
+	})
+
 	t.Run("multiple entries sanitized", func(t *testing.T) {
 		lib := DefaultLibrary()
 		lib.AddGame(&GameEntry{CRC32: "1", PlayTimeSeconds: -1})
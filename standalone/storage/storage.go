@@ -24,6 +24,7 @@ const (
 	rumbleDir     = "rumble"
 	savesDir      = "saves"
 	screenshotDir = "screenshots"
+	shadersDir    = "shaders"
 )
 
 // GetBaseDir returns the base directory for application data.
@@ -78,6 +79,7 @@ func EnsureDirectories() error {
 		filepath.Join(baseDir, rumbleDir),
 		filepath.Join(baseDir, savesDir),
 		filepath.Join(baseDir, screenshotDir),
+		filepath.Join(baseDir, shadersDir),
 	}
 
 	for _, dir := range dirs {
@@ -161,6 +163,15 @@ func GetScreenshotDir() (string, error) {
 	return filepath.Join(baseDir, screenshotDir), nil
 }
 
+// GetShadersDir returns the full path to the user shaders directory
+func GetShadersDir() (string, error) {
+	baseDir, err := GetBaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(baseDir, shadersDir), nil
+}
+
 // GetGameSaveDir returns the save directory for a specific game (by CRC32)
 func GetGameSaveDir(gameCRC string) (string, error) {
 	savesDir, err := GetSavesDir()
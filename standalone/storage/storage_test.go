@@ -443,6 +443,68 @@ func TestUpdatePlayTime(t *testing.T) {
 	}
 }
 
+func TestAddPatchedGame(t *testing.T) {
+	lib := DefaultLibrary()
+	base := &GameEntry{
+		CRC32:       "11111111",
+		File:        "/roms/Game (Japan).sms",
+		Name:        "Game (Japan)",
+		DisplayName: "Game",
+		Favorite:    true,
+	}
+	lib.AddGame(base)
+
+	patched := lib.AddPatchedGame(base, "/patches/English Translation.ips", "22222222")
+	if lib.GetGame("22222222") != patched {
+		t.Fatal("patched game not added")
+	}
+	if patched.File != base.File {
+		t.Errorf("File = %q, want %q", patched.File, base.File)
+	}
+	if len(patched.Patches) != 1 || patched.Patches[0] != "/patches/English Translation.ips" {
+		t.Errorf("Patches = %v", patched.Patches)
+	}
+	if patched.DisplayName != "Game [English Translation]" {
+		t.Errorf("DisplayName = %q", patched.DisplayName)
+	}
+	if patched.Favorite {
+		t.Error("user data should not be copied to the patched game")
+	}
+
+	// Patches stack on an already patched game
+	again := lib.AddPatchedGame(patched, "/patches/fix.bps", "33333333")
+	if len(again.Patches) != 2 || again.Patches[1] != "/patches/fix.bps" {
+		t.Errorf("Patches = %v", again.Patches)
+	}
+	if len(patched.Patches) != 1 {
+		t.Error("base patches were modified")
+	}
+
+	// Existing entries are returned unchanged
+	if got := lib.AddPatchedGame(base, "/patches/other.ips", "22222222"); got != patched {
+		t.Error("existing entry was replaced")
+	}
+}
+
+func TestPatchLabel(t *testing.T) {
+	tests := []struct {
+		rom   string
+		patch string
+		want  string
+	}{
+		{"/roms/game.sms", "/roms/game.ips", "Patched"},
+		{"/roms/game.zip", "/roms/GAME.bps", "Patched"},
+		{"/roms/game.sms", "/roms/Translation.ups", "Translation"},
+		{"/roms/set.zip#Game A.sms", "/roms/set.zip#Game A.ips", "Patched"},
+		{"/roms/set.zip", "/roms/set.zip#Hack.ips", "Hack"},
+	}
+	for _, tc := range tests {
+		if got := PatchLabel(tc.rom, tc.patch); got != tc.want {
+			t.Errorf("PatchLabel(%q, %q) = %q, want %q", tc.rom, tc.patch, got, tc.want)
+		}
+	}
+}
+
 func TestGetGamesSortedFiltered(t *testing.T) {
 	lib := DefaultLibrary()
 
@@ -518,6 +580,35 @@ func TestGetGamesSortedFiltered(t *testing.T) {
 	}
 }
 
+func TestGetGamesSortedFilteredQuery(t *testing.T) {
+	lib := DefaultLibrary()
+
+	lib.AddGame(&GameEntry{CRC32: "f9394e97", DisplayName: "Sonic the Hedgehog", Name: "Sonic the Hedgehog (USA, Europe)", Developer: "Sega", ReleaseDate: "June 1991"})
+	lib.AddGame(&GameEntry{CRC32: "2", DisplayName: "Streets of Rage", Name: "Streets of Rage (World)", Developer: "Sega", ReleaseDate: "1991"})
+	lib.AddGame(&GameEntry{CRC32: "3", DisplayName: "Gunstar Heroes", Name: "Gunstar Heroes (USA)", Developer: "Treasure", ReleaseDate: "September 1993"})
+
+	games := lib.GetGamesSortedFiltered("title", false, `{"developer":"Sega","releaseyear":1991}`)
+	if len(games) != 2 || games[0].CRC32 != "f9394e97" || games[1].CRC32 != "2" {
+		t.Errorf("developer and year query matched %d games", len(games))
+	}
+
+	games = lib.GetGamesSortedFiltered("title", false, `{"releasemonth":9}`)
+	if len(games) != 1 || games[0].CRC32 != "3" {
+		t.Errorf("month query matched %d games", len(games))
+	}
+
+	games = lib.GetGamesSortedFiltered("title", false, `{"crc":b"F9394E97"}`)
+	if len(games) != 1 || games[0].CRC32 != "f9394e97" {
+		t.Errorf("crc query matched %d games", len(games))
+	}
+
+	// Incomplete queries are searched as plain text
+	games = lib.GetGamesSortedFiltered("title", false, `{"developer":"Se`)
+	if len(games) != 0 {
+		t.Errorf("incomplete query matched %d games", len(games))
+	}
+}
+
 func TestApplyMissingDefaultsAlreadyCurrent(t *testing.T) {
 	config := &Config{
 		Version: 1,
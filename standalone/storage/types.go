@@ -51,7 +51,7 @@ type VideoConfig struct {
 }
 
 // ValidAspectRatios lists the allowed aspect ratio mode values
-var ValidAspectRatios = []string{"dar", "4:3", "1:1", "stretch"}
+var ValidAspectRatios = []string{"dar", "4:3", "1:1", "stretch", "integer", "integer-1:1", "pixel-perfect"}
 
 // AspectRatioDisplayName returns a user-facing label for the given mode.
 func AspectRatioDisplayName(mode string) string {
@@ -64,6 +64,12 @@ func AspectRatioDisplayName(mode string) string {
 		return "1:1 (PAR)"
 	case "stretch":
 		return "Stretch"
+	case "integer":
+		return "Integer (DAR)"
+	case "integer-1:1":
+		return "Integer (1:1)"
+	case "pixel-perfect":
+		return "Pixel Perfect"
 	default:
 		return "Standard (DAR)"
 	}
@@ -71,8 +77,52 @@ func AspectRatioDisplayName(mode string) string {
 
 // ShaderConfig contains shader effect settings
 type ShaderConfig struct {
-	UIShaders   []string `json:"uiShaders"`   // Ordered list of shader IDs for UI context
-	GameShaders []string `json:"gameShaders"` // Ordered list of shader IDs for Game context
+	UIShaders   []string                      `json:"uiShaders"`         // Ordered list of shader IDs for UI context
+	GameShaders []string                      `json:"gameShaders"`       // Ordered list of shader IDs for Game context
+	Params      map[string]map[string]float64 `json:"params,omitempty"`  // Shader ID -> uniform -> value; unset uses the default
+	Presets     []ShaderPreset                `json:"presets,omitempty"` // Named game shader setups, selectable per game
+}
+
+// SetParam stores a shader parameter value, creating maps as needed
+func (s *ShaderConfig) SetParam(shaderID, uniform string, value float64) {
+	if s.Params == nil {
+		s.Params = make(map[string]map[string]float64)
+	}
+	if s.Params[shaderID] == nil {
+		s.Params[shaderID] = make(map[string]float64)
+	}
+	s.Params[shaderID][uniform] = value
+}
+
+// ShaderPreset is a named, ordered game shader list with parameter values
+type ShaderPreset struct {
+	Name    string                        `json:"name"`
+	Shaders []string                      `json:"shaders"`
+	Params  map[string]map[string]float64 `json:"params,omitempty"` // Shader ID -> uniform -> value
+}
+
+// FindPreset returns the preset with the given name, or nil if none exists
+func (s *ShaderConfig) FindPreset(name string) *ShaderPreset {
+	if name == "" {
+		return nil
+	}
+	for i := range s.Presets {
+		if s.Presets[i].Name == name {
+			return &s.Presets[i]
+		}
+	}
+	return nil
+}
+
+// LoadPreset makes the preset's shaders the Game shaders and restores the
+// parameter values saved with them
+func (s *ShaderConfig) LoadPreset(preset ShaderPreset) {
+	s.GameShaders = append([]string{}, preset.Shaders...)
+	for _, id := range preset.Shaders {
+		for uniform, value := range preset.Params[id] {
+			s.SetParam(id, uniform, value)
+		}
+	}
 }
 
 // RewindConfig contains rewind feature settings
@@ -86,7 +136,56 @@ type RewindConfig struct {
 type AudioConfig struct {
 	Volume          float64 `json:"volume"`
 	Muted           bool    `json:"muted"`
-	FastForwardMute bool    `json:"fastForwardMute"` // Mute audio during fast-forward (default: true)
+	FastForwardMute bool    `json:"fastForwardMute"`           // Mute audio during fast-forward (default: true)
+	Latency         string  `json:"latency"`                   // "low", "normal", "safe", "custom"
+	LatencyMs       int     `json:"latencyMs"`                 // Target latency when Latency is "custom"
+	ShowDiagnostics bool    `json:"showDiagnostics,omitempty"` // Show buffer/underrun overlay during gameplay
+}
+
+// Audio latency bounds in milliseconds for the "custom" setting
+const (
+	AudioLatencyMinMs = 20
+	AudioLatencyMaxMs = 250
+)
+
+// ValidAudioLatencies lists the allowed audio latency mode values
+var ValidAudioLatencies = []string{"low", "normal", "safe", "custom"}
+
+// AudioLatencyDisplayName returns a user-facing label for the given mode.
+func AudioLatencyDisplayName(mode string) string {
+	switch mode {
+	case "low":
+		return "Low"
+	case "normal":
+		return "Normal"
+	case "safe":
+		return "Safe"
+	case "custom":
+		return "Custom"
+	default:
+		return "Normal"
+	}
+}
+
+// AudioLatencyMs returns the target output latency in milliseconds for
+// the configured mode. Custom values are clamped to the allowed range.
+func AudioLatencyMs(audio AudioConfig) int {
+	switch audio.Latency {
+	case "low":
+		return 32
+	case "safe":
+		return 100
+	case "custom":
+		if audio.LatencyMs < AudioLatencyMinMs {
+			return AudioLatencyMinMs
+		}
+		if audio.LatencyMs > AudioLatencyMaxMs {
+			return AudioLatencyMaxMs
+		}
+		return audio.LatencyMs
+	default:
+		return 50
+	}
 }
 
 // WindowConfig contains window position and size
@@ -122,10 +221,11 @@ type ScanDirectory struct {
 // GameEntry represents a single game in the library
 type GameEntry struct {
 	CRC32           string       `json:"crc32"`
-	File            string       `json:"file"`        // Path to ROM file or archive on disk
-	Name            string       `json:"name"`        // Full No-Intro name from RDB
-	DisplayName     string       `json:"displayName"` // Cleaned name for display (region info removed)
-	Region          string       `json:"region"`      // "us", "eu", "jp" (from RDB)
+	File            string       `json:"file"`              // Path to ROM file or archive on disk
+	Patches         []string     `json:"patches,omitempty"` // Patch files applied to File in order; CRC32 is of the patched ROM
+	Name            string       `json:"name"`              // Full No-Intro name from RDB
+	DisplayName     string       `json:"displayName"`       // Cleaned name for display (region info removed)
+	Region          string       `json:"region"`            // "us", "eu", "jp" (from RDB)
 	Developer       string       `json:"developer,omitempty"`
 	Publisher       string       `json:"publisher,omitempty"`
 	Genre           string       `json:"genre,omitempty"`
@@ -146,8 +246,16 @@ type GameEntry struct {
 type GameSettings struct {
 	RegionOverride string `json:"regionOverride,omitempty"` // "", "ntsc", "pal"
 	SaveSlot       int    `json:"saveSlot,omitempty"`       // Last-used save state slot (0-9)
+	CropTop        int    `json:"cropTop,omitempty"`        // Overscan crop in native pixels (0-MaxCrop)
+	CropBottom     int    `json:"cropBottom,omitempty"`
+	CropLeft       int    `json:"cropLeft,omitempty"`
+	CropRight      int    `json:"cropRight,omitempty"`
+	ShaderPreset   string `json:"shaderPreset,omitempty"` // Preset name; "" uses the global game shaders
 }
 
+// MaxCrop is the largest per-side overscan crop, in native pixels
+const MaxCrop = 64
+
 // FontSizePresets lists the available font size options
 var FontSizePresets = []int{10, 12, 14, 16, 18, 20, 24, 28, 32}
 
@@ -182,6 +290,8 @@ func DefaultConfig() *Config {
 			Volume:          1.0,
 			Muted:           false,
 			FastForwardMute: true,
+			Latency:         "normal",
+			LatencyMs:       50,
 		},
 		Window: WindowConfig{
 			Width:  900,
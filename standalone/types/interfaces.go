@@ -4,6 +4,7 @@ package types
 
 import (
 	"github.com/ebitenui/ebitenui/widget"
+	"github.com/user-none/eblitui/romloader"
 )
 
 // Direction constants for navigation
@@ -43,6 +44,7 @@ type ScreenCallback interface {
 	GetMissingArtImageData() []byte    // Get raw missing-art image data (no artwork found)
 	GetMD5ByCRC32(crc32 uint32) string // Get MD5 hash from RDB by CRC32
 	GetExtensions() []string           // Get supported ROM file extensions
+	GetLoadOptions() romloader.Options // Get the options for loading the core's ROMs
 	ShowNotification(msg string)       // Show a brief notification message
 }
 